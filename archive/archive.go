@@ -1,6 +1,7 @@
 package archive
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -10,9 +11,9 @@ import (
 	"github.com/gobackup/gobackup/logger"
 )
 
-// Run archive
-func Run(model config.ModelConfig) error {
-	logger := logger.Tag("Archive")
+// Run archive, tar is killed when ctx is done
+func Run(ctx context.Context, model config.ModelConfig) error {
+	logger := logger.TagContext(ctx, "Archive")
 
 	if model.Archive == nil {
 		return nil
@@ -36,7 +37,7 @@ func Run(model config.ModelConfig) error {
 
 	opts := options(model.DumpPath, excludes, includes)
 
-	_, err := helper.Exec("tar", opts...)
+	_, err := helper.ExecContext(ctx, "tar", opts...)
 	return err
 }
 
@@ -1,6 +1,7 @@
 package archive
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -14,7 +15,7 @@ func TestRun(t *testing.T) {
 	model := config.ModelConfig{
 		Archive: nil,
 	}
-	err := Run(model)
+	err := Run(context.Background(), model)
 	assert.NoError(t, err)
 }
 
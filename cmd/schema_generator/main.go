@@ -9,16 +9,65 @@ import (
 )
 
 type ConfigSchema struct {
-	WorkDir string                 `json:"workdir,omitempty" jsonschema:"title=WorkDir,description=Base working directory for temporary backup files."`
-	Web     WebConfig              `json:"web,omitempty" jsonschema:"title=WebConfig,description=Web UI and API server configuration."`
-	Models  map[string]ModelConfig `json:"models" jsonschema:"title=Models,description=Backup models keyed by model name."`
+	WorkDir           string                 `json:"workdir,omitempty" jsonschema:"title=WorkDir,description=Base working directory for temporary backup files."`
+	MaxConcurrentJobs int                    `json:"max_concurrent_jobs,omitempty" jsonschema:"title=MaxConcurrentJobs,minimum=1,description=Maximum number of models performed at the same time by the daemon. Default is 1."`
+	LogFormat         string                 `json:"log_format,omitempty" jsonschema:"title=LogFormat,enum=text,enum=json,description=Format of the log lines. Default is text."`
+	LogLevel          string                 `json:"log_level,omitempty" jsonschema:"title=LogLevel,enum=debug,enum=info,enum=warn,enum=error,description=Minimum level of the logs. Default is info."`
+	LogMaxSize        int                    `json:"log_max_size,omitempty" jsonschema:"title=LogMaxSize,minimum=0,description=Rotate the daemon log file when it is larger than this size in MB. Default is 0, which disables the rotation."`
+	LogMaxAge         string                 `json:"log_max_age,omitempty" jsonschema:"title=LogMaxAge,pattern=^([0-9]+[dw]|[0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$,description=Remove the rotated log files older than this duration such as 7d."`
+	LogMaxBackups     int                    `json:"log_max_backups,omitempty" jsonschema:"title=LogMaxBackups,minimum=0,description=Number of the rotated log files to keep. 0 keeps all. Default is 5."`
+	Include           any                    `json:"include,omitempty" jsonschema:"title=Include,description=Globs of the files with more models such as /etc/gobackup/models.d/*.yml relative to this file.,oneof_type=string;array"`
+	Web               WebConfig              `json:"web,omitempty" jsonschema:"title=WebConfig,description=Web UI and API server configuration."`
+	Secrets           SecretsConfig          `json:"secrets,omitempty" jsonschema:"title=Secrets,description=Providers of the secret references such as { secret: vault:secret/data/db#password }."`
+	Defaults          ModelConfig            `json:"defaults,omitempty" jsonschema:"title=Defaults,description=Config merged into all models."`
+	Templates         map[string]ModelConfig `json:"templates,omitempty" jsonschema:"title=Templates,description=Named configs merged into the models by extends."`
+	Models            map[string]ModelConfig `json:"models" jsonschema:"title=Models,description=Backup models keyed by model name."`
 }
 
 type WebConfig struct {
 	Host     string `json:"host,omitempty" jsonschema:"title=Host,description=Web server bind host."`
 	Port     string `json:"port,omitempty" jsonschema:"title=Port,description=Web server port."`
 	Username string `json:"username,omitempty" jsonschema:"title=Username,description=Web UI username."`
-	Password string `json:"password,omitempty" jsonschema:"title=Password,description=Web UI password."`
+	Password Secret `json:"password,omitempty" jsonschema:"title=Password,description=Web UI password."`
+}
+
+type SecretsConfig struct {
+	Vault VaultConfig `json:"vault,omitempty" jsonschema:"title=Vault,description=HashiCorp Vault server of the vault: references."`
+	Sops  SopsConfig  `json:"sops,omitempty" jsonschema:"title=Sops,description=SOPS command of the sops: references."`
+}
+
+type VaultConfig struct {
+	Address   string `json:"address,omitempty" jsonschema:"title=Address,description=Address of the Vault server. Default is VAULT_ADDR."`
+	Token     Secret `json:"token,omitempty" jsonschema:"title=Token,description=Token of Vault. Default is VAULT_TOKEN."`
+	Namespace string `json:"namespace,omitempty" jsonschema:"title=Namespace,description=Namespace of Vault Enterprise. Default is VAULT_NAMESPACE."`
+}
+
+type SopsConfig struct {
+	Command string `json:"command,omitempty" jsonschema:"title=Command,description=Command to decrypt the files. Default is sops."`
+}
+
+// Secret is a string or a secret reference like { secret: env:PG_PASS }
+type Secret string
+
+func (Secret) JSONSchema() *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set("secret", &jsonschema.Schema{
+		Type:        "string",
+		Pattern:     "^(env|file|exec|vault|sops):.+$",
+		Description: "Secret reference such as env:PG_PASS or file:/run/secrets/pg or exec:pass show db or vault:secret/data/db#password or sops:/path/to/secrets.yml#db.password.",
+	})
+
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Type: "string"},
+			{
+				Type:                 "object",
+				Properties:           props,
+				Required:             []string{"secret"},
+				AdditionalProperties: jsonschema.FalseSchema,
+			},
+		},
+	}
 }
 
 type ScheduleConfig struct {
@@ -28,19 +77,37 @@ type ScheduleConfig struct {
 }
 
 type ModelConfig struct {
-	Name           string                         `json:"name,omitempty" jsonschema:"title=Name,description=Model name."`
-	Description    string                         `json:"description,omitempty" jsonschema:"title=Description,description=Human readable description for the backup model."`
-	Schedule       ScheduleConfig                 `json:"schedule,omitempty" jsonschema:"title=Schedule,description=Backup schedule configuration."`
-	CompressWith   CompressSubConfig              `json:"compress_with,omitempty" jsonschema:"title=CompressWith,description=Compression configuration."`
-	EncryptWith    EncryptSubConfig               `json:"encrypt_with,omitempty" jsonschema:"title=EncryptWith,description=Encryption configuration."`
-	Archive        map[string]any                 `json:"archive,omitempty" jsonschema:"title=Archive,description=Archive configuration."`
-	Splitter       map[string]any                 `json:"split_with,omitempty" jsonschema:"title=Splitter,description=Split output configuration."`
-	Databases      map[string]DatabaseSubConfig   `json:"databases,omitempty" jsonschema:"title=Databases,description=Database sources keyed by name."`
-	Storages       map[string]StorageSubConfig    `json:"storages,omitempty" jsonschema:"title=Storages,description=Storage destinations keyed by name."`
-	DefaultStorage string                         `json:"default_storage,omitempty" jsonschema:"title=DefaultStorage,description=Default storage name."`
-	Notifiers      map[string]NotifierSubConfig   `json:"notifiers,omitempty" jsonschema:"title=Notifiers,description=Notification providers keyed by name."`
-	BeforeScript   string                         `json:"before_script,omitempty" jsonschema:"title=BeforeScript,description=Script executed before backup."`
-	AfterScript    string                         `json:"after_script,omitempty" jsonschema:"title=AfterScript,description=Script executed after backup."`
+	Name                string                       `json:"name,omitempty" jsonschema:"title=Name,description=Model name."`
+	Description         string                       `json:"description,omitempty" jsonschema:"title=Description,description=Human readable description for the backup model."`
+	Schedule            ScheduleConfig               `json:"schedule,omitempty" jsonschema:"title=Schedule,description=Backup schedule configuration."`
+	CompressWith        CompressSubConfig            `json:"compress_with,omitempty" jsonschema:"title=CompressWith,description=Compression configuration."`
+	EncryptWith         EncryptSubConfig             `json:"encrypt_with,omitempty" jsonschema:"title=EncryptWith,description=Encryption configuration."`
+	Archive             map[string]any               `json:"archive,omitempty" jsonschema:"title=Archive,description=Archive configuration."`
+	Splitter            map[string]any               `json:"split_with,omitempty" jsonschema:"title=Splitter,description=Split output configuration."`
+	Databases           map[string]DatabaseSubConfig `json:"databases,omitempty" jsonschema:"title=Databases,description=Database sources keyed by name."`
+	Storages            map[string]StorageSubConfig  `json:"storages,omitempty" jsonschema:"title=Storages,description=Storage destinations keyed by name."`
+	DefaultStorage      string                       `json:"default_storage,omitempty" jsonschema:"title=DefaultStorage,description=Default storage name."`
+	Notifiers           map[string]NotifierSubConfig `json:"notifiers,omitempty" jsonschema:"title=Notifiers,description=Notification providers keyed by name."`
+	BeforeScript        string                       `json:"before_script,omitempty" jsonschema:"title=BeforeScript,description=Script executed before backup."`
+	AfterScript         string                       `json:"after_script,omitempty" jsonschema:"title=AfterScript,description=Script executed after backup."`
+	Streaming           bool                         `json:"streaming,omitempty" jsonschema:"title=Streaming,description=Stream the archive into storages without temp files."`
+	MaxParallelStorages int                          `json:"max_parallel_storages,omitempty" jsonschema:"title=MaxParallelStorages,description=Max number of storages to upload at the same time (0 is unlimited).,minimum=0"`
+	Timeout             string                       `json:"timeout,omitempty" jsonschema:"title=Timeout,description=Timeout of the whole backup (e.g. 2h or 90m).,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	StageTimeouts       StageTimeoutsConfig          `json:"stage_timeouts,omitempty" jsonschema:"title=StageTimeouts,description=Timeouts of the stages."`
+	KeepFailedArtifacts bool                         `json:"keep_failed_artifacts,omitempty" jsonschema:"title=KeepFailedArtifacts,description=Keep the package in the spool when it is failed to upload so it can be uploaded again. It is not supported with streaming."`
+	DependsOn           []string                     `json:"depends_on,omitempty" jsonschema:"title=DependsOn,description=Models to perform before this one it is skipped when any of them fails."`
+	OnSuccessRun        []string                     `json:"on_success_run,omitempty" jsonschema:"title=OnSuccessRun,description=Models to perform after this one succeeds."`
+	Extends             any                          `json:"extends,omitempty" jsonschema:"title=Extends,description=Templates merged into this model in order and the model overrides them.,oneof_type=string;array"`
+}
+
+type StageTimeoutsConfig struct {
+	Database   string `json:"database,omitempty" jsonschema:"title=Database,description=Timeout of the database stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	Archive    string `json:"archive,omitempty" jsonschema:"title=Archive,description=Timeout of the archive stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	Compressor string `json:"compressor,omitempty" jsonschema:"title=Compressor,description=Timeout of the compressor stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	Encryptor  string `json:"encryptor,omitempty" jsonschema:"title=Encryptor,description=Timeout of the encryptor stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	Splitter   string `json:"splitter,omitempty" jsonschema:"title=Splitter,description=Timeout of the splitter stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	Storage    string `json:"storage,omitempty" jsonschema:"title=Storage,description=Timeout of the storage stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	Stream     string `json:"stream,omitempty" jsonschema:"title=Stream,description=Timeout of the stream stage.,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
 }
 
 type SubConfig struct {
@@ -50,26 +117,58 @@ type SubConfig struct {
 type DatabaseSubConfig struct {
 	SubConfig
 	Type string `json:"type" jsonschema:"title=Type,description=Database type,enum=mysql,enum=postgresql,enum=redis,enum=mongodb,enum=sqlite,enum=mssql,enum=influxdb,enum=mariadb,enum=etcd,enum=firebird,enum=foundationdb"`
+	Args any    `json:"args,omitempty" jsonschema:"title=Args,description=Additional arguments of the command as a list or a string split like the shell.,oneof_type=string;array"`
 }
 
 type StorageSubConfig struct {
 	SubConfig
-	Type string `json:"type" jsonschema:"title=Type,description=Storage type,enum=local,enum=ftp,enum=sftp,enum=scp,enum=s3,enum=oss,enum=gcs,enum=azure,enum=b2,enum=r2,enum=spaces,enum=cos,enum=us3,enum=kodo,enum=bos,enum=minio,enum=obs,enum=tos,enum=upyun,enum=webdav"`
+	Type            string      `json:"type" jsonschema:"title=Type,description=Storage type,enum=local,enum=ftp,enum=sftp,enum=scp,enum=s3,enum=oss,enum=gcs,enum=azure,enum=b2,enum=r2,enum=spaces,enum=cos,enum=us3,enum=kodo,enum=bos,enum=minio,enum=obs,enum=tos,enum=upyun,enum=webdav"`
+	Keep            int         `json:"keep,omitempty" jsonschema:"title=Keep,description=Keep the newest N packages.,minimum=0"`
+	KeepDaily       int         `json:"keep_daily,omitempty" jsonschema:"title=KeepDaily,description=Keep the newest package of each of the last N days.,minimum=0"`
+	KeepWeekly      int         `json:"keep_weekly,omitempty" jsonschema:"title=KeepWeekly,description=Keep the newest package of each of the last N weeks.,minimum=0"`
+	KeepMonthly     int         `json:"keep_monthly,omitempty" jsonschema:"title=KeepMonthly,description=Keep the newest package of each of the last N months.,minimum=0"`
+	KeepYearly      int         `json:"keep_yearly,omitempty" jsonschema:"title=KeepYearly,description=Keep the newest package of each of the last N years.,minimum=0"`
+	MaxAge          string      `json:"max_age,omitempty" jsonschema:"title=MaxAge,description=Remove the packages older than it (e.g. 90d or 2w or 36h).,pattern=^([0-9]+[dw]|[0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	RetentionSource string      `json:"retention_source,omitempty" jsonschema:"title=RetentionSource,description=Apply the retention to the packages in the cycler (local) or listed from the storage (remote).,enum=local,enum=remote"`
+	RetentionDryRun bool        `json:"retention_dry_run,omitempty" jsonschema:"title=RetentionDryRun,description=Only log the packages to remove."`
+	Retry           RetryConfig `json:"retry,omitempty" jsonschema:"title=Retry,description=Retry the failed upload with backoff."`
+}
+
+type RetryConfig struct {
+	Attempts        int    `json:"attempts,omitempty" jsonschema:"title=Attempts,description=Max attempts including the first one (default 1 is no retry).,minimum=1"`
+	InitialInterval string `json:"initial_interval,omitempty" jsonschema:"title=InitialInterval,description=Interval before the first retry and doubled for each retry (default 1s).,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
+	MaxInterval     string `json:"max_interval,omitempty" jsonschema:"title=MaxInterval,description=Max interval between the retries (default 30s).,pattern=^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"`
 }
 
 type CompressSubConfig struct {
 	SubConfig
-	Type string `json:"type,omitempty" jsonschema:"title=Type,description=Compression type,enum=tar,enum=tgz,enum=7z"`
+	Type    string `json:"type,omitempty" jsonschema:"title=Type,description=Compression type,enum=tar,enum=tgz,enum=7z"`
+	Engine  string `json:"engine,omitempty" jsonschema:"title=Engine,description=Use native to compress in process without tar and compress programs.,enum=native"`
+	Level   int    `json:"level,omitempty" jsonschema:"title=Level,description=Compression level of the native engine."`
+	Threads int    `json:"threads,omitempty" jsonschema:"title=Threads,description=Compression threads of the native engine."`
+	Args    any    `json:"args,omitempty" jsonschema:"title=Args,description=Additional arguments of the command as a list or a string split like the shell.,oneof_type=string;array"`
 }
 
 type EncryptSubConfig struct {
 	SubConfig
-	Type string `json:"type,omitempty" jsonschema:"title=Type,description=Encryption type,enum=openssl"`
+	Type           string   `json:"type,omitempty" jsonschema:"title=Type,description=Encryption type,enum=openssl,enum=age,enum=gpg,enum=aes-gcm,enum=chacha20"`
+	Recipients     []string `json:"recipients,omitempty" jsonschema:"title=Recipients,description=age X25519 or SSH public keys."`
+	RecipientsFile string   `json:"recipients_file,omitempty" jsonschema:"title=RecipientsFile,description=File with one age recipient per line."`
+	IdentityFile   string   `json:"identity_file,omitempty" jsonschema:"title=IdentityFile,description=age or SSH private keys to decrypt."`
+	PublicKeys     []string `json:"public_keys,omitempty" jsonschema:"title=PublicKeys,description=Armored OpenPGP public keys or the paths of them."`
+	PrivateKey     Secret   `json:"private_key,omitempty" jsonschema:"title=PrivateKey,description=Armored OpenPGP private key or the path of it to decrypt."`
+	Passphrase     Secret   `json:"passphrase,omitempty" jsonschema:"title=Passphrase,description=age passphrase or the passphrase of the OpenPGP private key."`
+	Armor          bool     `json:"armor,omitempty" jsonschema:"title=Armor,description=Output ASCII armored data."`
+	KDF            string   `json:"kdf,omitempty" jsonschema:"title=KDF,description=Key derivation function of aes-gcm and chacha20,enum=argon2id,enum=scrypt"`
+	Args           any      `json:"args,omitempty" jsonschema:"title=Args,description=Additional arguments of the command as a list or a string split like the shell.,oneof_type=string;array"`
 }
 
 type NotifierSubConfig struct {
 	SubConfig
-	Type string `json:"type" jsonschema:"title=Type,description=Notifier type,enum=mail,enum=webhook,enum=discord,enum=slack,enum=feishu,enum=dingtalk,enum=github,enum=telegram,enum=ses,enum=postmark,enum=sendgrid,enum=resend,enum=healthchecks,enum=wxwork,enum=googlechat"`
+	Type          string      `json:"type" jsonschema:"title=Type,description=Notifier type,enum=mail,enum=webhook,enum=discord,enum=slack,enum=feishu,enum=dingtalk,enum=github,enum=telegram,enum=ses,enum=postmark,enum=sendgrid,enum=resend,enum=healthchecks,enum=wxwork,enum=googlechat"`
+	Retry         RetryConfig `json:"retry,omitempty" jsonschema:"title=Retry,description=Retry the failed notification with backoff."`
+	TitleTemplate string      `json:"title_template,omitempty" jsonschema:"title=TitleTemplate,description=Go template of the notification title rendered with the event such as {{ .Status }}: {{ .Model }}."`
+	BodyTemplate  string      `json:"body_template,omitempty" jsonschema:"title=BodyTemplate,description=Go template of the notification body rendered with the event."`
 }
 
 func main() {
@@ -1,7 +1,9 @@
 package compressor
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -28,14 +30,15 @@ func (sz *SevenZip) HasVolumeSize() bool {
 	return len(sz.viper.GetString("volume_size")) > 0
 }
 
-func (sz *SevenZip) perform() (archivePath string, err error) {
+func (sz *SevenZip) perform(ctx context.Context) (archivePath string, err error) {
 	filePath := sz.archiveFilePath(sz.ext)
 
 	opts := sz.options()
 	opts = append(opts, filePath)
-	opts = append(opts, sz.name)
+	// 7z stores the directory with its name only, so the working directory is not required
+	opts = append(opts, sz.model.DumpPath)
 
-	_, err = helper.Exec("7z", opts...)
+	_, err = helper.ExecWithOptions(ctx, helper.ExecOptions{Stdin: sz.passwordInput()}, "7z", opts...)
 	if err != nil {
 		return "", err
 	}
@@ -54,13 +57,56 @@ func (sz *SevenZip) perform() (archivePath string, err error) {
 	return archivePath, nil
 }
 
+func (sz *SevenZip) extract(ctx context.Context, archivePath, targetDir string) error {
+	_, err := helper.ExecWithOptions(ctx, helper.ExecOptions{Stdin: sz.passwordInput()}, "7z", sz.extractOptions(archivePath, targetDir)...)
+	return err
+}
+
+// passwordInput return the stdin of 7z with the password, 7z prompts it by `-p` without the value,
+// and asks it twice when creating the archive. It returns nil if there is no password.
+func (sz *SevenZip) passwordInput() io.Reader {
+	password := sz.viper.GetString("password")
+	if len(password) == 0 {
+		return nil
+	}
+
+	return strings.NewReader(password + "\n" + password + "\n")
+}
+
+func (sz *SevenZip) extractOptions(archivePath, targetDir string) (opts []string) {
+	opts = append(opts, "x", "-y")
+
+	if len(sz.viper.GetString("password")) > 0 {
+		opts = append(opts, "-p")
+	}
+
+	opts = append(opts, "-o"+targetDir, archivePath)
+	return
+}
+
+func (sz *SevenZip) list(ctx context.Context, archivePath string) ([]string, error) {
+	opts := []string{"l", "-ba"}
+
+	if len(sz.viper.GetString("password")) > 0 {
+		opts = append(opts, "-p")
+	}
+	opts = append(opts, archivePath)
+
+	output, err := helper.ExecWithOptions(ctx, helper.ExecOptions{Stdin: sz.passwordInput()}, "7z", opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitLines(output), nil
+}
+
 func (sz *SevenZip) options() (opts []string) {
 	opts = append(opts, "a")
 
-	// Get password from config
+	// Get password from config, it is written into stdin
 	password := sz.viper.GetString("password")
 	if len(password) > 0 {
-		opts = append(opts, "-p"+password)
+		opts = append(opts, "-p")
 		// Enable header encryption when password is set
 		opts = append(opts, "-mhe=on")
 	}
@@ -84,10 +130,7 @@ func (sz *SevenZip) options() (opts []string) {
 	}
 
 	// Get additional args
-	args := sz.viper.GetString("args")
-	if len(args) > 0 {
-		opts = append(opts, args)
-	}
+	opts = append(opts, sz.args()...)
 
 	return
 }
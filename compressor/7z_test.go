@@ -1,6 +1,7 @@
 package compressor
 
 import (
+	"io"
 	"testing"
 
 	"github.com/gobackup/gobackup/config"
@@ -38,7 +39,7 @@ func TestSevenZip_optionsWithPassword(t *testing.T) {
 	sz := &SevenZip{base}
 	opts := sz.options()
 	assert.Equal(t, opts[0], "a")
-	assert.Equal(t, opts[1], "-ptest123")
+	assert.Equal(t, opts[1], "-p")
 	assert.Equal(t, opts[2], "-mhe=on")
 }
 
@@ -91,6 +92,10 @@ func TestSevenZip_optionsWithArgs(t *testing.T) {
 	opts := sz.options()
 	assert.Equal(t, opts[0], "a")
 	assert.Equal(t, opts[1], "-mmt=4")
+
+	v.Set("args", []any{"-mmt=4", "-xr!cache dir"})
+	opts = sz.options()
+	assert.Equal(t, opts[1:], []string{"-mmt=4", "-xr!cache dir"})
 }
 
 func TestSevenZip_optionsWithAllOptions(t *testing.T) {
@@ -109,7 +114,7 @@ func TestSevenZip_optionsWithAllOptions(t *testing.T) {
 	sz := &SevenZip{base}
 	opts := sz.options()
 	assert.Equal(t, opts[0], "a")
-	assert.Equal(t, opts[1], "-psecret")
+	assert.Equal(t, opts[1], "-p")
 	assert.Equal(t, opts[2], "-mhe=on")
 	assert.Equal(t, opts[3], "-mx=5")
 	assert.Equal(t, opts[4], "-mmt=4")
@@ -203,10 +208,31 @@ func TestSevenZip_optionsWithAllNewOptions(t *testing.T) {
 	sz := &SevenZip{base}
 	opts := sz.options()
 	assert.Equal(t, opts[0], "a")
-	assert.Equal(t, opts[1], "-psecret")
+	assert.Equal(t, opts[1], "-p")
 	assert.Equal(t, opts[2], "-mhe=on")
 	assert.Equal(t, opts[3], "-m0=LZMA2")
 	assert.Equal(t, opts[4], "-mx=9")
 	assert.Equal(t, opts[5], "-v500m")
 	assert.Equal(t, opts[6], "-mmt=4")
 }
+
+func TestSevenZip_extractOptions(t *testing.T) {
+	v := viper.New()
+	base := newBase(config.ModelConfig{
+		CompressWith: config.SubConfig{
+			Type:  "7z",
+			Name:  "7z",
+			Viper: v,
+		},
+	})
+
+	sz := &SevenZip{base}
+	assert.Equal(t, []string{"x", "-y", "-o/tmp/restore", "/tmp/foo.7z"}, sz.extractOptions("/tmp/foo.7z", "/tmp/restore"))
+
+	v.Set("password", "test123")
+	assert.Equal(t, []string{"x", "-y", "-p", "-o/tmp/restore", "/tmp/foo.7z"}, sz.extractOptions("/tmp/foo.7z", "/tmp/restore"))
+
+	input, err := io.ReadAll(sz.passwordInput())
+	assert.NoError(t, err)
+	assert.Equal(t, "test123\ntest123\n", string(input))
+}
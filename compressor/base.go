@@ -1,10 +1,11 @@
 package compressor
 
 import (
+	"context"
 	"fmt"
 	"github.com/gobackup/gobackup/helper"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gobackup/gobackup/config"
@@ -23,12 +24,22 @@ type Base struct {
 
 // Compressor
 type Compressor interface {
-	perform() (archivePath string, err error)
+	// perform the compression, the command is killed when ctx is done
+	perform(ctx context.Context) (archivePath string, err error)
+	// extract and list the archive, the command is killed when ctx is done
+	extract(ctx context.Context, archivePath, targetDir string) error
+	list(ctx context.Context, archivePath string) (entries []string, err error)
 }
 
 func (c *Base) archiveFilePath(ext string) string {
-    format := c.model.CompressWith.Viper.GetString("filename_format")
-    return filepath.Join(c.model.TempPath, time.Now().Format(format)+ext)
+	format := c.model.CompressWith.Viper.GetString("filename_format")
+	return filepath.Join(c.model.TempPath, time.Now().Format(format)+ext)
+}
+
+// args return the additional arguments of the command, they are validated when the config is loaded
+func (c *Base) args() []string {
+	args, _ := helper.ParseArgs(c.viper.Get("args"))
+	return args
 }
 
 func newBase(model config.ModelConfig) (base Base) {
@@ -40,15 +51,9 @@ func newBase(model config.ModelConfig) (base Base) {
 	return
 }
 
-// Run compressor, return archive path (single file or directory containing split files)
-func Run(model config.ModelConfig) (string, error) {
-	logger := logger.Tag("Compressor")
-
-	base := newBase(model)
-
-	var c Compressor
-	var ext, parallelProgram string
-	switch model.CompressWith.Type {
+// extension return the archive extension and the optional parallel program of compress type
+func extension(compressType string) (ext string, parallelProgram string, err error) {
+	switch compressType {
 	case "gz", "tgz", "taz", "tar.gz":
 		ext = ".tar.gz"
 		parallelProgram = "pigz"
@@ -70,13 +75,32 @@ func Run(model config.ModelConfig) (string, error) {
 		ext = ".tar.zst"
 	case "7z", "7zip":
 		ext = ".7z"
-	case "tar":
+	case "tar", "":
 		ext = ".tar"
-	case "":
-		ext = ".tar"
-		model.CompressWith.Type = "tar"
 	default:
-		return "", fmt.Errorf("Unsupported compress type: %s", model.CompressWith.Type)
+		return "", "", fmt.Errorf("Unsupported compress type: %s", compressType)
+	}
+
+	return
+}
+
+// Run compressor, return archive path (single file or directory containing split files)
+func Run(ctx context.Context, model config.ModelConfig) (string, error) {
+	logger := logger.TagContext(ctx, "Compressor")
+
+	base := newBase(model)
+
+	var c Compressor
+	if model.CompressWith.Type == "" {
+		model.CompressWith.Type = "tar"
+	}
+	ext, parallelProgram, err := extension(model.CompressWith.Type)
+	if err != nil {
+		return "", err
+	}
+	engine, err := engine(model)
+	if err != nil {
+		return "", err
 	}
 
 	// save Extension
@@ -92,24 +116,31 @@ func Run(model config.ModelConfig) (string, error) {
 		if sz.HasVolumeSize() && model.Splitter != nil {
 			return "", fmt.Errorf("cannot use both 7z native volume splitting (volume_size) and external splitter (split_with) at the same time")
 		}
+		if engine == "native" {
+			return "", fmt.Errorf("7z compressor is not supported by native engine")
+		}
 		c = sz
+	} else if engine == "native" {
+		if nativeExt(ext) != ext {
+			return "", fmt.Errorf("%s compressor is not supported by native engine", model.CompressWith.Type)
+		}
+		c = newNative(base)
 	} else {
 		c = &Tar{Base: base}
 	}
 
-	logger.Info("=> Compress | " + model.CompressWith.Type)
+	if engine == "native" {
+		logger.Info("=> Compress | " + model.CompressWith.Type + " (native)")
+	} else {
+		logger.Info("=> Compress | " + model.CompressWith.Type)
+	}
 
 	if err := helper.MkdirP(model.DumpPath); err != nil {
 		logger.Errorf("Failed to mkdir dump path %s: %v", model.DumpPath, err)
 		return "", err
 	}
 
-	// set workdir
-	if err := os.Chdir(filepath.Join(model.DumpPath, "../")); err != nil {
-		return "", fmt.Errorf("chdir to dump path: %s: %w", model.DumpPath, err)
-	}
-
-	archivePath, err := c.perform()
+	archivePath, err := c.perform(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -117,3 +148,53 @@ func Run(model config.ModelConfig) (string, error) {
 
 	return archivePath, nil
 }
+
+// detect the compressor by the archive extension
+func detect(model config.ModelConfig, archivePath string) Compressor {
+	base := newBase(model)
+
+	if strings.HasSuffix(archivePath, ".7z") {
+		return &SevenZip{Base: base}
+	}
+	if engine, _ := engine(model); engine == "native" {
+		return newNative(base)
+	}
+
+	return &Tar{Base: base}
+}
+
+// Extract the archive into targetDir, the compressor is detected by the archive extension
+func Extract(ctx context.Context, model config.ModelConfig, archivePath string, targetDir string) error {
+	logger := logger.TagContext(ctx, "Compressor")
+
+	c := detect(model, archivePath)
+
+	logger.Info("=> Extract | " + filepath.Base(archivePath))
+
+	if err := helper.MkdirP(targetDir); err != nil {
+		return err
+	}
+
+	if err := c.extract(ctx, archivePath, targetDir); err != nil {
+		return err
+	}
+	logger.Info("->", targetDir)
+
+	return nil
+}
+
+// List the entries of the archive, the compressor is detected by the archive extension
+func List(ctx context.Context, model config.ModelConfig, archivePath string) ([]string, error) {
+	return detect(model, archivePath).list(ctx, archivePath)
+}
+
+func splitLines(output string) (lines []string) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return
+}
@@ -1,6 +1,7 @@
 package compressor
 
 import (
+	"context"
 	"path"
 	"strings"
 	"testing"
@@ -15,7 +16,7 @@ type Monkey struct {
 	Base
 }
 
-func (c Monkey) perform() (archivePath string, err error) {
+func (c Monkey) perform(ctx context.Context) (archivePath string, err error) {
 	result := "aaa"
 	return result, nil
 }
@@ -45,7 +46,7 @@ func TestBaseInterface(t *testing.T) {
 	assert.Equal(t, base.model, model)
 
 	c := Monkey{Base: base}
-	result, err := c.perform()
+	result, err := c.perform(context.Background())
 	assert.Equal(t, result, "aaa")
 	assert.Nil(t, err)
 }
@@ -74,7 +75,23 @@ func TestRun_SevenZipVolumeSizeWithSplitter_ReturnsError(t *testing.T) {
 		Viper:    modelViper,
 	}
 
-	_, err := Run(model)
+	_, err := Run(context.Background(), model)
 	assert.NotNil(t, err)
 	assert.True(t, strings.Contains(err.Error(), "cannot use both 7z native volume splitting"))
 }
+
+func TestDetect(t *testing.T) {
+	model := config.ModelConfig{
+		CompressWith: config.SubConfig{Viper: viper.New()},
+	}
+
+	_, ok := detect(model, "/tmp/2022.12.04.07.09.25.7z").(*SevenZip)
+	assert.True(t, ok)
+	_, ok = detect(model, "/tmp/2022.12.04.07.09.25.tar.gz").(*Tar)
+	assert.True(t, ok)
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Equal(t, []string{"foo/", "foo/bar.sql"}, splitLines("foo/\n\n  foo/bar.sql \n"))
+	assert.Nil(t, splitLines(""))
+}
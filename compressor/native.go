@@ -0,0 +1,344 @@
+package compressor
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	dsnetbzip2 "github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// nativeExts are the extensions supported by the native engine, longest first
+var nativeExts = []string{".tar.lzma", ".tar.bz2", ".tar.zst", ".tar.gz", ".tar.xz", ".tar"}
+
+// xzDictCaps are the dictionary sizes of the xz/lzma presets 0-9
+var xzDictCaps = []int{1 << 18, 1 << 20, 1 << 21, 1 << 22, 1 << 22, 1 << 23, 1 << 23, 1 << 24, 1 << 25, 1 << 26}
+
+// Native compressor, archive with archive/tar and compress in process,
+// no tar or compress program is required.
+//
+// type: tgz
+// engine: native
+// # Compression level, 0 is the default level of the type
+// level: 6
+// # Compression threads of gz and zst, default is the number of CPUs
+// threads: 4
+type Native struct {
+	Base
+	level   int
+	threads int
+}
+
+func newNative(base Base) *Native {
+	n := &Native{Base: base, threads: runtime.NumCPU()}
+	if base.viper != nil {
+		n.level = base.viper.GetInt("level")
+		if threads := base.viper.GetInt("threads"); threads > 0 {
+			n.threads = threads
+		}
+	}
+
+	return n
+}
+
+// engine return the `engine` of `compress_with`, empty means the external tar programs
+func engine(model config.ModelConfig) (string, error) {
+	if model.CompressWith.Viper == nil {
+		return "", nil
+	}
+
+	switch e := model.CompressWith.Viper.GetString("engine"); e {
+	case "", "native":
+		return e, nil
+	default:
+		return "", fmt.Errorf("Unsupported compress engine: %s", e)
+	}
+}
+
+func (n *Native) perform(ctx context.Context) (archivePath string, err error) {
+	filePath := n.archiveFilePath(n.ext)
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	cw, err := n.writer(helper.ContextWriter(ctx, f))
+	if err != nil {
+		return "", err
+	}
+
+	tw := tar.NewWriter(cw)
+	if err := writeTar(tw, n.model.DumpPath); err != nil {
+		cw.Close()
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		cw.Close()
+		return "", err
+	}
+	if err := cw.Close(); err != nil {
+		return "", err
+	}
+
+	return filePath, f.Close()
+}
+
+// writer return a writer which compress the data into w with the level and threads
+func (n *Native) writer(w io.Writer) (io.WriteCloser, error) {
+	switch n.ext {
+	case ".tar":
+		return nopWriteCloser{w}, nil
+	case ".tar.gz":
+		level := n.level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gw, err := pgzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		if err := gw.SetConcurrency(1<<20, n.threads); err != nil {
+			return nil, err
+		}
+		return gw, nil
+	case ".tar.zst":
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(n.threads)}
+		if n.level > 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(n.level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	case ".tar.xz":
+		return xz.WriterConfig{DictCap: n.dictCap()}.NewWriter(w)
+	case ".tar.lzma":
+		return lzma.WriterConfig{DictCap: n.dictCap()}.NewWriter(w)
+	case ".tar.bz2":
+		return dsnetbzip2.NewWriter(w, &dsnetbzip2.WriterConfig{Level: n.level})
+	}
+
+	return nil, fmt.Errorf("%s is not supported by native engine", n.ext)
+}
+
+// dictCap return the dictionary size of the xz preset level, default is 6 as xz does
+func (n *Native) dictCap() int {
+	if n.level <= 0 || n.level >= len(xzDictCaps) {
+		return xzDictCaps[6]
+	}
+
+	return xzDictCaps[n.level]
+}
+
+// reader open the archive and return a tar reader, the compression is detected by the extension.
+// The reading is stopped when ctx is done.
+func (n *Native) reader(ctx context.Context, archivePath string) (*tar.Reader, func() error, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	in := helper.ContextReader(ctx, f)
+
+	var r io.Reader
+	closeReader := func() error { return nil }
+	switch nativeExt(archivePath) {
+	case ".tar":
+		r = in
+	case ".tar.gz":
+		var gr *pgzip.Reader
+		if gr, err = pgzip.NewReader(in); err == nil {
+			r, closeReader = gr, gr.Close
+		}
+	case ".tar.zst":
+		var d *zstd.Decoder
+		if d, err = zstd.NewReader(in); err == nil {
+			r, closeReader = d, func() error { d.Close(); return nil }
+		}
+	case ".tar.xz":
+		r, err = xz.NewReader(in)
+	case ".tar.lzma":
+		r, err = lzma.NewReader(in)
+	case ".tar.bz2":
+		r = bzip2.NewReader(in)
+	default:
+		err = fmt.Errorf("%s is not supported by native engine", filepath.Base(archivePath))
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	closer := func() error {
+		closeReader()
+		return f.Close()
+	}
+
+	return tar.NewReader(r), closer, nil
+}
+
+func (n *Native) extract(ctx context.Context, archivePath, targetDir string) error {
+	logger := logger.TagContext(ctx, "Compressor")
+
+	tr, closer, err := n.reader(ctx, archivePath)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	targetDir = filepath.Clean(targetDir)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(targetDir, header.Name)
+		if !isWithin(targetDir, target) {
+			return fmt.Errorf("illegal file path in archive: %s", header.Name)
+		}
+		// the symlink of the former entry must not take the later ones out of targetDir, e.g. `x -> /etc` and `x/cron.d/evil`
+		if err := checkSymlinkParents(targetDir, target); err != nil {
+			return fmt.Errorf("illegal file path in archive: %s, %v", header.Name, err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, header.FileInfo().Mode().Perm()|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, target, header); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			linkTarget := filepath.Join(targetDir, header.Linkname)
+			if !isWithin(targetDir, linkTarget) {
+				return fmt.Errorf("illegal link path in archive: %s", header.Linkname)
+			}
+			if err := checkSymlinkParents(targetDir, linkTarget); err != nil {
+				return fmt.Errorf("illegal link path in archive: %s, %v", header.Linkname, err)
+			}
+			if err := os.Link(linkTarget, target); err != nil {
+				return err
+			}
+		default:
+			logger.Warnf("Skip %s with unsupported type: %c", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// isWithin check the path p is inside dir
+func isWithin(dir, p string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(os.PathSeparator))
+}
+
+// checkSymlinkParents check the parents of target in dir are not symlinks, so target is not written out of dir through them
+func checkSymlinkParents(dir, target string) error {
+	rel, err := filepath.Rel(dir, filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	p := dir
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		if part == "." {
+			continue
+		}
+
+		p = filepath.Join(p, part)
+		info, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", p)
+		}
+	}
+
+	return nil
+}
+
+func extractFile(r io.Reader, target string, header *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+
+	// replace the symlink instead of writing the file it links to
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, header.FileInfo().Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
+
+func (n *Native) list(ctx context.Context, archivePath string) (entries []string, err error) {
+	tr, closer, err := n.reader(ctx, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, header.Name)
+	}
+}
+
+// nativeExt return the extension of archivePath supported by the native engine
+func nativeExt(archivePath string) string {
+	for _, ext := range nativeExts {
+		if strings.HasSuffix(archivePath, ext) {
+			return ext
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,167 @@
+package compressor
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestNative(t *testing.T) {
+	cases := map[string]string{
+		"tar":  ".tar",
+		"tgz":  ".tar.gz",
+		"zst":  ".tar.zst",
+		"xz":   ".tar.xz",
+		"lzma": ".tar.lzma",
+		"bz2":  ".tar.bz2",
+	}
+
+	for compressType, ext := range cases {
+		model := newStreamModel(t, compressType)
+		model.CompressWith.Viper.Set("engine", "native")
+		model.CompressWith.Viper.Set("level", 3)
+		model.CompressWith.Viper.Set("threads", 2)
+
+		archivePath, err := Run(context.Background(), model)
+		assert.NoError(t, err, compressType)
+		assert.True(t, strings.HasSuffix(archivePath, ext), archivePath)
+		assert.Equal(t, ext, model.Viper.GetString("Ext"))
+
+		entries, err := List(context.Background(), model, archivePath)
+		assert.NoError(t, err, compressType)
+		assert.Equal(t, []string{"mymodel/", "mymodel/postgresql/", "mymodel/postgresql/pg1/", "mymodel/postgresql/pg1/db.sql"}, entries)
+
+		targetDir := t.TempDir()
+		assert.NoError(t, Extract(context.Background(), model, archivePath, targetDir))
+		data, err := os.ReadFile(filepath.Join(targetDir, "mymodel", "postgresql", "pg1", "db.sql"))
+		assert.NoError(t, err)
+		assert.Equal(t, "select 1;", string(data))
+	}
+}
+
+func TestNative_Stream(t *testing.T) {
+	model := newStreamModel(t, "zst")
+	model.CompressWith.Viper.Set("engine", "native")
+
+	s, err := NewStream(context.Background(), model)
+	assert.NoError(t, err)
+	assert.NotNil(t, s.native)
+
+	archivePath := filepath.Join(t.TempDir(), s.Filename())
+	f, err := os.Create(archivePath)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Archive(context.Background(), f))
+	assert.NoError(t, f.Close())
+
+	entries, err := List(context.Background(), model, archivePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(entries))
+}
+
+func TestNative_Unsupported(t *testing.T) {
+	model := newStreamModel(t, "7z")
+	model.CompressWith.Viper.Set("engine", "native")
+	_, err := Run(context.Background(), model)
+	assert.EqualError(t, err, "7z compressor is not supported by native engine")
+
+	model = newStreamModel(t, "lzo")
+	model.CompressWith.Viper.Set("engine", "native")
+	_, err = Run(context.Background(), model)
+	assert.EqualError(t, err, "lzo compressor is not supported by native engine")
+	_, err = NewStream(context.Background(), model)
+	assert.EqualError(t, err, "lzo compressor is not supported by native engine")
+
+	model = newStreamModel(t, "tgz")
+	model.CompressWith.Viper.Set("engine", "foo")
+	_, err = Run(context.Background(), model)
+	assert.EqualError(t, err, "Unsupported compress engine: foo")
+}
+
+func TestNative_extractIllegalPath(t *testing.T) {
+	assert.True(t, isWithin("/tmp/out", "/tmp/out"))
+	assert.True(t, isWithin("/tmp/out", "/tmp/out/foo"))
+	assert.False(t, isWithin("/tmp/out", "/tmp/outside"))
+	assert.False(t, isWithin("/tmp/out", "/etc/passwd"))
+}
+
+// writeTestTar write the entries into a tar file, the content of the regular files is their name
+func writeTestTar(t *testing.T, headers ...*tar.Header) string {
+	archivePath := filepath.Join(t.TempDir(), "hostile.tar")
+	f, err := os.Create(archivePath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for _, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(header.Name))
+		}
+		header.Mode = 0644
+		assert.NoError(t, tw.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(header.Name))
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, tw.Close())
+
+	return archivePath
+}
+
+func TestNative_extractSymlink(t *testing.T) {
+	n := &Native{}
+	outside := t.TempDir()
+
+	// the file through the symlink to the outside
+	archivePath := writeTestTar(t,
+		&tar.Header{Name: "x", Typeflag: tar.TypeSymlink, Linkname: outside},
+		&tar.Header{Name: "x/cron.d/evil", Typeflag: tar.TypeReg},
+	)
+	err := n.extract(context.Background(), archivePath, t.TempDir())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "illegal file path in archive: x/cron.d/evil")
+	_, err = os.Stat(filepath.Join(outside, "cron.d"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the file replaces the symlink to the outside, instead of writing into it
+	outsideFile := filepath.Join(outside, "passwd")
+	assert.NoError(t, os.WriteFile(outsideFile, []byte("root"), 0644))
+	archivePath = writeTestTar(t,
+		&tar.Header{Name: "passwd", Typeflag: tar.TypeSymlink, Linkname: outsideFile},
+		&tar.Header{Name: "passwd", Typeflag: tar.TypeReg},
+	)
+	targetDir := t.TempDir()
+	assert.NoError(t, n.extract(context.Background(), archivePath, targetDir))
+	data, err := os.ReadFile(outsideFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "root", string(data))
+	data, err = os.ReadFile(filepath.Join(targetDir, "passwd"))
+	assert.NoError(t, err)
+	assert.Equal(t, "passwd", string(data))
+
+	// the symlinks to the outside are restored as they are
+	archivePath = writeTestTar(t,
+		&tar.Header{Name: "etc/localtime", Typeflag: tar.TypeSymlink, Linkname: "/usr/share/zoneinfo/UTC"},
+		&tar.Header{Name: "etc/hostname", Typeflag: tar.TypeReg},
+	)
+	targetDir = t.TempDir()
+	assert.NoError(t, n.extract(context.Background(), archivePath, targetDir))
+	link, err := os.Readlink(filepath.Join(targetDir, "etc", "localtime"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/share/zoneinfo/UTC", link)
+}
+
+func TestNative_extractCanceled(t *testing.T) {
+	archivePath := writeTestTar(t, &tar.Header{Name: "foo.txt", Typeflag: tar.TypeReg})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&Native{}).extract(ctx, archivePath, t.TempDir())
+	assert.True(t, errors.Is(err, context.Canceled), err)
+}
@@ -0,0 +1,230 @@
+package compressor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// streamPrograms are the programs to compress the tar stream from stdin to stdout,
+// `.tar.gz` is compressed with compress/gzip when pigz is not available.
+var streamPrograms = map[string]string{
+	".tar.Z":    "compress -c",
+	".tar.bz2":  "bzip2 -c",
+	".tar.lz":   "lzip -c",
+	".tar.lzma": "lzma -c",
+	".tar.lzo":  "lzop -c",
+	".tar.xz":   "xz -c",
+	".tar.zst":  "zstd -c",
+}
+
+// parallelStreamArgs are the args of the parallel programs to compress from stdin to stdout
+var parallelStreamArgs = map[string][]string{
+	"pigz":   {"-c"},
+	"pbzip2": {"-c"},
+	"pixz":   {},
+}
+
+// Stream compressor for the streaming mode, the dump path is written as tar
+// and compressed on the fly without a temp archive file.
+type Stream struct {
+	Base
+	filename string
+	native   *Native
+}
+
+// NewStream prepare the compressor with `compress_with` config for the streaming mode,
+// the extension is saved as Run does.
+func NewStream(ctx context.Context, model config.ModelConfig) (*Stream, error) {
+	if model.CompressWith.Type == "" {
+		model.CompressWith.Type = "tar"
+	}
+
+	ext, parallelProgram, err := extension(model.CompressWith.Type)
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".7z" {
+		return nil, fmt.Errorf("7z compressor does not support streaming")
+	}
+	engine, err := engine(model)
+	if err != nil {
+		return nil, err
+	}
+	if engine == "native" && nativeExt(ext) != ext {
+		return nil, fmt.Errorf("%s compressor is not supported by native engine", model.CompressWith.Type)
+	}
+
+	logger := logger.TagContext(ctx, "Compressor")
+	logger.Info("=> Compress | " + model.CompressWith.Type + " (streaming)")
+
+	base := newBase(model)
+	base.ext = ext
+	base.parallelProgram = parallelProgram
+
+	if len(base.args()) > 0 {
+		logger.Warn("args option is ignored in streaming mode")
+	}
+
+	// save Extension
+	model.Viper.Set("Ext", ext)
+
+	s := &Stream{
+		Base:     base,
+		filename: filepath.Base(base.archiveFilePath("")),
+	}
+	if engine == "native" {
+		s.native = newNative(base)
+	}
+
+	return s, nil
+}
+
+// Filename return the archive filename with the extension saved by the compressor and encryptor
+func (s *Stream) Filename() string {
+	return s.filename + s.model.Viper.GetString("Ext")
+}
+
+// Archive write the compressed tar of the dump path into w, w is not closed.
+// It is stopped when ctx is done.
+func (s *Stream) Archive(ctx context.Context, w io.Writer) error {
+	logger := logger.TagContext(ctx, "Compressor")
+
+	cw, err := s.compressWriter(ctx, helper.ContextWriter(ctx, w))
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(cw)
+	if err := writeTar(tw, s.model.DumpPath); err != nil {
+		tw.Close()
+		cw.Close()
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		cw.Close()
+		return err
+	}
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("compress failed: %v", err)
+	}
+
+	logger.Info("->", s.filename+s.ext)
+	return nil
+}
+
+func (s *Stream) compressWriter(ctx context.Context, w io.Writer) (io.WriteCloser, error) {
+	if s.native != nil {
+		return s.native.writer(w)
+	}
+
+	if len(s.parallelProgram) > 0 {
+		if path, err := exec.LookPath(s.parallelProgram); err == nil {
+			return execPipe(ctx, w, path, parallelStreamArgs[s.parallelProgram]...)
+		}
+	}
+
+	switch s.ext {
+	case ".tar":
+		return nopWriteCloser{w}, nil
+	case ".tar.gz":
+		return gzip.NewWriter(w), nil
+	}
+
+	program, ok := streamPrograms[s.ext]
+	if !ok {
+		return nil, fmt.Errorf("%s compressor does not support streaming", s.ext)
+	}
+
+	return execPipe(ctx, w, program)
+}
+
+func execPipe(ctx context.Context, w io.Writer, command string, args ...string) (io.WriteCloser, error) {
+	p, err := helper.ExecPipeCommand(ctx, w, helper.ExecOptions{}, helper.NewCommand(command, args...))
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// writeTar write the dump path into tw with the model name as the top directory, as `tar` does in Run.
+// The files that cannot be read are skipped like `--ignore-failed-read`.
+func writeTar(tw *tar.Writer, dumpPath string) error {
+	logger := logger.Tag("Compressor")
+
+	root := filepath.Dir(dumpPath)
+	return filepath.WalkDir(dumpPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dumpPath {
+				return err
+			}
+			logger.Warnf("Skip %s: %v", p, err)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			logger.Warnf("Skip %s: %v", p, err)
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				logger.Warnf("Skip %s: %v", p, err)
+				return nil
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			logger.Warnf("Skip %s: %v", p, err)
+			return nil
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+
+		if !info.Mode().IsRegular() {
+			return tw.WriteHeader(header)
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			logger.Warnf("Skip %s: %v", p, err)
+			return nil
+		}
+		defer f.Close()
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, f); err != nil {
+			return fmt.Errorf("archive %s failed: %w", p, err)
+		}
+
+		return nil
+	})
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
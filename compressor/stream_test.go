@@ -0,0 +1,101 @@
+package compressor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newStreamModel(t *testing.T, compressType string) config.ModelConfig {
+	tempPath := t.TempDir()
+	dumpPath := filepath.Join(tempPath, "mymodel")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dumpPath, "postgresql", "pg1"), 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(dumpPath, "postgresql", "pg1", "db.sql"), []byte("select 1;"), 0640))
+
+	compressViper := viper.New()
+	compressViper.Set("filename_format", "2006.01.02.15.04.05")
+
+	return config.ModelConfig{
+		Name:     "mymodel",
+		TempPath: tempPath,
+		DumpPath: dumpPath,
+		Viper:    viper.New(),
+		CompressWith: config.SubConfig{
+			Type:  compressType,
+			Viper: compressViper,
+		},
+	}
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	entries := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		entries[header.Name] = string(data)
+	}
+
+	return entries
+}
+
+func TestStream(t *testing.T) {
+	model := newStreamModel(t, "tgz")
+
+	s, err := NewStream(context.Background(), model)
+	assert.NoError(t, err)
+	assert.Equal(t, ".tar.gz", model.Viper.GetString("Ext"))
+	assert.True(t, strings.HasSuffix(s.Filename(), ".tar.gz"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.Archive(context.Background(), &buf))
+
+	gr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	entries := readTar(t, gr)
+
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"mymodel/", "mymodel/postgresql/", "mymodel/postgresql/pg1/", "mymodel/postgresql/pg1/db.sql"}, names)
+	assert.Equal(t, "select 1;", entries["mymodel/postgresql/pg1/db.sql"])
+}
+
+func TestStream_Tar(t *testing.T) {
+	model := newStreamModel(t, "")
+
+	s, err := NewStream(context.Background(), model)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(s.Filename(), ".tar"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.Archive(context.Background(), &buf))
+	assert.Equal(t, "select 1;", readTar(t, &buf)["mymodel/postgresql/pg1/db.sql"])
+}
+
+func TestStream_Unsupported(t *testing.T) {
+	_, err := NewStream(context.Background(), newStreamModel(t, "7z"))
+	assert.EqualError(t, err, "7z compressor does not support streaming")
+
+	_, err = NewStream(context.Background(), newStreamModel(t, "arj"))
+	assert.EqualError(t, err, "Unsupported compress type: arj")
+}
@@ -1,7 +1,9 @@
 package compressor
 
 import (
+	"context"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/gobackup/gobackup/helper"
 )
@@ -10,14 +12,16 @@ type Tar struct {
 	Base
 }
 
-func (tar *Tar) perform() (archivePath string, err error) {
+func (tar *Tar) perform(ctx context.Context) (archivePath string, err error) {
 	filePath := tar.archiveFilePath(tar.ext)
 
 	opts := tar.options()
 	opts = append(opts, filePath)
+	// -C instead of chdir, the working directory is shared by the jobs running at the same time
+	opts = append(opts, "-C", filepath.Dir(tar.model.DumpPath))
 	opts = append(opts, tar.name)
 
-	_, err = helper.Exec("tar", opts...)
+	_, err = helper.ExecContext(ctx, "tar", opts...)
 	if err != nil {
 		return "", err
 	}
@@ -25,6 +29,25 @@ func (tar *Tar) perform() (archivePath string, err error) {
 	return filePath, nil
 }
 
+func (tar *Tar) extract(ctx context.Context, archivePath, targetDir string) error {
+	_, err := helper.ExecContext(ctx, "tar", tar.extractOptions(archivePath, targetDir)...)
+	return err
+}
+
+func (tar *Tar) extractOptions(archivePath, targetDir string) []string {
+	// Both GNU tar and bsdtar detect the compression automatically on extract
+	return []string{"-xf", archivePath, "-C", targetDir}
+}
+
+func (tar *Tar) list(ctx context.Context, archivePath string) ([]string, error) {
+	output, err := helper.ExecContext(ctx, "tar", "-tf", archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitLines(output), nil
+}
+
 func (tar *Tar) options() (opts []string) {
 	if helper.IsGnuTar {
 		opts = append(opts, "--ignore-failed-read")
@@ -40,12 +63,9 @@ func (tar *Tar) options() (opts []string) {
 	if !useCompressProgram {
 		opts = append(opts, "-a")
 	}
+	// args are before `-cf`, which is followed by the archive path
+	opts = append(opts, tar.args()...)
 	opts = append(opts, "-cf")
 
-	args := tar.viper.GetString("args")
-	if len(args) > 0 {
-		opts = append(opts, args)
-	}
-
 	return
 }
@@ -26,11 +26,22 @@ func TestTar_options(t *testing.T) {
 	if helper.IsGnuTar {
 		assert.Equal(t, opts[0], "--ignore-failed-read")
 		assert.Equal(t, opts[1], "-a")
-		assert.Equal(t, opts[2], "-cf")
+		assert.Equal(t, opts[2:], []string{"--foo", "--bar", "--dar", "-cf"})
 	} else {
 		assert.Equal(t, opts[0], "-a")
-		assert.Equal(t, opts[1], "-cf")
-		assert.Equal(t, opts[2], "--foo --bar --dar")
+		assert.Equal(t, opts[1:], []string{"--foo", "--bar", "--dar", "-cf"})
 	}
 
 }
+
+func TestTar_extractOptions(t *testing.T) {
+	base := newBase(config.ModelConfig{
+		CompressWith: config.SubConfig{
+			Type:  "tgz",
+			Viper: viper.New(),
+		},
+	})
+
+	tar := &Tar{base}
+	assert.Equal(t, []string{"-xf", "/tmp/foo.tar.gz", "-C", "/tmp/restore"}, tar.extractOptions("/tmp/foo.tar.gz", "/tmp/restore"))
+}
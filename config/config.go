@@ -2,9 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -26,6 +28,9 @@ var (
 	missingPropertiesPattern = regexp.MustCompile(`missing properties?: (.+)$`)
 	typeErrorPattern         = regexp.MustCompile(`expected ([^,]+), but got ([^\s]+)`)
 
+	// Version of GoBackup, it is set by main
+	Version = "master"
+
 	// Exist Is config file exist
 	Exist bool
 	// Models configs
@@ -36,6 +41,8 @@ var (
 	PidFilePath string = filepath.Join(GoBackupDir, "gobackup.pid")
 	LogFilePath string = filepath.Join(GoBackupDir, "gobackup.log")
 	Web         WebConfig
+	// MaxConcurrentJobs is the number of models performed at the same time by the daemon
+	MaxConcurrentJobs int
 
 	wLock = sync.Mutex{}
 
@@ -49,7 +56,12 @@ type WebConfig struct {
 	Host     string
 	Port     string
 	Username string
+	// Password is empty when it is a secret reference, use ResolvePassword
 	Password string
+	// passwordRef is the secret reference of the password, it is resolved by ResolvePassword
+	passwordRef string
+	// secretsConfig is the `secrets` config to resolve passwordRef
+	secretsConfig any
 }
 
 type ScheduleConfig struct {
@@ -63,8 +75,10 @@ type ScheduleConfig struct {
 }
 
 type runtimeValidationState struct {
-	models []ModelConfig
-	web    WebConfig
+	models            []ModelConfig
+	web               WebConfig
+	maxConcurrentJobs int
+	log               logger.Options
 }
 
 func (sc ScheduleConfig) String() string {
@@ -88,7 +102,8 @@ type ModelConfig struct {
 	Name        string
 	Description string
 	// WorkDir of the gobackup started
-	WorkDir        string
+	WorkDir string
+	// TempPath in the workdir, each run of the model creates a new one next to it
 	TempPath       string
 	DumpPath       string
 	Schedule       ScheduleConfig
@@ -103,8 +118,30 @@ type ModelConfig struct {
 	Viper          *viper.Viper
 	BeforeScript   string
 	AfterScript    string
+	// Streaming pipes the archive through compressor, encryptor and splitter into storages without temp files
+	Streaming bool
+	// MaxParallelStorages is the max number of storages to upload at the same time, 0 is unlimited
+	MaxParallelStorages int
+	// Timeout of the whole perform, 0 is unlimited
+	Timeout time.Duration
+	// StageTimeouts are the timeouts of the stages in Stages
+	StageTimeouts map[string]time.Duration
+	// KeepFailedArtifacts moves the package into the spool when it is failed to upload, instead of deleting it,
+	// it can not be used with Streaming
+	KeepFailedArtifacts bool
+	// DependsOn are the models to perform before this one in the chain, it is skipped when any of them fails
+	DependsOn []string
+	// OnSuccessRun are the models to perform after this one succeeds in the chain
+	OnSuccessRun []string
+	// ConfigFile is the file which the model is defined in, the main config file or an included one
+	ConfigFile string
+	// secretsConfig is the `secrets` config to resolve the secret references by ResolveSecrets
+	secretsConfig any
 }
 
+// Stages of the perform, which can have a timeout in `stage_timeouts`
+var Stages = []string{"database", "archive", "compressor", "encryptor", "splitter", "storage", "stream"}
+
 func getGoBackupDir() string {
 	dir := os.Getenv("GOBACKUP_DIR")
 	if len(dir) == 0 {
@@ -130,7 +167,7 @@ func normalizeConfigPath(configFilePath string) string {
 	return helper.AbsolutePath(configFilePath)
 }
 
-// KnownConfigFilePaths returns the allowed config file locations.
+// KnownConfigFilePaths returns the allowed config file locations, and the files included by the current config.
 func KnownConfigFilePaths() []string {
 	paths := []string{
 		normalizeConfigPath(filepath.Join(".", "gobackup.yml")),
@@ -141,6 +178,7 @@ func KnownConfigFilePaths() []string {
 	if currentPath := CurrentConfigFilePath(); currentPath != "" {
 		paths = append([]string{currentPath}, paths...)
 	}
+	paths = append(paths, IncludedConfigFiles()...)
 
 	seen := map[string]struct{}{}
 	result := make([]string, 0, len(paths))
@@ -184,6 +222,8 @@ type SubConfig struct {
 	Name  string
 	Type  string
 	Viper *viper.Viper
+	// Retry policy of the storage or the notifier
+	Retry helper.RetryPolicy
 }
 
 // Init
@@ -215,15 +255,23 @@ func Init(configFile string) error {
 	}
 
 	viper.WatchConfig()
-	viper.OnConfigChange(func(in fsnotify.Event) {
-		logger.Info("Config file changed:", in.Name)
-		defer onConfigChanged(in)
-		if err := loadConfig(); err != nil {
-			logger.Error(err.Error())
-		}
-	})
+	viper.OnConfigChange(reloadConfig)
 
-	return loadConfig()
+	err := loadConfig()
+	watchIncludes()
+	return err
+}
+
+// reloadConfig when the config file or an included file changed
+func reloadConfig(in fsnotify.Event) {
+	logger := logger.Tag("Config")
+
+	logger.Info("Config file changed:", in.Name)
+	defer onConfigChanged(in)
+	if err := loadConfig(); err != nil {
+		logger.Error(err.Error())
+	}
+	watchIncludes()
 }
 
 // OnConfigChange add callback when config changed
@@ -239,6 +287,11 @@ func onConfigChanged(in fsnotify.Event) {
 }
 
 func validateConfig(schemaPath string, configData []byte) error {
+	return validateConfigWithSources(schemaPath, configData, nil)
+}
+
+// validateConfigWithSources validate the config, the errors have the file and line of the fields if sources is given
+func validateConfigWithSources(schemaPath string, configData []byte, sources *configSources) error {
 	compiler := jsonschema.NewCompiler()
 	schema, err := compiler.Compile(schemaPath)
 	if err != nil {
@@ -252,7 +305,7 @@ func validateConfig(schemaPath string, configData []byte) error {
 
 	if err := schema.Validate(data); err != nil {
 		if validationErr, ok := err.(*jsonschema.ValidationError); ok {
-			return formatValidationError(validationErr)
+			return formatValidationError(validationErr, sources)
 		}
 
 		return err
@@ -261,7 +314,7 @@ func validateConfig(schemaPath string, configData []byte) error {
 	return nil
 }
 
-func formatValidationError(err *jsonschema.ValidationError) error {
+func formatValidationError(err *jsonschema.ValidationError, sources *configSources) error {
 	validationErrors := flattenValidationErrors(err)
 	if len(validationErrors) == 0 {
 		validationErrors = []*jsonschema.ValidationError{err}
@@ -269,7 +322,7 @@ func formatValidationError(err *jsonschema.ValidationError) error {
 
 	formattedErrors := make([]string, 0, len(validationErrors))
 	for _, validationErr := range validationErrors {
-		formattedErrors = append(formattedErrors, formatSingleValidationError(validationErr))
+		formattedErrors = append(formattedErrors, formatSingleValidationError(validationErr, sources))
 	}
 
 	sort.Strings(formattedErrors)
@@ -294,10 +347,16 @@ func flattenValidationErrors(err *jsonschema.ValidationError) []*jsonschema.Vali
 	return result
 }
 
-func formatSingleValidationError(err *jsonschema.ValidationError) string {
+func formatSingleValidationError(err *jsonschema.ValidationError, sources *configSources) string {
 	path := jsonPointerToFieldPath(err.InstanceLocation)
 	message := strings.TrimSpace(err.Message)
 
+	location := ""
+	if sources != nil {
+		file, line := sources.locate(jsonPointerParts(err.InstanceLocation))
+		location = fmt.Sprintf("%s:%d: ", file, line)
+	}
+
 	if requiredField := missingRequiredField(message); requiredField != "" {
 		path = joinFieldPath(path, requiredField)
 		message = "field is required"
@@ -306,10 +365,10 @@ func formatSingleValidationError(err *jsonschema.ValidationError) string {
 	}
 
 	if path == "" {
-		return message
+		return location + message
 	}
 
-	return fmt.Sprintf("%s: %s", path, message)
+	return fmt.Sprintf("%s%s: %s", location, path, message)
 }
 
 func missingRequiredField(message string) string {
@@ -342,8 +401,12 @@ func parseTypeError(message string) (string, string, bool) {
 }
 
 func jsonPointerToFieldPath(pointer string) string {
+	return strings.Join(jsonPointerParts(pointer), ".")
+}
+
+func jsonPointerParts(pointer string) []string {
 	if pointer == "" || pointer == "/" {
-		return ""
+		return nil
 	}
 
 	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
@@ -353,7 +416,7 @@ func jsonPointerToFieldPath(pointer string) string {
 		parts[i] = part
 	}
 
-	return strings.Join(parts, ".")
+	return parts
 }
 
 func joinFieldPath(base string, part string) string {
@@ -467,30 +530,65 @@ func parseRuntimeConfig(expandedCfg []byte, cleanupTempWorkDir bool) (_ *viper.V
 		return nil, nil, fmt.Errorf("no model found")
 	}
 
+	if err := validateDependencies(state.models); err != nil {
+		return nil, nil, err
+	}
+
 	v.SetDefault("web.host", "0.0.0.0")
 	v.SetDefault("web.port", 2703)
 	state.web.Host = v.GetString("web.host")
 	state.web.Port = v.GetString("web.port")
 	state.web.Username = v.GetString("web.username")
-	state.web.Password = v.GetString("web.password")
+	if err := validateSecretRefs(v.Get("secrets"), "secrets"); err != nil {
+		return nil, nil, err
+	}
+	if err := validateSecretRefs(v.Get("web.password"), "web.password"); err != nil {
+		return nil, nil, err
+	}
+	if ref, ok := secretRef(v.Get("web.password")); ok {
+		state.web.passwordRef = ref
+		state.web.secretsConfig = v.Get("secrets")
+	} else {
+		state.web.Password = v.GetString("web.password")
+	}
+
+	v.SetDefault("max_concurrent_jobs", 1)
+	state.maxConcurrentJobs = v.GetInt("max_concurrent_jobs")
+
+	if state.log, err = loadLogConfig(v); err != nil {
+		return nil, nil, err
+	}
 
 	return v, state, nil
 }
 
+// ValidateRuntimeConfig validate the content of the config file,
+// if it is an included file, the main config file is validated with it.
 func ValidateRuntimeConfig(configFilePath string, configData []byte) error {
-	expandedCfg, err := expandConfigContent(configFilePath, configData)
+	contents := map[string][]byte{}
+	if mainPath, ok := mainConfigFileOf(configFilePath); ok {
+		contents[configFilePath] = configData
+
+		var err error
+		if configData, err = os.ReadFile(mainPath); err != nil {
+			return err
+		}
+		configFilePath = mainPath
+	}
+
+	sources, err := loadConfigSources(configFilePath, configData, contents)
 	if err != nil {
 		return err
 	}
 
 	schemaPath := findSchemaPath(configFilePath)
 	if schemaPath != "" {
-		if err := validateConfig(schemaPath, expandedCfg); err != nil {
+		if err := validateConfigWithSources(schemaPath, sources.content, sources); err != nil {
 			return err
 		}
 	}
 
-	_, state, err := parseRuntimeConfig(expandedCfg, true)
+	_, state, err := parseRuntimeConfig(sources.content, true)
 	if err != nil {
 		if err.Error() == "no model found" {
 			return fmt.Errorf("no model found in %s", configFilePath)
@@ -528,11 +626,15 @@ func loadConfig() error {
 	logger.Info("Config file:", viperConfigFile)
 
 	cfg, _ := os.ReadFile(viperConfigFile)
-	expandedCfg, err := expandConfigContent(viperConfigFile, cfg)
+	sources, err := loadConfigSources(normalizeConfigPath(viperConfigFile), cfg, nil)
 	if err != nil {
-		logger.Errorf("Expand config failed: %v", err)
+		logger.Errorf("Load config files failed: %v", err)
 		return err
 	}
+	if files := sources.includedFiles(); len(files) > 0 {
+		logger.Info("Included config files:", strings.Join(files, ", "))
+	}
+	expandedCfg := sources.content
 
 	schemaPath := findSchemaPath(viperConfigFile)
 	if schemaPath == "" {
@@ -541,7 +643,7 @@ func loadConfig() error {
 		goto skipValidation
 	}
 
-	if err := validateConfig(schemaPath, expandedCfg); err != nil {
+	if err := validateConfigWithSources(schemaPath, expandedCfg, sources); err != nil {
 		logger.Errorf("Validate config failed: %v", err)
 		return err
 	}
@@ -567,9 +669,18 @@ skipValidation:
 		viper.Set(key, value)
 	}
 
+	for i := range state.models {
+		state.models[i].ConfigFile = sources.modelFiles[state.models[i].Name]
+	}
+
 	Exist = true
+	currentSources.Store(sources)
 	Models = state.models
 	Web = state.web
+	MaxConcurrentJobs = state.maxConcurrentJobs
+	if err := applyLogConfig(state); err != nil {
+		return err
+	}
 
 	UpdatedAt = time.Now()
 	logger.Infof("Config loaded, found %d models.", len(Models))
@@ -629,7 +740,23 @@ func loadModelFromViper(root *viper.Viper, key string) (ModelConfig, error) {
 	model.TempPath = filepath.Join(root.GetString("workdir"), fmt.Sprintf("%d", time.Now().UnixNano()))
 	model.DumpPath = filepath.Join(model.TempPath, key)
 	model.Viper = root.Sub("models." + key)
+	if err := applyModelTemplates(root, &model); err != nil {
+		return model, err
+	}
+	if err := validateSecretRefs(model.Viper.AllSettings(), ""); err != nil {
+		return model, err
+	}
+	model.secretsConfig = root.Get("secrets")
 
+	if err := loadModelSettings(&model); err != nil {
+		return ModelConfig{}, err
+	}
+
+	return model, nil
+}
+
+// loadModelSettings load the settings of model from its Viper
+func loadModelSettings(model *ModelConfig) error {
 	model.Description = model.Viper.GetString("description")
 	model.Schedule = ScheduleConfig{Enabled: false}
 
@@ -654,18 +781,78 @@ func loadModelFromViper(root *viper.Viper, key string) (ModelConfig, error) {
 
 	model.BeforeScript = model.Viper.GetString("before_script")
 	model.AfterScript = model.Viper.GetString("after_script")
+	model.Streaming = model.Viper.GetBool("streaming")
+	model.MaxParallelStorages = model.Viper.GetInt("max_parallel_storages")
+	model.KeepFailedArtifacts = model.Viper.GetBool("keep_failed_artifacts")
+	model.DependsOn = model.Viper.GetStringSlice("depends_on")
+	model.OnSuccessRun = model.Viper.GetStringSlice("on_success_run")
 
-	loadScheduleConfig(&model)
-	loadDatabasesConfig(&model)
-	loadStoragesConfig(&model)
+	if err := loadTimeoutsConfig(model); err != nil {
+		return err
+	}
+
+	if model.Streaming && model.KeepFailedArtifacts {
+		return fmt.Errorf("keep_failed_artifacts is not supported in the streaming mode of model %s, as there is no package file", model.Name)
+	}
+
+	loadScheduleConfig(model)
+	loadDatabasesConfig(model)
+	loadStoragesConfig(model)
 
 	if len(model.Storages) == 0 {
-		return ModelConfig{}, fmt.Errorf("no storage found in model %s", model.Name)
+		return fmt.Errorf("no storage found in model %s", model.Name)
 	}
 
-	loadNotifiersConfig(&model)
+	loadNotifiersConfig(model)
 
-	return model, nil
+	return validateArgs(model)
+}
+
+// validateArgs check the `args` of the databases, compressor and encryptor can be parsed, they are used without checking
+func validateArgs(model *ModelConfig) error {
+	subConfigs := map[string]SubConfig{
+		"compress_with": model.CompressWith,
+		"encrypt_with":  model.EncryptWith,
+	}
+	for name, subConfig := range model.Databases {
+		subConfigs["database "+name] = subConfig
+	}
+
+	for name, subConfig := range subConfigs {
+		if subConfig.Viper == nil {
+			continue
+		}
+		if _, err := helper.ParseArgs(subConfig.Viper.Get("args")); err != nil {
+			return fmt.Errorf("invalid args of %s in model %s: %v", name, model.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func loadTimeoutsConfig(model *ModelConfig) error {
+	if timeout := model.Viper.GetString("timeout"); len(timeout) > 0 {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout of model %s: %v", model.Name, err)
+		}
+		model.Timeout = d
+	}
+
+	model.StageTimeouts = map[string]time.Duration{}
+	for stage, value := range model.Viper.GetStringMapString("stage_timeouts") {
+		if !slices.Contains(Stages, stage) {
+			return fmt.Errorf("invalid stage %s in stage_timeouts of model %s, it must be one of %v", stage, model.Name, Stages)
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid timeout of stage %s in model %s: %v", stage, model.Name, err)
+		}
+		model.StageTimeouts[stage] = d
+	}
+
+	return nil
 }
 
 func loadScheduleConfig(model *ModelConfig) {
@@ -708,6 +895,7 @@ func loadStoragesConfig(model *ModelConfig) {
 			Name:  key,
 			Type:  storageViper.GetString("type"),
 			Viper: storageViper,
+			Retry: loadRetryConfig(storageViper),
 		}
 
 		// Set default storage
@@ -728,8 +916,174 @@ func loadNotifiersConfig(model *ModelConfig) {
 			Name:  key,
 			Type:  dbViper.GetString("type"),
 			Viper: dbViper,
+			Retry: loadRetryConfig(dbViper),
+		}
+	}
+}
+
+// loadRetryConfig load the `retry` of a storage or a notifier, it does not retry by default
+func loadRetryConfig(v *viper.Viper) helper.RetryPolicy {
+	v.SetDefault("retry.attempts", 1)
+	v.SetDefault("retry.initial_interval", "1s")
+	v.SetDefault("retry.max_interval", "30s")
+
+	return helper.RetryPolicy{
+		Attempts:        v.GetInt("retry.attempts"),
+		InitialInterval: v.GetDuration("retry.initial_interval"),
+		MaxInterval:     v.GetDuration("retry.max_interval"),
+	}
+}
+
+// loadLogConfig load the format, level and rotation of the log, the rotation is off by default, 5 of the rotated files are kept when log_max_size is set
+func loadLogConfig(v *viper.Viper) (opts logger.Options, err error) {
+	v.SetDefault("log_max_backups", 5)
+
+	opts = logger.Options{
+		Format:     v.GetString("log_format"),
+		Level:      v.GetString("log_level"),
+		MaxSize:    v.GetInt64("log_max_size") * 1024 * 1024,
+		MaxBackups: v.GetInt("log_max_backups"),
+	}
+
+	if maxAge := v.GetString("log_max_age"); len(maxAge) > 0 {
+		if opts.MaxAge, err = helper.ParseDuration(maxAge); err != nil {
+			return opts, fmt.Errorf("invalid log_max_age: %v", err)
 		}
 	}
+
+	return opts, opts.Validate()
+}
+
+// applyLogConfig apply the log options and the secrets to mask in the logs, the logger is shadowed in loadConfig
+func applyLogConfig(state *runtimeValidationState) error {
+	logger.SetSecrets(collectSecrets(state))
+	return logger.SetOptions(state.log)
+}
+
+// secretKeyRegexp match the config keys which values are secrets
+var secretKeyRegexp = regexp.MustCompile(`(?i)(password|passphrase|secret|token|api_key|private_key|credential)`)
+
+// collectSecrets return the secrets in the config of the models and web, they are masked in the logs
+func collectSecrets(state *runtimeValidationState) []string {
+	secrets := []string{}
+	if len(state.web.Password) > 0 {
+		secrets = append(secrets, state.web.Password)
+	}
+	if password, ok := resolvedWebPassword(state.web.passwordRef); ok {
+		secrets = append(secrets, password)
+	}
+
+	for _, model := range state.models {
+		subConfigs := []SubConfig{model.CompressWith, model.EncryptWith}
+		for _, configs := range []map[string]SubConfig{model.Databases, model.Storages, model.Notifiers} {
+			for _, subConfig := range configs {
+				subConfigs = append(subConfigs, subConfig)
+			}
+		}
+
+		for _, subConfig := range subConfigs {
+			if subConfig.Viper == nil {
+				continue
+			}
+			secrets = appendSecrets(secrets, "", subConfig.Viper.AllSettings())
+		}
+	}
+
+	return secrets
+}
+
+// appendSecrets append the secret values in settings, and the passwords in the `uri` or `url`
+func appendSecrets(secrets []string, key string, value any) []string {
+	if _, ok := secretRef(value); ok {
+		// the reference is not a secret, the secret is masked when it is resolved
+		return secrets
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for k, v := range value {
+			secrets = appendSecrets(secrets, k, v)
+		}
+	case string:
+		if secretKeyRegexp.MatchString(key) {
+			secrets = append(secrets, value)
+		} else if key == "uri" || key == "url" {
+			if u, err := url.Parse(value); err == nil && u.User != nil {
+				if password, ok := u.User.Password(); ok {
+					secrets = append(secrets, password)
+				}
+			}
+		}
+	}
+
+	return secrets
+}
+
+// validateDependencies check the models in `depends_on` and `on_success_run` are exist, and there is no cycle between them
+func validateDependencies(models []ModelConfig) error {
+	// next are the models performed after the model
+	next := map[string][]string{}
+	names := make([]string, 0, len(models))
+	for _, model := range models {
+		next[model.Name] = []string{}
+		names = append(names, model.Name)
+	}
+	sort.Strings(names)
+
+	for _, model := range models {
+		for _, name := range model.DependsOn {
+			if _, ok := next[name]; !ok {
+				return fmt.Errorf("model %s in depends_on of model %s is not found", name, model.Name)
+			}
+			next[name] = append(next[name], model.Name)
+		}
+		for _, name := range model.OnSuccessRun {
+			if _, ok := next[name]; !ok {
+				return fmt.Errorf("model %s in on_success_run of model %s is not found", name, model.Name)
+			}
+			next[model.Name] = append(next[model.Name], name)
+		}
+	}
+	for _, names := range next {
+		sort.Strings(names)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	states := map[string]int{}
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch states[name] {
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return fmt.Errorf("dependency cycle between models: %s", strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+
+		states[name] = visiting
+		path = append(path, name)
+		for _, n := range next[name] {
+			if err := visit(n); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[name] = visited
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // GetModelConfigByName get model config by name
@@ -0,0 +1,383 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// The models can be defined in the other files by `include`, the globs are relative to the main config file:
+//
+//	include:
+//	  - /etc/gobackup/models.d/*.yml
+//
+// The included files can only have `models`, and a model can't be defined in more than one file.
+
+var (
+	// currentSources are the files of the config loaded
+	currentSources atomic.Pointer[configSources]
+
+	includeWatcher struct {
+		sync.Mutex
+		watcher *fsnotify.Watcher
+		dirs    map[string]bool
+	}
+)
+
+// configFile is a file of the config, which content is expanded by the environment variables
+type configFile struct {
+	path    string
+	content []byte
+	// node of the YAML document, to find the line of the fields
+	node *yaml.Node
+}
+
+// configSources are the main config file and the included files
+type configSources struct {
+	main     configFile
+	includes []configFile
+	// patterns of `include` in absolute paths
+	patterns []string
+	// modelFiles are the files which the models are defined in
+	modelFiles map[string]string
+	// content of the config merged from the files
+	content []byte
+}
+
+// loadConfigSources load the main config file with data, and the files included by it.
+// The content of the files in contents are used instead of reading them, e.g. the file edited in the web.
+func loadConfigSources(configFilePath string, configData []byte, contents map[string][]byte) (*configSources, error) {
+	main, settings, err := readConfigFile(configFilePath, configData)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := &configSources{
+		main:       main,
+		modelFiles: map[string]string{},
+		content:    main.content,
+	}
+
+	models, ok := settings["models"].(map[string]any)
+	if !ok && settings["models"] != nil {
+		return nil, fmt.Errorf("%s: models must be a map", configFilePath)
+	}
+	for name := range models {
+		sources.modelFiles[name] = configFilePath
+	}
+
+	if sources.patterns, err = includePatterns(configFilePath, settings["include"]); err != nil {
+		return nil, err
+	}
+	if len(sources.patterns) == 0 {
+		return sources, nil
+	}
+
+	files, err := matchIncludeFiles(sources.patterns, configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if models == nil {
+		models = map[string]any{}
+	}
+	for _, path := range files {
+		data, ok := contents[path]
+		if !ok {
+			if data, err = os.ReadFile(path); err != nil {
+				return nil, err
+			}
+		}
+
+		file, fileSettings, err := readConfigFile(path, data)
+		if err != nil {
+			return nil, err
+		}
+
+		for key := range fileSettings {
+			if key != "models" {
+				return nil, fmt.Errorf("%s: only models are allowed in the included file, got %s", path, key)
+			}
+		}
+
+		fileModels, ok := fileSettings["models"].(map[string]any)
+		if !ok && fileSettings["models"] != nil {
+			return nil, fmt.Errorf("%s: models must be a map", path)
+		}
+		for name, model := range fileModels {
+			if source, ok := sources.modelFiles[name]; ok {
+				return nil, fmt.Errorf("%s: model %s is already defined in %s", path, name, source)
+			}
+			models[name] = model
+			sources.modelFiles[name] = path
+		}
+
+		sources.includes = append(sources.includes, file)
+	}
+
+	settings["models"] = models
+	if sources.content, err = yaml.Marshal(settings); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// readConfigFile return the file with the expanded content, and the settings in it
+func readConfigFile(path string, data []byte) (configFile, map[string]any, error) {
+	file := configFile{path: path, node: &yaml.Node{}}
+
+	content, err := expandConfigContent(path, data)
+	if err != nil {
+		return file, nil, err
+	}
+	file.content = content
+
+	if err := yaml.Unmarshal(content, file.node); err != nil {
+		return file, nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	settings := map[string]any{}
+	if err := file.node.Decode(&settings); err != nil && file.node.Kind != 0 {
+		return file, nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return file, settings, nil
+}
+
+// includePatterns return the globs in `include` as absolute paths
+func includePatterns(configFilePath string, value any) ([]string, error) {
+	patterns, err := stringList(value, "include")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, pattern := range patterns {
+		pattern = helper.ExplandHome(pattern)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(configFilePath), pattern)
+		}
+		patterns[i] = filepath.Clean(pattern)
+	}
+
+	return patterns, nil
+}
+
+// matchIncludeFiles return the files matched by patterns in order, except the main config file
+func matchIncludeFiles(patterns []string, configFilePath string) ([]string, error) {
+	seen := map[string]bool{configFilePath: true}
+	files := []string{}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include %s: %v", pattern, err)
+		}
+		sort.Strings(matches)
+
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+
+			if info, err := os.Stat(match); err != nil || info.IsDir() {
+				continue
+			}
+			files = append(files, match)
+		}
+	}
+
+	return files, nil
+}
+
+// includedFiles return the paths of the included files
+func (s *configSources) includedFiles() []string {
+	files := make([]string, 0, len(s.includes))
+	for _, file := range s.includes {
+		files = append(files, file.path)
+	}
+	return files
+}
+
+// locate return the file and line of the field in the JSON pointer parts like ["models", "foo", "storages"],
+// the line of the nearest parent is returned if the field is not exists.
+func (s *configSources) locate(parts []string) (string, int) {
+	file := s.main
+	if len(parts) >= 2 && parts[0] == "models" {
+		for _, include := range s.includes {
+			if include.path == s.modelFiles[parts[1]] {
+				file = include
+				break
+			}
+		}
+	}
+
+	node := file.node
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line := node.Line
+
+	for _, part := range parts {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == part {
+					line = node.Content[i].Line
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(part); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return file.path, line
+}
+
+// IncludedConfigFiles return the files included by the config loaded
+func IncludedConfigFiles() []string {
+	sources := currentSources.Load()
+	if sources == nil {
+		return nil
+	}
+
+	return sources.includedFiles()
+}
+
+// mainConfigFileOf return the main config file if path is included by it
+func mainConfigFileOf(path string) (string, bool) {
+	sources := currentSources.Load()
+	if sources == nil {
+		return "", false
+	}
+
+	for _, file := range sources.includes {
+		if file.path == path {
+			return sources.main.path, true
+		}
+	}
+
+	return "", false
+}
+
+// watchIncludes watch the directories of the included files, the config is reloaded when any of them is changed,
+// or a new file matched by `include` is created.
+func watchIncludes() {
+	logger := logger.Tag("Config")
+
+	dirs := map[string]bool{}
+	if sources := currentSources.Load(); sources != nil {
+		for _, pattern := range sources.patterns {
+			dirs[filepath.Dir(pattern)] = true
+		}
+		for _, path := range sources.includedFiles() {
+			dirs[filepath.Dir(path)] = true
+		}
+	}
+
+	includeWatcher.Lock()
+	defer includeWatcher.Unlock()
+
+	if includeWatcher.watcher == nil {
+		if len(dirs) == 0 {
+			return
+		}
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			logger.Errorf("Watch included config files failed: %v", err)
+			return
+		}
+		includeWatcher.watcher = watcher
+		includeWatcher.dirs = map[string]bool{}
+		go runIncludeWatcher(watcher)
+	}
+
+	for dir := range includeWatcher.dirs {
+		if !dirs[dir] {
+			_ = includeWatcher.watcher.Remove(dir)
+			delete(includeWatcher.dirs, dir)
+		}
+	}
+	for dir := range dirs {
+		if includeWatcher.dirs[dir] {
+			continue
+		}
+		if err := includeWatcher.watcher.Add(dir); err != nil {
+			logger.Warnf("Watch included config files in %s failed: %v", dir, err)
+			continue
+		}
+		includeWatcher.dirs[dir] = true
+	}
+}
+
+func runIncludeWatcher(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if isIncludeChanged(event) {
+				reloadConfig(event)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Tag("Config").Errorf("Watch included config files failed: %v", err)
+		}
+	}
+}
+
+// isIncludeChanged return true if the event is of an included file, or a file matched by `include`
+func isIncludeChanged(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+
+	sources := currentSources.Load()
+	if sources == nil {
+		return false
+	}
+
+	name := filepath.Clean(event.Name)
+	// the main config file is watched by viper
+	if name == sources.main.path {
+		return false
+	}
+
+	for _, path := range sources.includedFiles() {
+		if path == name {
+			return true
+		}
+	}
+	for _, pattern := range sources.patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	return dir
+}
+
+func Test_loadConfigSources(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"gobackup.yml": `include: models.d/*.yml
+models:
+  main:
+    storages:
+      local:
+        type: local
+        path: /tmp/main
+`,
+		"models.d/a.yml": `models:
+  a:
+    storages:
+      local:
+        type: local
+        path: /tmp/a
+`,
+		"models.d/b.yml": `models:
+  b:
+    storages:
+      local:
+        type: local
+        path: /tmp/b
+`,
+		"models.d/c.txt": "not included",
+	})
+	mainPath := filepath.Join(dir, "gobackup.yml")
+	data, _ := os.ReadFile(mainPath)
+
+	sources, err := loadConfigSources(mainPath, data, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "models.d", "*.yml")}, sources.patterns)
+	assert.Equal(t, []string{filepath.Join(dir, "models.d", "a.yml"), filepath.Join(dir, "models.d", "b.yml")}, sources.includedFiles())
+	assert.Equal(t, map[string]string{
+		"main": mainPath,
+		"a":    filepath.Join(dir, "models.d", "a.yml"),
+		"b":    filepath.Join(dir, "models.d", "b.yml"),
+	}, sources.modelFiles)
+
+	v, state, err := parseRuntimeConfig(sources.content, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(state.models))
+	assert.Equal(t, "/tmp/b", v.GetString("models.b.storages.local.path"))
+
+	// the content of the file edited is used
+	sources, err = loadConfigSources(mainPath, data, map[string][]byte{
+		filepath.Join(dir, "models.d", "b.yml"): []byte("models:\n  a:\n    description: dup\n"),
+	})
+	assert.EqualError(t, err, filepath.Join(dir, "models.d", "b.yml")+": model a is already defined in "+filepath.Join(dir, "models.d", "a.yml"))
+	assert.Nil(t, sources)
+
+	_, err = loadConfigSources(mainPath, data, map[string][]byte{
+		filepath.Join(dir, "models.d", "b.yml"): []byte("web:\n  port: 2703\n"),
+	})
+	assert.EqualError(t, err, filepath.Join(dir, "models.d", "b.yml")+": only models are allowed in the included file, got web")
+
+	_, err = loadConfigSources(mainPath, []byte("include: 123\n"), nil)
+	assert.EqualError(t, err, "invalid include: 123, must be a string or a list")
+
+	// without include, the content is kept as it is
+	sources, err = loadConfigSources(mainPath, []byte("models: {}\n"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "models: {}\n", string(sources.content))
+}
+
+func Test_validateConfigWithSources(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"gobackup.yml": `include:
+  - models.d/*.yml
+models:
+  main:
+    storages:
+      local:
+        type: bad
+`,
+		"models.d/db.yml": `models:
+  db:
+    storages:
+      local:
+        type: invalid
+`,
+	})
+	mainPath := filepath.Join(dir, "gobackup.yml")
+	data, _ := os.ReadFile(mainPath)
+
+	sources, err := loadConfigSources(mainPath, data, nil)
+	assert.NoError(t, err)
+
+	err = validateConfigWithSources(getTestSchemaPath(t), sources.content, sources)
+	assert.Error(t, err)
+	dbPath := filepath.Join(dir, "models.d", "db.yml")
+	assert.Contains(t, err.Error(), dbPath+":5: models.db.storages.local.type: ")
+	assert.Contains(t, err.Error(), mainPath+":7: models.main.storages.local.type: ")
+}
+
+func TestWatchIncludesToReload(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"gobackup.yml": `include: models.d/*.yml
+models:
+  main:
+    storages:
+      local:
+        type: local
+        path: /tmp/main
+`,
+		"models.d/a.yml": `models:
+  a:
+    storages:
+      local:
+        type: local
+        path: /tmp/a
+`,
+	})
+	t.Cleanup(func() {
+		viper.Reset()
+		_ = Init(testConfigFile)
+	})
+
+	assert.NoError(t, Init(filepath.Join(dir, "gobackup.yml")))
+	assert.Equal(t, 2, len(Models))
+	assert.Contains(t, KnownConfigFilePaths(), filepath.Join(dir, "models.d", "a.yml"))
+	for _, model := range Models {
+		assert.Equal(t, model.Name == "a", model.ConfigFile == filepath.Join(dir, "models.d", "a.yml"))
+	}
+
+	// a new file in models.d is included
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "models.d", "b.yml"), []byte(`models:
+  b:
+    storages:
+      local:
+        type: local
+        path: /tmp/b
+`), 0600))
+
+	for i := 0; i < 100 && len(Models) != 3; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.Equal(t, 3, len(Models))
+	assert.Equal(t, []string{filepath.Join(dir, "models.d", "a.yml"), filepath.Join(dir, "models.d", "b.yml")}, IncludedConfigFiles())
+}
@@ -2,36 +2,109 @@
 
 package config
 
+import "github.com/invopop/jsonschema"
+
 // Package config provides schema-only structs that mirror the YAML config shape.
 
 // ConfigSchemaSpec describes the top-level gobackup YAML file.
 type ConfigSchemaSpec struct {
-	Web    WebSchemaSpec              `json:"web,omitempty" yaml:"web,omitempty"`
-	Models map[string]ModelSchemaSpec `json:"models,omitempty" yaml:"models,omitempty"`
+	MaxConcurrentJobs int                        `json:"max_concurrent_jobs,omitempty" yaml:"max_concurrent_jobs,omitempty"`
+	LogFormat         string                     `json:"log_format,omitempty" yaml:"log_format,omitempty"`
+	LogLevel          string                     `json:"log_level,omitempty" yaml:"log_level,omitempty"`
+	LogMaxSize        int                        `json:"log_max_size,omitempty" yaml:"log_max_size,omitempty"`
+	LogMaxAge         string                     `json:"log_max_age,omitempty" yaml:"log_max_age,omitempty"`
+	LogMaxBackups     int                        `json:"log_max_backups,omitempty" yaml:"log_max_backups,omitempty"`
+	Include           any                        `json:"include,omitempty" yaml:"include,omitempty"`
+	Web               WebSchemaSpec              `json:"web,omitempty" yaml:"web,omitempty"`
+	Secrets           *SecretsSchemaSpec         `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	Defaults          *ModelSchemaSpec           `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Templates         map[string]ModelSchemaSpec `json:"templates,omitempty" yaml:"templates,omitempty"`
+	Models            map[string]ModelSchemaSpec `json:"models,omitempty" yaml:"models,omitempty"`
 }
 
 // WebSchemaSpec describes web auth settings.
 type WebSchemaSpec struct {
-	Host     string `json:"host,omitempty" yaml:"host,omitempty"`
-	Port     string `json:"port,omitempty" yaml:"port,omitempty"`
-	Username string `json:"username,omitempty" yaml:"username,omitempty"`
-	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	Host     string           `json:"host,omitempty" yaml:"host,omitempty"`
+	Port     string           `json:"port,omitempty" yaml:"port,omitempty"`
+	Username string           `json:"username,omitempty" yaml:"username,omitempty"`
+	Password SecretSchemaSpec `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// SecretsSchemaSpec describes the providers of the secret references.
+type SecretsSchemaSpec struct {
+	Vault *VaultSchemaSpec `json:"vault,omitempty" yaml:"vault,omitempty"`
+	Sops  *SopsSchemaSpec  `json:"sops,omitempty" yaml:"sops,omitempty"`
+}
+
+// VaultSchemaSpec describes the HashiCorp Vault server of `vault:` references.
+type VaultSchemaSpec struct {
+	Address   string           `json:"address,omitempty" yaml:"address,omitempty"`
+	Token     SecretSchemaSpec `json:"token,omitempty" yaml:"token,omitempty"`
+	Namespace string           `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// SopsSchemaSpec describes the command to decrypt the files of `sops:` references.
+type SopsSchemaSpec struct {
+	Command string `json:"command,omitempty" yaml:"command,omitempty"`
+}
+
+// SecretSchemaSpec is a string, or a secret reference like `{ secret: env:PG_PASS }`.
+type SecretSchemaSpec string
+
+// JSONSchema allows the secret reference in place of the string
+func (SecretSchemaSpec) JSONSchema() *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set("secret", &jsonschema.Schema{
+		Type:    "string",
+		Pattern: "^(env|file|exec|vault|sops):.+$",
+	})
+
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Type: "string"},
+			{
+				Type:                 "object",
+				Properties:           props,
+				Required:             []string{"secret"},
+				AdditionalProperties: jsonschema.FalseSchema,
+			},
+		},
+	}
 }
 
 // ModelSchemaSpec describes one backup model.
 type ModelSchemaSpec struct {
-	Description    string                        `json:"description,omitempty" yaml:"description,omitempty"`
-	Schedule       *ScheduleSchemaSpec           `json:"schedule,omitempty" yaml:"schedule,omitempty"`
-	CompressWith   *SubConfigSchemaSpec          `json:"compress_with,omitempty" yaml:"compress_with,omitempty"`
-	EncryptWith    *SubConfigSchemaSpec          `json:"encrypt_with,omitempty" yaml:"encrypt_with,omitempty"`
-	Archive        *ArchiveSchemaSpec            `json:"archive,omitempty" yaml:"archive,omitempty"`
-	SplitWith      *SplitWithSchemaSpec          `json:"split_with,omitempty" yaml:"split_with,omitempty"`
-	Databases      map[string]DatabaseSchemaSpec `json:"databases,omitempty" yaml:"databases,omitempty"`
-	Storages       map[string]StorageSchemaSpec  `json:"storages,omitempty" yaml:"storages,omitempty"`
-	Notifiers      map[string]NotifierSchemaSpec `json:"notifiers,omitempty" yaml:"notifiers,omitempty"`
-	DefaultStorage string                        `json:"default_storage,omitempty" yaml:"default_storage,omitempty"`
-	BeforeScript   string                        `json:"before_script,omitempty" yaml:"before_script,omitempty"`
-	AfterScript    string                        `json:"after_script,omitempty" yaml:"after_script,omitempty"`
+	Description         string                        `json:"description,omitempty" yaml:"description,omitempty"`
+	Schedule            *ScheduleSchemaSpec           `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	CompressWith        *SubConfigSchemaSpec          `json:"compress_with,omitempty" yaml:"compress_with,omitempty"`
+	EncryptWith         *SubConfigSchemaSpec          `json:"encrypt_with,omitempty" yaml:"encrypt_with,omitempty"`
+	Archive             *ArchiveSchemaSpec            `json:"archive,omitempty" yaml:"archive,omitempty"`
+	SplitWith           *SplitWithSchemaSpec          `json:"split_with,omitempty" yaml:"split_with,omitempty"`
+	Databases           map[string]DatabaseSchemaSpec `json:"databases,omitempty" yaml:"databases,omitempty"`
+	Storages            map[string]StorageSchemaSpec  `json:"storages,omitempty" yaml:"storages,omitempty"`
+	Notifiers           map[string]NotifierSchemaSpec `json:"notifiers,omitempty" yaml:"notifiers,omitempty"`
+	DefaultStorage      string                        `json:"default_storage,omitempty" yaml:"default_storage,omitempty"`
+	BeforeScript        string                        `json:"before_script,omitempty" yaml:"before_script,omitempty"`
+	AfterScript         string                        `json:"after_script,omitempty" yaml:"after_script,omitempty"`
+	Streaming           bool                          `json:"streaming,omitempty" yaml:"streaming,omitempty"`
+	MaxParallelStorages int                           `json:"max_parallel_storages,omitempty" yaml:"max_parallel_storages,omitempty"`
+	Timeout             string                        `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	StageTimeouts       *StageTimeoutsSchemaSpec      `json:"stage_timeouts,omitempty" yaml:"stage_timeouts,omitempty"`
+	KeepFailedArtifacts bool                          `json:"keep_failed_artifacts,omitempty" yaml:"keep_failed_artifacts,omitempty"`
+	DependsOn           []string                      `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	OnSuccessRun        []string                      `json:"on_success_run,omitempty" yaml:"on_success_run,omitempty"`
+	Extends             any                           `json:"extends,omitempty" yaml:"extends,omitempty"`
+}
+
+// StageTimeoutsSchemaSpec describes the timeouts of the stages.
+type StageTimeoutsSchemaSpec struct {
+	Database   string `json:"database,omitempty" yaml:"database,omitempty"`
+	Archive    string `json:"archive,omitempty" yaml:"archive,omitempty"`
+	Compressor string `json:"compressor,omitempty" yaml:"compressor,omitempty"`
+	Encryptor  string `json:"encryptor,omitempty" yaml:"encryptor,omitempty"`
+	Splitter   string `json:"splitter,omitempty" yaml:"splitter,omitempty"`
+	Storage    string `json:"storage,omitempty" yaml:"storage,omitempty"`
+	Stream     string `json:"stream,omitempty" yaml:"stream,omitempty"`
 }
 
 // ScheduleSchemaSpec describes model scheduling.
@@ -43,11 +116,23 @@ type ScheduleSchemaSpec struct {
 
 // SubConfigSchemaSpec describes the shared common fields for inline provider configs.
 type SubConfigSchemaSpec struct {
-	Type           string `json:"type,omitempty" yaml:"type,omitempty"`
-	FilenameFormat string `json:"filename_format,omitempty" yaml:"filename_format,omitempty"`
-	Password       string `json:"password,omitempty" yaml:"password,omitempty"`
-	Salt           bool   `json:"salt,omitempty" yaml:"salt,omitempty"`
-	OpenSSL        bool   `json:"openssl,omitempty" yaml:"openssl,omitempty"`
+	Type           string           `json:"type,omitempty" yaml:"type,omitempty"`
+	FilenameFormat string           `json:"filename_format,omitempty" yaml:"filename_format,omitempty"`
+	Password       SecretSchemaSpec `json:"password,omitempty" yaml:"password,omitempty"`
+	Salt           bool             `json:"salt,omitempty" yaml:"salt,omitempty"`
+	OpenSSL        bool             `json:"openssl,omitempty" yaml:"openssl,omitempty"`
+	Engine         string           `json:"engine,omitempty" yaml:"engine,omitempty"`
+	Level          int              `json:"level,omitempty" yaml:"level,omitempty"`
+	Threads        int              `json:"threads,omitempty" yaml:"threads,omitempty"`
+	Recipients     []string         `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+	RecipientsFile string           `json:"recipients_file,omitempty" yaml:"recipients_file,omitempty"`
+	IdentityFile   string           `json:"identity_file,omitempty" yaml:"identity_file,omitempty"`
+	PublicKeys     []string         `json:"public_keys,omitempty" yaml:"public_keys,omitempty"`
+	PrivateKey     SecretSchemaSpec `json:"private_key,omitempty" yaml:"private_key,omitempty"`
+	Passphrase     SecretSchemaSpec `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+	Armor          bool             `json:"armor,omitempty" yaml:"armor,omitempty"`
+	KDF            string           `json:"kdf,omitempty" yaml:"kdf,omitempty"`
+	Args           any              `json:"args,omitempty" yaml:"args,omitempty"`
 }
 
 // ArchiveSchemaSpec describes archive includes/excludes.
@@ -63,39 +148,58 @@ type SplitWithSchemaSpec struct {
 
 // DatabaseSchemaSpec describes database-specific inline YAML keys used in the sample config.
 type DatabaseSchemaSpec struct {
-	Type       string `json:"type,omitempty" yaml:"type,omitempty"`
-	Host       string `json:"host,omitempty" yaml:"host,omitempty"`
-	Port       int    `json:"port,omitempty" yaml:"port,omitempty"`
-	Database   string `json:"database,omitempty" yaml:"database,omitempty"`
-	Username   string `json:"username,omitempty" yaml:"username,omitempty"`
-	Password   string `json:"password,omitempty" yaml:"password,omitempty"`
-	Mode       string `json:"mode,omitempty" yaml:"mode,omitempty"`
-	RdbPath    string `json:"rdb_path,omitempty" yaml:"rdb_path,omitempty"`
-	InvokeSave bool   `json:"invoke_save,omitempty" yaml:"invoke_save,omitempty"`
+	Type       string           `json:"type,omitempty" yaml:"type,omitempty"`
+	Host       string           `json:"host,omitempty" yaml:"host,omitempty"`
+	Port       int              `json:"port,omitempty" yaml:"port,omitempty"`
+	Database   string           `json:"database,omitempty" yaml:"database,omitempty"`
+	Username   string           `json:"username,omitempty" yaml:"username,omitempty"`
+	Password   SecretSchemaSpec `json:"password,omitempty" yaml:"password,omitempty"`
+	Mode       string           `json:"mode,omitempty" yaml:"mode,omitempty"`
+	RdbPath    string           `json:"rdb_path,omitempty" yaml:"rdb_path,omitempty"`
+	InvokeSave bool             `json:"invoke_save,omitempty" yaml:"invoke_save,omitempty"`
+	Args       any              `json:"args,omitempty" yaml:"args,omitempty"`
 }
 
 // StorageSchemaSpec describes storage-specific inline YAML keys used in the sample config.
 type StorageSchemaSpec struct {
-	Type            string `json:"type,omitempty" yaml:"type,omitempty"`
-	Keep            int    `json:"keep,omitempty" yaml:"keep,omitempty"`
-	Path            string `json:"path,omitempty" yaml:"path,omitempty"`
-	Host            string `json:"host,omitempty" yaml:"host,omitempty"`
-	Port            int    `json:"port,omitempty" yaml:"port,omitempty"`
-	PrivateKey      string `json:"private_key,omitempty" yaml:"private_key,omitempty"`
-	Username        string `json:"username,omitempty" yaml:"username,omitempty"`
-	Password        string `json:"password,omitempty" yaml:"password,omitempty"`
-	Timeout         int    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
-	Bucket          string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
-	Region          string `json:"region,omitempty" yaml:"region,omitempty"`
-	AccessKeyID     string `json:"access_key_id,omitempty" yaml:"access_key_id,omitempty"`
-	SecretAccessKey string `json:"secret_access_key,omitempty" yaml:"secret_access_key,omitempty"`
-	Account         string `json:"account,omitempty" yaml:"account,omitempty"`
-	TenantID        string `json:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
-	ClientID        string `json:"client_id,omitempty" yaml:"client_id,omitempty"`
-	ClientSecret    string `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	Type            string           `json:"type,omitempty" yaml:"type,omitempty"`
+	Keep            int              `json:"keep,omitempty" yaml:"keep,omitempty"`
+	KeepDaily       int              `json:"keep_daily,omitempty" yaml:"keep_daily,omitempty"`
+	KeepWeekly      int              `json:"keep_weekly,omitempty" yaml:"keep_weekly,omitempty"`
+	KeepMonthly     int              `json:"keep_monthly,omitempty" yaml:"keep_monthly,omitempty"`
+	KeepYearly      int              `json:"keep_yearly,omitempty" yaml:"keep_yearly,omitempty"`
+	MaxAge          string           `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+	RetentionSource string           `json:"retention_source,omitempty" yaml:"retention_source,omitempty"`
+	RetentionDryRun bool             `json:"retention_dry_run,omitempty" yaml:"retention_dry_run,omitempty"`
+	Path            string           `json:"path,omitempty" yaml:"path,omitempty"`
+	Host            string           `json:"host,omitempty" yaml:"host,omitempty"`
+	Port            int              `json:"port,omitempty" yaml:"port,omitempty"`
+	PrivateKey      SecretSchemaSpec `json:"private_key,omitempty" yaml:"private_key,omitempty"`
+	Username        string           `json:"username,omitempty" yaml:"username,omitempty"`
+	Password        SecretSchemaSpec `json:"password,omitempty" yaml:"password,omitempty"`
+	Timeout         int              `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Bucket          string           `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	Region          string           `json:"region,omitempty" yaml:"region,omitempty"`
+	AccessKeyID     string           `json:"access_key_id,omitempty" yaml:"access_key_id,omitempty"`
+	SecretAccessKey SecretSchemaSpec `json:"secret_access_key,omitempty" yaml:"secret_access_key,omitempty"`
+	Account         string           `json:"account,omitempty" yaml:"account,omitempty"`
+	TenantID        string           `json:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	ClientID        string           `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	ClientSecret    SecretSchemaSpec `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	Retry           *RetrySchemaSpec `json:"retry,omitempty" yaml:"retry,omitempty"`
 }
 
 // NotifierSchemaSpec describes notifier-specific inline YAML keys used in the sample config.
 type NotifierSchemaSpec struct {
-	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	Type          string           `json:"type,omitempty" yaml:"type,omitempty"`
+	Retry         *RetrySchemaSpec `json:"retry,omitempty" yaml:"retry,omitempty"`
+	TitleTemplate string           `json:"title_template,omitempty" yaml:"title_template,omitempty"`
+	BodyTemplate  string           `json:"body_template,omitempty" yaml:"body_template,omitempty"`
+}
+
+// RetrySchemaSpec describes the retry policy of storages and notifiers.
+type RetrySchemaSpec struct {
+	Attempts        int    `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	InitialInterval string `json:"initial_interval,omitempty" yaml:"initial_interval,omitempty"`
+	MaxInterval     string `json:"max_interval,omitempty" yaml:"max_interval,omitempty"`
 }
@@ -0,0 +1,486 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// The secrets in the config can be the references resolved when the model is performed,
+// so only the references are in the config file and the loaded config, e.g. `/api/config/raw`.
+// The references are checked when the config is loaded or validated, without resolving them.
+//
+//	password: { secret: env:PG_PASS }
+//	password: { secret: file:/run/secrets/pg }
+//	password: { secret: exec:pass show db }
+//	password: { secret: vault:secret/data/db#password }
+//	password: { secret: sops:/etc/gobackup/secrets.enc.yml#db.password }
+
+var (
+	// secretExecTimeout of the command of `exec:`
+	secretExecTimeout = 30 * time.Second
+	// secretHTTPTimeout of the requests to Vault
+	secretHTTPTimeout = 10 * time.Second
+)
+
+// secretProvider resolve the reference without the scheme, e.g. `PG_PASS` of `env:PG_PASS`
+type secretProvider interface {
+	resolve(ref string) (string, error)
+}
+
+// fileSecretProvider read the secret from the file, the trailing newlines are removed
+type fileSecretProvider struct{}
+
+func (p *fileSecretProvider) resolve(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// envSecretProvider read the secret from the environment variable
+type envSecretProvider struct{}
+
+func (p *envSecretProvider) resolve(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+
+	return value, nil
+}
+
+// execSecretProvider use the output of the command as the secret, e.g. `pass show db`
+type execSecretProvider struct{}
+
+func (p *execSecretProvider) resolve(ref string) (string, error) {
+	cmd, err := helper.ParseCommand(ref)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretExecTimeout)
+	defer cancel()
+
+	out, err := helper.ExecCommand(ctx, helper.ExecOptions{}, cmd)
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %s", cmd.Name, strings.TrimSpace(err.Error()))
+	}
+
+	return strings.TrimRight(out, "\r\n"), nil
+}
+
+// vaultSecretProvider read the secret from HashiCorp Vault KV (v1 or v2) by the HTTP API,
+// the reference is `<path>#<key>`, e.g. `secret/data/db#password` for KV v2.
+type vaultSecretProvider struct {
+	address string
+	// token is resolved when the first secret is read from Vault, it can be a reference
+	token     func() (string, error)
+	namespace string
+	client    *http.Client
+}
+
+func (p *vaultSecretProvider) resolve(ref string) (string, error) {
+	secretPath, key, _ := strings.Cut(ref, "#")
+	if len(secretPath) == 0 || len(key) == 0 {
+		return "", fmt.Errorf("invalid vault reference %q, it must be like secret/data/db#password", ref)
+	}
+	if len(p.address) == 0 {
+		return "", fmt.Errorf("vault address is required, set secrets.vault.address or VAULT_ADDR")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(p.address, "/")+"/v1/"+strings.TrimPrefix(secretPath, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	token, err := p.token()
+	if err != nil {
+		return "", fmt.Errorf("secrets.vault.token: %v", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if len(p.namespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Data   map[string]any `json:"data"`
+		Errors []string       `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid response of vault: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %d %s", resp.StatusCode, strings.Join(result.Errors, ", "))
+	}
+
+	data := result.Data
+	// KV v2 has the secret in data.data, with the version in data.metadata
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s is not found in vault %s", key, secretPath)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s in vault %s is not a string", key, secretPath)
+	}
+
+	return s, nil
+}
+
+// sopsSecretProvider read the secret from the file encrypted by SOPS, it is decrypted by the `sops` command.
+// The reference is `<file>#<key>`, the key is the path like `db.password` in the YAML or JSON,
+// the whole content is the secret without the key.
+type sopsSecretProvider struct {
+	command string
+	// decrypted content of the files, each file is decrypted once
+	files map[string]string
+}
+
+func (p *sopsSecretProvider) resolve(ref string) (string, error) {
+	file, key, _ := strings.Cut(ref, "#")
+	if len(file) == 0 {
+		return "", fmt.Errorf("invalid sops reference %q, it must be like /path/to/secrets.yml#db.password", ref)
+	}
+
+	content, ok := p.files[file]
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), secretExecTimeout)
+		defer cancel()
+
+		out, err := helper.ExecCommand(ctx, helper.ExecOptions{}, helper.NewCommand(p.command, "--decrypt", file))
+		if err != nil {
+			return "", fmt.Errorf("sops decrypt %s failed: %s", file, strings.TrimSpace(err.Error()))
+		}
+		content = out
+		p.files[file] = content
+	}
+
+	if len(key) == 0 {
+		return strings.TrimRight(content, "\r\n"), nil
+	}
+
+	var doc any
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return "", fmt.Errorf("invalid decrypted content of %s: %v", file, err)
+	}
+
+	for _, part := range strings.Split(key, ".") {
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("key %s is not found in %s", key, file)
+		}
+		if doc, ok = m[part]; !ok {
+			return "", fmt.Errorf("key %s is not found in %s", key, file)
+		}
+	}
+
+	switch value := doc.(type) {
+	case string:
+		return value, nil
+	case int, float64, bool:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("key %s in %s is not a string", key, file)
+	}
+}
+
+// secretResolver resolve the secret references with the providers of the schemes
+type secretResolver struct {
+	providers map[string]secretProvider
+	// values of the references, the same reference is resolved once
+	values map[string]string
+}
+
+// newSecretResolver return the resolver with the `secrets` config of root, e.g. the address of Vault.
+// The token of Vault can be a reference of the other providers, it is resolved when Vault is used.
+func newSecretResolver(root *viper.Viper) *secretResolver {
+	root.SetDefault("secrets.vault.address", os.Getenv("VAULT_ADDR"))
+	root.SetDefault("secrets.vault.token", os.Getenv("VAULT_TOKEN"))
+	root.SetDefault("secrets.vault.namespace", os.Getenv("VAULT_NAMESPACE"))
+	root.SetDefault("secrets.sops.command", "sops")
+
+	r := &secretResolver{
+		providers: map[string]secretProvider{
+			"file": &fileSecretProvider{},
+			"env":  &envSecretProvider{},
+			"exec": &execSecretProvider{},
+			"sops": &sopsSecretProvider{
+				command: root.GetString("secrets.sops.command"),
+				files:   map[string]string{},
+			},
+		},
+		values: map[string]string{},
+	}
+
+	token := root.Get("secrets.vault.token")
+	r.providers["vault"] = &vaultSecretProvider{
+		address: root.GetString("secrets.vault.address"),
+		token: func() (string, error) {
+			return r.resolveValue(token)
+		},
+		namespace: root.GetString("secrets.vault.namespace"),
+		client:    &http.Client{Timeout: secretHTTPTimeout},
+	}
+
+	return r
+}
+
+// secretRef return the reference of `{ secret: <ref> }`
+func secretRef(value any) (string, bool) {
+	m, ok := value.(map[string]any)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+
+	ref, ok := m["secret"].(string)
+	return ref, ok
+}
+
+// secretSchemes are the schemes of the providers
+var secretSchemes = []string{"env", "file", "exec", "vault", "sops"}
+
+// parseSecretRef return the scheme and the rest of the reference like `env:PG_PASS`
+func parseSecretRef(ref string) (scheme string, rest string, err error) {
+	scheme, rest, found := strings.Cut(ref, ":")
+	if !found {
+		return "", "", fmt.Errorf("invalid secret reference %q, it must be like env:NAME", ref)
+	}
+	if !slices.Contains(secretSchemes, scheme) {
+		return "", "", fmt.Errorf("unknown secret provider %s in %q", scheme, ref)
+	}
+
+	return scheme, rest, nil
+}
+
+// resolve the reference like `env:PG_PASS`
+func (r *secretResolver) resolve(ref string) (string, error) {
+	if value, ok := r.values[ref]; ok {
+		return value, nil
+	}
+
+	scheme, rest, err := parseSecretRef(ref)
+	if err != nil {
+		return "", err
+	}
+	provider := r.providers[scheme]
+
+	value, err := provider.resolve(rest)
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %s: %v", ref, err)
+	}
+
+	r.values[ref] = value
+	return value, nil
+}
+
+// resolveValue return the secret if value is a reference, otherwise value as a string
+func (r *secretResolver) resolveValue(value any) (string, error) {
+	if ref, ok := secretRef(value); ok {
+		return r.resolve(ref)
+	}
+
+	if value == nil {
+		return "", nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// resolveSettings replace the references in settings with the secrets, it returns whether any is replaced
+func (r *secretResolver) resolveSettings(settings map[string]any, path string) (changed bool, err error) {
+	for key, value := range settings {
+		resolved, ok, err := r.resolveItem(value, joinFieldPath(path, key))
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			settings[key] = resolved
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+func (r *secretResolver) resolveItem(value any, path string) (any, bool, error) {
+	if ref, ok := secretRef(value); ok {
+		secret, err := r.resolve(ref)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %v", path, err)
+		}
+		return secret, true, nil
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		changed, err := r.resolveSettings(value, path)
+		return value, changed, err
+	case []any:
+		changed := false
+		for i, item := range value {
+			resolved, ok, err := r.resolveItem(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				value[i] = resolved
+				changed = true
+			}
+		}
+		return value, changed, nil
+	}
+
+	return value, false, nil
+}
+
+// secrets return the resolved values, they are masked in the logs
+func (r *secretResolver) secrets() []string {
+	secrets := make([]string, 0, len(r.values))
+	for _, value := range r.values {
+		secrets = append(secrets, value)
+	}
+	return secrets
+}
+
+// validateSecretRefs check the secret references in value are valid, they are not resolved
+func validateSecretRefs(value any, path string) error {
+	if ref, ok := secretRef(value); ok {
+		if _, _, err := parseSecretRef(ref); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		return nil
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if err := validateSecretRefs(item, joinFieldPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if err := validateSecretRefs(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResolveSecrets return the model with the secret references in its config replaced by the secrets,
+// it is called when the model is performed, so the secrets are read at the time they are used.
+// The resolved secrets are masked in the logs until the run of ctx is closed.
+func ResolveSecrets(ctx context.Context, model ModelConfig) (ModelConfig, error) {
+	if model.Viper == nil {
+		return model, nil
+	}
+
+	root := viper.New()
+	if model.secretsConfig != nil {
+		root.Set("secrets", model.secretsConfig)
+	}
+	resolver := newSecretResolver(root)
+
+	settings := model.Viper.AllSettings()
+	changed, err := resolver.resolveSettings(settings, "")
+	if err != nil {
+		return model, fmt.Errorf("resolve secrets of model %s: %v", model.Name, err)
+	}
+	logger.AddSecrets(ctx, resolver.secrets())
+	if !changed {
+		return model, nil
+	}
+
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return model, err
+	}
+	model.Viper = v
+	if err := loadModelSettings(&model); err != nil {
+		return model, err
+	}
+
+	return model, nil
+}
+
+// webPassword is the resolved password of `web.password`, it is resolved once for the reference
+var webPassword struct {
+	sync.Mutex
+	ref   string
+	value string
+}
+
+// resolvedWebPassword return the resolved password of ref, if it is resolved
+func resolvedWebPassword(ref string) (string, bool) {
+	if len(ref) == 0 {
+		return "", false
+	}
+
+	webPassword.Lock()
+	defer webPassword.Unlock()
+
+	return webPassword.value, webPassword.ref == ref
+}
+
+// ResolvePassword return the password of the web, the secret reference is resolved when it is needed
+// by the web server, not when the config is loaded or validated.
+func (c WebConfig) ResolvePassword() (string, error) {
+	if len(c.passwordRef) == 0 {
+		return c.Password, nil
+	}
+	if password, ok := resolvedWebPassword(c.passwordRef); ok {
+		return password, nil
+	}
+
+	root := viper.New()
+	if c.secretsConfig != nil {
+		root.Set("secrets", c.secretsConfig)
+	}
+	resolver := newSecretResolver(root)
+	password, err := resolver.resolve(c.passwordRef)
+	if err != nil {
+		return "", fmt.Errorf("web.password: %v", err)
+	}
+
+	webPassword.Lock()
+	webPassword.ref, webPassword.value = c.passwordRef, password
+	webPassword.Unlock()
+
+	// mask it in the logs with the secrets of the config
+	logger.SetSecrets(collectSecrets(&runtimeValidationState{models: Models, web: c}))
+
+	return password, nil
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func Test_secretRef(t *testing.T) {
+	ref, ok := secretRef(map[string]any{"secret": "env:PG_PASS"})
+	assert.True(t, ok)
+	assert.Equal(t, "env:PG_PASS", ref)
+
+	_, ok = secretRef("env:PG_PASS")
+	assert.False(t, ok)
+	_, ok = secretRef(map[string]any{"secret": "env:PG_PASS", "type": "mysql"})
+	assert.False(t, ok)
+	_, ok = secretRef(map[string]any{"secret": 123})
+	assert.False(t, ok)
+}
+
+func Test_secretResolver(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "pg")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("file-pass\n"), 0600))
+	t.Setenv("TEST_SECRET_PASS", "env-pass")
+
+	resolver := newSecretResolver(viper.New())
+
+	value, err := resolver.resolve("file:" + secretFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "file-pass", value)
+
+	value, err = resolver.resolve("env:TEST_SECRET_PASS")
+	assert.NoError(t, err)
+	assert.Equal(t, "env-pass", value)
+
+	value, err = resolver.resolve("exec:echo 'exec pass'")
+	assert.NoError(t, err)
+	assert.Equal(t, "exec pass", value)
+
+	_, err = resolver.resolve("env:TEST_SECRET_NOT_EXISTS")
+	assert.EqualError(t, err, "resolve secret env:TEST_SECRET_NOT_EXISTS: environment variable TEST_SECRET_NOT_EXISTS is not set")
+
+	_, err = resolver.resolve("foo:bar")
+	assert.EqualError(t, err, `unknown secret provider foo in "foo:bar"`)
+
+	_, err = resolver.resolve("PG_PASS")
+	assert.EqualError(t, err, `invalid secret reference "PG_PASS", it must be like env:NAME`)
+
+	assert.Equal(t, 3, len(resolver.secrets()))
+}
+
+func Test_vaultSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+			w.Write([]byte(`{"data":{"data":{"password":"kv2-pass"},"metadata":{"version":1}}}`))
+		case "/v1/kv/db":
+			w.Write([]byte(`{"data":{"password":"kv1-pass"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_VAULT_TOKEN", "vault-token")
+	root := viper.New()
+	root.SetConfigType("yaml")
+	assert.NoError(t, root.ReadConfig(bytes.NewBufferString(`
+secrets:
+  vault:
+    address: `+server.URL+`
+    token:
+      secret: env:TEST_VAULT_TOKEN
+    namespace: team
+`)))
+
+	resolver := newSecretResolver(root)
+
+	value, err := resolver.resolve("vault:secret/data/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "kv2-pass", value)
+
+	value, err = resolver.resolve("vault:kv/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "kv1-pass", value)
+
+	_, err = resolver.resolve("vault:kv/db#username")
+	assert.EqualError(t, err, "resolve secret vault:kv/db#username: key username is not found in vault kv/db")
+
+	_, err = resolver.resolve("vault:kv/missing#password")
+	assert.EqualError(t, err, "resolve secret vault:kv/missing#password: vault returned 404 ")
+
+	_, err = resolver.resolve("vault:kv/db")
+	assert.EqualError(t, err, `resolve secret vault:kv/db: invalid vault reference "kv/db", it must be like secret/data/db#password`)
+
+	// the secrets must not be in the errors
+	assert.NotContains(t, err.Error(), "vault-token")
+}
+
+func Test_sopsSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	// the fake sops prints the file as it is decrypted
+	command := filepath.Join(dir, "sops")
+	assert.NoError(t, os.WriteFile(command, []byte("#!/bin/sh\ncat \"$2\"\n"), 0700))
+	secretFile := filepath.Join(dir, "secrets.enc.yml")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("db:\n  password: sops-pass\n  port: 5432\n"), 0600))
+
+	root := viper.New()
+	root.Set("secrets.sops.command", command)
+	resolver := newSecretResolver(root)
+
+	value, err := resolver.resolve("sops:" + secretFile + "#db.password")
+	assert.NoError(t, err)
+	assert.Equal(t, "sops-pass", value)
+
+	value, err = resolver.resolve("sops:" + secretFile + "#db.port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", value)
+
+	_, err = resolver.resolve("sops:" + secretFile + "#db.username")
+	assert.EqualError(t, err, "resolve secret sops:"+secretFile+"#db.username: key db.username is not found in "+secretFile)
+}
+
+func Test_loadModelFromViper_secrets(t *testing.T) {
+	t.Setenv("TEST_SECRET_DB_PASS", "db-pass")
+	t.Setenv("TEST_SECRET_ENC_PASS", "enc-pass")
+
+	root := viper.New()
+	root.SetConfigType("yaml")
+	assert.NoError(t, root.ReadConfig(bytes.NewBufferString(`
+models:
+  test:
+    encrypt_with:
+      type: openssl
+      password:
+        secret: env:TEST_SECRET_ENC_PASS
+    databases:
+      pg:
+        type: postgresql
+        password: { secret: "env:TEST_SECRET_DB_PASS" }
+    storages:
+      local:
+        type: local
+        path: /tmp/gobackup-test
+`)))
+
+	model, err := loadModelFromViper(root, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/gobackup-test", model.Storages["local"].Viper.GetString("path"))
+
+	// the references are not resolved when the model is loaded
+	assert.Equal(t, map[string]any{"secret": "env:TEST_SECRET_DB_PASS"}, model.Databases["pg"].Viper.Get("password"))
+	secrets := collectSecrets(&runtimeValidationState{models: []ModelConfig{model}})
+	assert.NotContains(t, secrets, "env:TEST_SECRET_DB_PASS")
+	assert.NotContains(t, secrets, "db-pass")
+
+	resolved, err := ResolveSecrets(context.Background(), model)
+	assert.NoError(t, err)
+	assert.Equal(t, "enc-pass", resolved.EncryptWith.Viper.GetString("password"))
+	assert.Equal(t, "db-pass", resolved.Databases["pg"].Viper.GetString("password"))
+	assert.Equal(t, "/tmp/gobackup-test", resolved.Storages["local"].Viper.GetString("path"))
+
+	// the references are kept in the loaded model and the root config
+	assert.Equal(t, map[string]any{"secret": "env:TEST_SECRET_DB_PASS"}, model.Databases["pg"].Viper.Get("password"))
+	assert.Equal(t, map[string]any{"secret": "env:TEST_SECRET_DB_PASS"}, root.Get("models.test.databases.pg.password"))
+
+	// the missing secret is an error when it is resolved, not when it is loaded
+	assert.NoError(t, os.Unsetenv("TEST_SECRET_DB_PASS"))
+	model, err = loadModelFromViper(root, "test")
+	assert.NoError(t, err)
+	_, err = ResolveSecrets(context.Background(), model)
+	assert.EqualError(t, err, "resolve secrets of model test: databases.pg.password: resolve secret env:TEST_SECRET_DB_PASS: environment variable TEST_SECRET_DB_PASS is not set")
+
+	// the invalid reference is an error when it is loaded
+	root = viper.New()
+	root.SetConfigType("yaml")
+	assert.NoError(t, root.ReadConfig(bytes.NewBufferString(`
+models:
+  test:
+    databases:
+      pg:
+        type: postgresql
+        password: { secret: "foo:bar" }
+    storages:
+      local:
+        type: local
+        path: /tmp/gobackup-test
+`)))
+	_, err = loadModelFromViper(root, "test")
+	assert.EqualError(t, err, `databases.pg.password: unknown secret provider foo in "foo:bar"`)
+}
+
+func Test_parseRuntimeConfig_secretsNotResolved(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "resolved")
+	tokenMarker := filepath.Join(t.TempDir(), "token")
+
+	_, state, err := parseRuntimeConfig([]byte(`
+secrets:
+  vault:
+    token: { secret: "exec:touch `+tokenMarker+`" }
+web:
+  username: gobackup
+  password: { secret: "exec:sh -c 'touch `+marker+`; echo web-pass'" }
+models:
+  test:
+    storages:
+      local:
+        type: local
+        path: /tmp/gobackup-test
+`), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", state.web.Password)
+
+	// the commands are not run when the config is loaded or validated
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+
+	password, err := state.web.ResolvePassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "web-pass", password)
+
+	// the token of vault is resolved only when vault is used
+	_, err = os.Stat(tokenMarker)
+	assert.True(t, os.IsNotExist(err))
+
+	_, _, err = parseRuntimeConfig([]byte(`
+web:
+  password: { secret: "foo:bar" }
+models:
+  test:
+    storages:
+      local:
+        type: local
+        path: /tmp/gobackup-test
+`), true)
+	assert.EqualError(t, err, `web.password: unknown secret provider foo in "foo:bar"`)
+}
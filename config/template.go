@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// The models can inherit the config from `defaults` and the `templates` in `extends`:
+//
+//	defaults:
+//	  compress_with:
+//	    type: tgz
+//	templates:
+//	  prod-s3:
+//	    storages:
+//	      s3:
+//	        type: s3
+//	        bucket: backups
+//	models:
+//	  app:
+//	    extends: [prod-s3, slack-alerts]
+//
+// The config is merged in order: defaults, the templates in `extends`, then the model itself.
+// The maps (e.g. storages or a storage) are merged by the keys, the other values (e.g. lists) are replaced.
+
+// applyModelTemplates merge `defaults` and the templates in `extends` into the config of model
+func applyModelTemplates(root *viper.Viper, model *ModelConfig) error {
+	extends, err := stringList(model.Viper.Get("extends"), "extends")
+	if err != nil {
+		return err
+	}
+
+	defaults := root.Sub("defaults")
+	if defaults == nil && len(extends) == 0 {
+		return nil
+	}
+
+	settings := map[string]any{}
+	if defaults != nil {
+		settings = mergeSettings(settings, defaults.AllSettings())
+	}
+	for _, name := range extends {
+		templateSettings, err := loadTemplateSettings(root, name, nil)
+		if err != nil {
+			return err
+		}
+		settings = mergeSettings(settings, templateSettings)
+	}
+	settings = mergeSettings(settings, model.Viper.AllSettings())
+	delete(settings, "extends")
+
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return err
+	}
+	model.Viper = v
+
+	return nil
+}
+
+// loadTemplateSettings return the settings of the template, merged with the templates it extends
+func loadTemplateSettings(root *viper.Viper, name string, chain []string) (map[string]any, error) {
+	// the keys are case insensitive in viper
+	name = strings.ToLower(name)
+
+	chain = append(chain, name)
+	for _, parent := range chain[:len(chain)-1] {
+		if parent == name {
+			return nil, fmt.Errorf("templates extend each other: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	template := root.Sub("templates." + name)
+	if template == nil {
+		return nil, fmt.Errorf("template %s is not found", name)
+	}
+
+	extends, err := stringList(template.Get("extends"), "extends of template "+name)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]any{}
+	for _, parent := range extends {
+		parentSettings, err := loadTemplateSettings(root, parent, chain)
+		if err != nil {
+			return nil, err
+		}
+		settings = mergeSettings(settings, parentSettings)
+	}
+	settings = mergeSettings(settings, template.AllSettings())
+	delete(settings, "extends")
+
+	return settings, nil
+}
+
+// mergeSettings return the settings of base overridden by override, the maps are merged deeply
+func mergeSettings(base, override map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range override {
+		if overrideMap, ok := value.(map[string]any); ok {
+			if baseMap, ok := result[key].(map[string]any); ok {
+				result[key] = mergeSettings(baseMap, overrideMap)
+				continue
+			}
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+// stringList return the value of a string or a list of strings, e.g. `extends` or `include`
+func stringList(value any, name string) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []string:
+		return value, nil
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s item: %v", name, item)
+			}
+			items = append(items, s)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("invalid %s: %v, must be a string or a list", name, value)
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func readTestViper(t *testing.T, content string) *viper.Viper {
+	t.Helper()
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(bytes.NewBufferString(content)))
+	return v
+}
+
+func Test_mergeSettings(t *testing.T) {
+	base := map[string]any{
+		"compress_with": map[string]any{"type": "tgz", "level": 6},
+		"storages": map[string]any{
+			"s3": map[string]any{"type": "s3", "bucket": "backups", "path": "default"},
+		},
+		"archive": map[string]any{"includes": []any{"/etc"}},
+	}
+	override := map[string]any{
+		"storages": map[string]any{
+			"s3":    map[string]any{"path": "app"},
+			"local": map[string]any{"type": "local"},
+		},
+		"archive":       map[string]any{"includes": []any{"/var/app"}},
+		"compress_with": "tar",
+	}
+
+	assert.Equal(t, map[string]any{
+		"compress_with": "tar",
+		"storages": map[string]any{
+			"s3":    map[string]any{"type": "s3", "bucket": "backups", "path": "app"},
+			"local": map[string]any{"type": "local"},
+		},
+		"archive": map[string]any{"includes": []any{"/var/app"}},
+	}, mergeSettings(base, override))
+
+	// base is not changed
+	assert.Equal(t, "default", base["storages"].(map[string]any)["s3"].(map[string]any)["path"])
+}
+
+func Test_loadModelFromViper_templates(t *testing.T) {
+	root := readTestViper(t, `
+defaults:
+  compress_with:
+    type: tgz
+  notifiers:
+    mail:
+      type: mail
+      to: ops@example.com
+templates:
+  base-s3:
+    storages:
+      s3:
+        type: s3
+        bucket: backups
+        path: default
+  prod-s3:
+    extends: base-s3
+    storages:
+      s3:
+        region: us-east-1
+  Slack-Alerts:
+    notifiers:
+      slack:
+        type: slack
+        url: https://hooks.slack.com/xxx
+models:
+  app:
+    extends: [prod-s3, slack-alerts]
+    storages:
+      s3:
+        path: app
+    notifiers:
+      mail:
+        to: app@example.com
+  plain:
+    storages:
+      local:
+        type: local
+        path: /tmp/plain
+`)
+
+	model, err := loadModelFromViper(root, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, "tgz", model.CompressWith.Type)
+	assert.Equal(t, "s3", model.Storages["s3"].Type)
+	assert.Equal(t, "backups", model.Storages["s3"].Viper.GetString("bucket"))
+	assert.Equal(t, "us-east-1", model.Storages["s3"].Viper.GetString("region"))
+	assert.Equal(t, "app", model.Storages["s3"].Viper.GetString("path"))
+	assert.Equal(t, "app@example.com", model.Notifiers["mail"].Viper.GetString("to"))
+	assert.Equal(t, "slack", model.Notifiers["slack"].Type)
+	assert.Nil(t, model.Viper.Get("extends"))
+
+	// the defaults are applied to the models without extends
+	model, err = loadModelFromViper(root, "plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "tgz", model.CompressWith.Type)
+	assert.Equal(t, 1, len(model.Storages))
+	assert.Equal(t, 1, len(model.Notifiers))
+
+	root.Set("models.plain.extends", "not-exists")
+	_, err = loadModelFromViper(root, "plain")
+	assert.EqualError(t, err, "template not-exists is not found")
+
+	root.Set("templates.base-s3.extends", []any{"prod-s3"})
+	_, err = loadModelFromViper(root, "app")
+	assert.EqualError(t, err, "templates extend each other: prod-s3 -> base-s3 -> prod-s3")
+
+	root.Set("models.plain.extends", 123)
+	_, err = loadModelFromViper(root, "plain")
+	assert.EqualError(t, err, "invalid extends: 123, must be a string or a list")
+}
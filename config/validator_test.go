@@ -110,6 +110,22 @@ models:
         type: local
       s3_backup:
         type: s3
+`,
+		},
+		{
+			name: "config with retention",
+			config: `
+models:
+  my_backup:
+    storages:
+      local:
+        type: local
+        keep: 3
+        keep_daily: 7
+        keep_weekly: 4
+        keep_monthly: 12
+        keep_yearly: 5
+        max_age: 1w12h
 `,
 		},
 		{
@@ -704,6 +720,18 @@ models:
 `,
 			expectedErrMsg: "after_script",
 		},
+		{
+			name: "invalid storage max_age",
+			config: `
+models:
+  my_backup:
+    storages:
+      local:
+        type: local
+        max_age: 90 days
+`,
+			expectedErrMsg: "max_age",
+		},
 	}
 
 	for _, tt := range tests {
@@ -772,7 +800,7 @@ models:
 models:
   my_backup:
     encrypt_with:
-      type: gpg
+      type: pgp
     storages:
       local:
         type: local
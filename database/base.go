@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
 
@@ -24,8 +25,14 @@ type Base struct {
 type Database interface {
 	// Init database config, prepare all things
 	init() error
-	// Peform backup
-	perform() error
+	// Peform backup, the commands are killed when ctx is done
+	perform(ctx context.Context) error
+}
+
+// Restorer is implemented by the databases which are able to load the dump back
+type Restorer interface {
+	// Load the dump in dumpPath back into the database, the commands are killed when ctx is done
+	restore(ctx context.Context) error
 }
 
 func newBase(model config.ModelConfig, dbConfig config.SubConfig) (base Base) {
@@ -43,14 +50,14 @@ func newBase(model config.ModelConfig, dbConfig config.SubConfig) (base Base) {
 	return
 }
 
-func runHook(action, script string) error {
-	logger := logger.Tag("Database")
+func runHook(ctx context.Context, action, script string) error {
+	logger := logger.TagContext(ctx, "Database")
 	if len(script) == 0 {
 		return nil
 	}
 	logger.Infof("Run %s", action)
 
-	if _, err := helper.ExecScriptWithStdio(script, true); err != nil {
+	if _, err := helper.ExecScriptWithStdioContext(ctx, script, true); err != nil {
 		return fmt.Errorf("Run %s failed: %v", action, err)
 	}
 
@@ -58,13 +65,8 @@ func runHook(action, script string) error {
 	return nil
 }
 
-// New - initialize Database
-func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
-	logger := logger.Tag("Database")
-
-	base := newBase(model, dbConfig)
-	var db Database
-	switch dbConfig.Type {
+func newDatabase(base Base) (db Database) {
+	switch base.dbConfig.Type {
 	case "mysql":
 		db = &MySQL{Base: base}
 	case "mariadb":
@@ -95,7 +97,18 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 		db = &Firebird{Base: base}
 	case "foundationdb":
 		db = &FoundationDB{Base: base}
-	default:
+	}
+
+	return
+}
+
+// New - initialize Database
+func runModel(ctx context.Context, model config.ModelConfig, dbConfig config.SubConfig) (err error) {
+	logger := logger.TagContext(ctx, "Database")
+
+	base := newBase(model, dbConfig)
+	db := newDatabase(base)
+	if db == nil {
 		logger.Warn(fmt.Errorf("model: %s databases.%s config `type: %s`, but is not implement", model.Name, dbConfig.Name, dbConfig.Type))
 		return
 	}
@@ -104,7 +117,7 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 
 	// before perform
 	beforeScript := dbConfig.Viper.GetString("before_script")
-	if err := runHook("dump before_script", beforeScript); err != nil {
+	if err := runHook(ctx, "dump before_script", beforeScript); err != nil {
 		return err
 	}
 
@@ -116,7 +129,7 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 		return
 	}
 
-	err = db.perform()
+	err = db.perform(ctx)
 	if err != nil {
 		logger.Info("Dump failed")
 		if len(afterScript) == 0 {
@@ -141,22 +154,22 @@ func runModel(model config.ModelConfig, dbConfig config.SubConfig) (err error) {
 		logger.Info("Dump succeeded")
 	}
 
-	// after perform
-	if err := runHook("dump after_script", afterScript); err != nil {
+	// after perform, it is not killed by ctx as it may clean up what before_script did
+	if err := runHook(context.Background(), "dump after_script", afterScript); err != nil {
 		return err
 	}
 
 	return
 }
 
-// Run databases
-func Run(model config.ModelConfig) error {
+// Run databases, the dumps are stopped when ctx is done
+func Run(ctx context.Context, model config.ModelConfig) error {
 	if len(model.Databases) == 0 {
 		return nil
 	}
 
 	for _, dbCfg := range model.Databases {
-		err := runModel(model, dbCfg)
+		err := runModel(ctx, model, dbCfg)
 		if err != nil {
 			return err
 		}
@@ -164,3 +177,35 @@ func Run(model config.ModelConfig) error {
 
 	return nil
 }
+
+// Restore databases from the dump files under model.DumpPath
+func Restore(ctx context.Context, model config.ModelConfig) error {
+	logger := logger.TagContext(ctx, "Database")
+
+	for _, dbConfig := range model.Databases {
+		base := newBase(model, dbConfig)
+		db := newDatabase(base)
+		if db == nil {
+			logger.Warn(fmt.Errorf("model: %s databases.%s config `type: %s`, but is not implement", model.Name, dbConfig.Name, dbConfig.Type))
+			continue
+		}
+
+		restorer, ok := db.(Restorer)
+		if !ok {
+			logger.Warnf("=> database | %v: %v does not support restore, skipped", dbConfig.Type, base.name)
+			continue
+		}
+
+		logger.Infof("=> restore database | %v: %v", dbConfig.Type, base.name)
+		if err := db.init(); err != nil {
+			return err
+		}
+
+		if err := restorer.restore(ctx); err != nil {
+			return fmt.Errorf("restore %s failed: %v", base.name, err)
+		}
+		logger.Info("Restore succeeded")
+	}
+
+	return nil
+}
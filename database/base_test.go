@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -18,7 +19,7 @@ type Monkey struct {
 	Base
 }
 
-func (db Monkey) perform() error {
+func (db Monkey) perform(ctx context.Context) error {
 	if db.model.Name != "TestMonkey" {
 		return fmt.Errorf("Error")
 	}
@@ -38,7 +39,7 @@ func TestBaseInterface(t *testing.T) {
 		},
 	}
 	db := Monkey{Base: base}
-	err := db.perform()
+	err := db.perform(context.Background())
 	assert.Nil(t, err)
 }
 
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gobackup/gobackup/helper"
+)
+
+// The credentials are passed to the commands by the environment variables or the temp option files (0600),
+// instead of the arguments which are visible to the other users by `ps`.
+
+// mysqlOptionContent return the content of the MySQL option file with the password in `[client]` and groups,
+// it is passed by `--defaults-extra-file`, which must be the first argument.
+func mysqlOptionContent(password string, groups ...string) string {
+	// the value in double quotes supports the escapes of backslash and double quote
+	value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(password)
+
+	var b strings.Builder
+	for _, group := range append([]string{"client"}, groups...) {
+		fmt.Fprintf(&b, "[%s]\npassword=\"%s\"\n", group, value)
+	}
+	return b.String()
+}
+
+// writeMySQLOptionFile write the password into a temp MySQL option file, it returns "" when there is no password.
+// The caller should remove the file by removeOptionFile.
+func writeMySQLOptionFile(password string, groups ...string) (string, error) {
+	if len(password) == 0 {
+		return "", nil
+	}
+
+	return helper.WriteSecretFile("gobackup-*.cnf", mysqlOptionContent(password, groups...))
+}
+
+// yamlString quote s as a YAML double quoted string, which is compatible with the JSON string
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// removeOptionFile remove the temp option file, it does nothing if path is empty
+func removeOptionFile(path string) {
+	if len(path) > 0 {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func Test_mysqlOptionContent(t *testing.T) {
+	assert.Equal(t, "[client]\npassword=\"pass1\"\n", mysqlOptionContent("pass1"))
+	assert.Equal(t, "[client]\npassword=\"a\\\"b\\\\c d\"\n[xtrabackup]\npassword=\"a\\\"b\\\\c d\"\n", mysqlOptionContent(`a"b\c d`, "xtrabackup"))
+}
+
+func Test_writeMySQLOptionFile(t *testing.T) {
+	path, err := writeMySQLOptionFile("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", path)
+
+	path, err = writeMySQLOptionFile("*&^92'")
+	assert.NoError(t, err)
+	defer removeOptionFile(path)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "[client]\npassword=\"*&^92'\"\n", string(data))
+
+	removeOptionFile(path)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMongoDB_writeConfigFile(t *testing.T) {
+	db := &MongoDB{password: `p"1`}
+	assert.NoError(t, db.writeConfigFile())
+	defer removeOptionFile(db.configFile)
+
+	data, err := os.ReadFile(db.configFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "password: \"p\\\"1\"\n", string(data))
+
+	db = &MongoDB{}
+	assert.NoError(t, db.writeConfigFile())
+	assert.Equal(t, "", db.configFile)
+}
+
+func Test_env(t *testing.T) {
+	assert.Equal(t, []string{"PGPASSWORD=pass1"}, (&PostgreSQL{password: "pass1"}).env())
+	assert.Nil(t, (&PostgreSQL{}).env())
+	assert.Equal(t, []string{"REDISCLI_AUTH=pass1"}, (&Redis{password: "pass1"}).env())
+	assert.Equal(t, []string{"ISC_PASSWORD=pass1"}, (&Firebird{password: "pass1"}).env())
+}
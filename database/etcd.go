@@ -1,9 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -25,7 +25,7 @@ type Etcd struct {
 	Base
 	endpoint      string
 	endpoints     []string
-	args          string
+	args          []string
 	_dumpFilePath string
 }
 
@@ -34,7 +34,9 @@ func (db *Etcd) init() (err error) {
 
 	db.endpoint = viper.GetString("endpoint")
 	db.endpoints = viper.GetStringSlice("endpoints")
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	if len(db.endpoint) == 0 && len(db.endpoints) == 0 {
 		return fmt.Errorf("etcd endpoint config is required")
@@ -55,30 +57,28 @@ func (db *Etcd) init() (err error) {
 	return nil
 }
 
-func (db *Etcd) build() string {
+func (db *Etcd) build() *helper.Command {
 	// etcdctl command
 	var etcdctlArgs []string
 
-	etcdctlArgs = append(etcdctlArgs, "snapshot save")
+	etcdctlArgs = append(etcdctlArgs, "snapshot", "save")
 	etcdctlArgs = append(etcdctlArgs, db._dumpFilePath)
 
 	if len(db.endpoint) > 0 {
-		etcdctlArgs = append(etcdctlArgs, "--endpoints "+db.endpoint)
+		etcdctlArgs = append(etcdctlArgs, "--endpoints", db.endpoint)
 	}
 
-	if len(db.args) > 0 {
-		etcdctlArgs = append(etcdctlArgs, db.args)
-	}
+	etcdctlArgs = append(etcdctlArgs, db.args...)
 
-	return "etcdctl " + strings.Join(etcdctlArgs, " ")
+	return helper.NewCommand("etcdctl", etcdctlArgs...)
 }
 
-func (db *Etcd) perform() error {
-	logger := logger.Tag("etcd")
+func (db *Etcd) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "etcd")
 
 	logger.Info("-> Getting snapshot from etcd...")
 
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return err
 	}
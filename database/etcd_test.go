@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
 )
@@ -33,5 +34,5 @@ func TestEtcd_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 
-	assert.Equal(t, db.build(), "etcdctl snapshot save "+db._dumpFilePath+" --endpoints 127.0.0.1:2379 --foo --bar --baz")
+	assert.Equal(t, db.build(), helper.NewCommand("etcdctl", "snapshot", "save", db._dumpFilePath, "--endpoints", "127.0.0.1:2379", "--foo", "--bar", "--baz"))
 }
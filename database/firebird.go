@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -28,7 +29,7 @@ type Firebird struct {
 	username               string
 	password               string
 	role 				   string
-	args                   string
+	args                   []string
 	_dumpFilePath	 	   string
 }
 
@@ -45,7 +46,9 @@ func (db *Firebird) init() (err error) {
 	db.username = viper.GetString("username")
 	db.role = viper.GetString("role")
 	db.password = viper.GetString("password")
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	if len(db.database) == 0 {
 		return fmt.Errorf("Firebird database config is required")
@@ -54,20 +57,17 @@ func (db *Firebird) init() (err error) {
 	return nil
 }
 
-func (db *Firebird) build() string {
+func (db *Firebird) build() *helper.Command {
 	// gbak command
 	var gbakArgs []string
 	
 	gbakArgs = append(gbakArgs, "-b")
 
 	if len(db.username) > 0 {
-		gbakArgs = append(gbakArgs, "-user "+db.username)
-	}
-	if len(db.password) > 0 {
-		gbakArgs = append(gbakArgs, "-pass "+db.password)
+		gbakArgs = append(gbakArgs, "-user", db.username)
 	}
 	if len(db.role) > 0 {
-		gbakArgs = append(gbakArgs, "-role "+db.role)
+		gbakArgs = append(gbakArgs, "-role", db.role)
 	}
 
 	var dbString string
@@ -81,18 +81,28 @@ func (db *Firebird) build() string {
 		strings.TrimSuffix(path.Base(db.database), path.Ext(db.database)) + ".fbk",
 	)
 
+	gbakArgs = append(gbakArgs, db.args...)
 	gbakArgs = append(gbakArgs, dbString)
 	gbakArgs = append(gbakArgs, db._dumpFilePath)
 
-	return "gbak " + strings.Join(gbakArgs, " ")
+	return helper.NewCommand("gbak", gbakArgs...)
+}
+
+// env of gbak, the password is passed by ISC_PASSWORD
+func (db *Firebird) env() []string {
+	if len(db.password) == 0 {
+		return nil
+	}
+
+	return []string{"ISC_PASSWORD=" + db.password}
 }
 
-func (db *Firebird) perform() error {
-	logger := logger.Tag("Firebird")
+func (db *Firebird) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "Firebird")
 
 	logger.Info("-> Dumping Firebird...")
 
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecCommand(ctx, helper.ExecOptions{Env: db.env()}, db.build())
 	if err != nil {
 		return err
 	}
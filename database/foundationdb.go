@@ -1,10 +1,10 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
-	"strings"
 	"time"
 
 	"github.com/gobackup/gobackup/helper"
@@ -36,7 +36,7 @@ type FoundationDB struct {
 	partitionedLog    bool
 	keyRanges         []string
 	blobCredentials   string
-	args              string
+	args              []string
 	_backupURL        string
 	_dumpFilePath     string
 }
@@ -56,7 +56,9 @@ func (db *FoundationDB) init() (err error) {
 	db.partitionedLog = viper.GetBool("partitioned_log")
 	db.keyRanges = viper.GetStringSlice("key_ranges")
 	db.blobCredentials = viper.GetString("blob_credentials")
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	// backup_url is optional
 	// If not specified, use local file path for integration with GoBackup's archive/compressor
@@ -73,9 +75,9 @@ func (db *FoundationDB) init() (err error) {
 	return nil
 }
 
-func (db *FoundationDB) build() string {
+func (db *FoundationDB) build() *helper.Command {
 	// fdbbackup start command
-	var args []string
+	args := []string{"start"}
 
 	// Add cluster file
 	if len(db.clusterFile) > 0 {
@@ -107,7 +109,7 @@ func (db *FoundationDB) build() string {
 
 	// Add key ranges
 	for _, keyRange := range db.keyRanges {
-		args = append(args, "-k", fmt.Sprintf("'%s'", keyRange))
+		args = append(args, "-k", keyRange)
 	}
 
 	// Add blob credentials file
@@ -123,25 +125,22 @@ func (db *FoundationDB) build() string {
 	}
 
 	// Add additional args
-	if len(db.args) > 0 {
-		args = append(args, db.args)
-	}
+	args = append(args, db.args...)
 
-	return strings.Join(args, " ")
+	return helper.NewCommand("fdbbackup", args...)
 }
 
-func (db *FoundationDB) perform() (err error) {
-	logger := logger.Tag("Database")
+func (db *FoundationDB) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "Database")
 
-	opts := db.build()
+	cmd := db.build()
 	logger.Info("-> Dumping FoundationDB...")
 
 	// Start the backup
 	// With -w flag (in non-continuous mode), this will block until backup is complete and restorable
-	cmd := fmt.Sprintf("fdbbackup start %s", opts)
 	logger.Info("Executing:", cmd)
 	
-	output, err := helper.Exec(cmd)
+	output, err := helper.ExecCommand(ctx, helper.ExecOptions{}, cmd)
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
@@ -177,7 +176,7 @@ func (db *FoundationDB) perform() (err error) {
 
 // Status returns the current backup status
 func (db *FoundationDB) Status() (string, error) {
-	var args []string
+	args := []string{"status"}
 
 	if len(db.clusterFile) > 0 {
 		args = append(args, "-C", db.clusterFile)
@@ -187,8 +186,7 @@ func (db *FoundationDB) Status() (string, error) {
 		args = append(args, "-t", db.tag)
 	}
 
-	cmd := fmt.Sprintf("fdbbackup status %s", strings.Join(args, " "))
-	output, err := helper.Exec(cmd)
+	output, err := helper.Exec("fdbbackup", args...)
 	if err != nil {
 		return "", fmt.Errorf("failed to get backup status: %s", err)
 	}
@@ -198,7 +196,7 @@ func (db *FoundationDB) Status() (string, error) {
 
 // Abort aborts the current backup
 func (db *FoundationDB) Abort() error {
-	var args []string
+	args := []string{"abort"}
 
 	if len(db.clusterFile) > 0 {
 		args = append(args, "-C", db.clusterFile)
@@ -208,8 +206,7 @@ func (db *FoundationDB) Abort() error {
 		args = append(args, "-t", db.tag)
 	}
 
-	cmd := fmt.Sprintf("fdbbackup abort %s", strings.Join(args, " "))
-	_, err := helper.Exec(cmd)
+	_, err := helper.Exec("fdbbackup", args...)
 	if err != nil {
 		return fmt.Errorf("failed to abort backup: %s", err)
 	}
@@ -223,7 +220,7 @@ func (db *FoundationDB) Discontinue() error {
 		return fmt.Errorf("backup is not in continuous mode")
 	}
 
-	var args []string
+	args := []string{"discontinue"}
 
 	if len(db.clusterFile) > 0 {
 		args = append(args, "-C", db.clusterFile)
@@ -233,8 +230,7 @@ func (db *FoundationDB) Discontinue() error {
 		args = append(args, "-t", db.tag)
 	}
 
-	cmd := fmt.Sprintf("fdbbackup discontinue %s", strings.Join(args, " "))
-	_, err := helper.Exec(cmd)
+	_, err := helper.Exec("fdbbackup", args...)
 	if err != nil {
 		return fmt.Errorf("failed to discontinue backup: %s", err)
 	}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gobackup/gobackup/helper"
@@ -49,7 +50,6 @@ func (db *InfluxDB2) influxCliArguments() []string {
 	args := make([]string, 0, 15)
 	args = append(args, "backup")
 	args = append(args, "--host="+db.host+"")
-	args = append(args, "--token="+db.token+"")
 	if db.bucket != "" {
 		args = append(args, "--bucket="+db.bucket+"")
 	}
@@ -72,11 +72,12 @@ func (db *InfluxDB2) influxCliArguments() []string {
 	return args
 }
 
-func (db *InfluxDB2) perform() error {
-	logger := logger.Tag("InfluxDB2")
+func (db *InfluxDB2) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "InfluxDB2")
 
 	args := db.influxCliArguments()
-	out, err := helper.Exec("influx", args...)
+	// the token is passed by INFLUX_TOKEN
+	out, err := helper.ExecWithOptions(ctx, helper.ExecOptions{Env: []string{"INFLUX_TOKEN=" + db.token}}, "influx", args...)
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
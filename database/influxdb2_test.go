@@ -67,6 +67,6 @@ func TestInfluxDB2_influxCliArguments(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	assert.Equal(t, db.influxCliArguments(), []string{"backup",
-		"--host=http://localhost:8086", "--token=my-token", "--bucket=my-bucket", "--bucket-id=my-bucket-id",
+		"--host=http://localhost:8086", "--bucket=my-bucket", "--bucket-id=my-bucket-id",
 		"--org=my-org", "--org-id=my-org-id", "--skip-verify", "--http-debug", "/data/backups/influxdb2/influxdb-v2-oss"})
 }
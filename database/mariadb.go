@@ -1,8 +1,8 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -21,13 +21,16 @@ import (
 
 type MariaDB struct {
 	Base
-	host          		string
-	port          		string
-	socket        		string
-	database     		string
-	username      		string
-	password      		string
-	args          		string
+	host     string
+	port     string
+	socket   string
+	database string
+	username string
+	password string
+	args     []string
+
+	// optionFile is the temp option file with the password, it exists while performing
+	optionFile string
 }
 
 func (db *MariaDB) init() (err error) {
@@ -43,8 +46,8 @@ func (db *MariaDB) init() (err error) {
 	db.username = viper.GetString("username")
 	db.password = viper.GetString("password")
 
-	if len(viper.GetString("args")) > 0 {
-		db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
 	}
 
 	// socket
@@ -56,8 +59,12 @@ func (db *MariaDB) init() (err error) {
 	return nil
 }
 
-func (db *MariaDB) build() string {
+func (db *MariaDB) build() *helper.Command {
 	dumpArgs := []string{}
+	if len(db.optionFile) > 0 {
+		dumpArgs = append(dumpArgs, "--defaults-extra-file="+db.optionFile)
+	}
+	dumpArgs = append(dumpArgs, "--backup")
 	if len(db.host) > 0 {
 		dumpArgs = append(dumpArgs, "--host", db.host)
 	}
@@ -70,26 +77,26 @@ func (db *MariaDB) build() string {
 	if len(db.username) > 0 {
 		dumpArgs = append(dumpArgs, "-u", db.username)
 	}
-	if len(db.password) > 0 {
-		dumpArgs = append(dumpArgs, `-p`+db.password)
-	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 	if len(db.database) > 0 {
 		dumpArgs = append(dumpArgs, "--databases="+db.database)
 	}
 	dumpArgs = append(dumpArgs, "--target-dir="+db.dumpPath)
 
-	return "mariadb-backup --backup " + strings.Join(dumpArgs, " ")
+	return helper.NewCommand("mariadb-backup", dumpArgs...)
 }
 
-func (db *MariaDB) perform() error {
-	logger := logger.Tag("MariaDB")
+func (db *MariaDB) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "MariaDB")
 
 	logger.Info("-> Dumping MariaDB...")
-	_, err := helper.Exec(db.build())
+	if db.optionFile, err = writeMySQLOptionFile(db.password, "xtrabackup"); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
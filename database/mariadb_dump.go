@@ -1,9 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -31,7 +31,9 @@ type MariaDBDump struct {
 	password      string
 	tables        []string
 	excludeTables []string
-	args          string
+	args          []string
+	// optionFile is the temp option file with the password, it exists while performing
+	optionFile string
 }
 
 func (db *MariaDBDump) init() (err error) {
@@ -50,8 +52,8 @@ func (db *MariaDBDump) init() (err error) {
 	db.tables = viper.GetStringSlice("tables")
 	db.excludeTables = viper.GetStringSlice("exclude_tables")
 
-	if len(viper.GetString("args")) > 0 {
-		db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
 	}
 
 	// mariadb-dump command
@@ -68,8 +70,11 @@ func (db *MariaDBDump) init() (err error) {
 	return nil
 }
 
-func (db *MariaDBDump) build() string {
+func (db *MariaDBDump) build() *helper.Command {
 	dumpArgs := []string{}
+	if len(db.optionFile) > 0 {
+		dumpArgs = append(dumpArgs, "--defaults-extra-file="+db.optionFile)
+	}
 	if len(db.host) > 0 {
 		dumpArgs = append(dumpArgs, "--host", db.host)
 	}
@@ -82,17 +87,12 @@ func (db *MariaDBDump) build() string {
 	if len(db.username) > 0 {
 		dumpArgs = append(dumpArgs, "-u", db.username)
 	}
-	if len(db.password) > 0 {
-		dumpArgs = append(dumpArgs, `-p`+db.password)
-	}
 
 	for _, table := range db.excludeTables {
 		dumpArgs = append(dumpArgs, "--ignore-table="+db.database+"."+table)
 	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 
 	dumpArgs = append(dumpArgs, db.database)
 	if len(db.tables) > 0 {
@@ -102,14 +102,19 @@ func (db *MariaDBDump) build() string {
 	dumpFilePath := path.Join(db.dumpPath, db.database+".sql")
 	dumpArgs = append(dumpArgs, "--result-file="+dumpFilePath)
 
-	return "mariadb-dump" + " " + strings.Join(dumpArgs, " ")
+	return helper.NewCommand("mariadb-dump", dumpArgs...)
 }
 
-func (db *MariaDBDump) perform() error {
-	logger := logger.Tag("MariaDBDump")
+func (db *MariaDBDump) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "MariaDBDump")
 
 	logger.Info("-> Dumping MariaDB with mariadb-dump...")
-	_, err := helper.Exec(db.build())
+	if db.optionFile, err = writeMySQLOptionFile(db.password); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/spf13/viper"
 
 	"testing"
@@ -38,7 +39,7 @@ func TestMariaDBDump_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	script := db.build()
-	assert.Equal(t, script, "mariadb-dump --host 1.2.3.4 --port 1234 -u user1 -ppass1 --ignore-table=my_db.aa --ignore-table=my_db.bb --a1 --a2 --a3 my_db foo bar --result-file=/data/backups/mariadb-dump/mariadb_dump1/my_db.sql")
+	assert.Equal(t, script, helper.NewCommand("mariadb-dump", "--host", "1.2.3.4", "--port", "1234", "-u", "user1", "--ignore-table=my_db.aa", "--ignore-table=my_db.bb", "--a1", "--a2", "--a3", "my_db", "foo", "bar", "--result-file=/data/backups/mariadb-dump/mariadb_dump1/my_db.sql"))
 }
 
 func TestMariaDBDump_dumpArgsWithAdditionalOptions(t *testing.T) {
@@ -52,13 +53,14 @@ func TestMariaDBDump_dumpArgsWithAdditionalOptions(t *testing.T) {
 		},
 	)
 	db := &MariaDBDump{
-		Base:     base,
-		database: "dummy_test",
-		host:     "127.0.0.2",
-		port:     "6378",
-		password: "*&^92'",
-		args:     "--single-transaction --quick",
+		Base:       base,
+		database:   "dummy_test",
+		host:       "127.0.0.2",
+		port:       "6378",
+		password:   "*&^92'",
+		optionFile: "/tmp/gobackup-1.cnf",
+		args:       []string{"--single-transaction", "--quick"},
 	}
 
-	assert.Equal(t, db.build(), "mariadb-dump --host 127.0.0.2 --port 6378 -p*&^92' --single-transaction --quick dummy_test --result-file=/data/backups/mariadb-dump/mariadb_dump1/dummy_test.sql")
+	assert.Equal(t, db.build(), helper.NewCommand("mariadb-dump", "--defaults-extra-file=/tmp/gobackup-1.cnf", "--host", "127.0.0.2", "--port", "6378", "--single-transaction", "--quick", "dummy_test", "--result-file=/data/backups/mariadb-dump/mariadb_dump1/dummy_test.sql"))
 }
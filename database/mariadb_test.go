@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/spf13/viper"
 
 	// "github.com/spf13/viper"
@@ -38,7 +39,7 @@ func TestMariaDB_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	script := db.build()
-	assert.Equal(t, script, "mariadb-backup --backup --host 1.2.3.4 --port 1234 -u user1 -ppass1 --a1 --a2 --a3 --databases=my_db --target-dir=/data/backups/mariadb/mariadb1")
+	assert.Equal(t, script, helper.NewCommand("mariadb-backup", "--backup", "--host", "1.2.3.4", "--port", "1234", "-u", "user1", "--a1", "--a2", "--a3", "--databases=my_db", "--target-dir=/data/backups/mariadb/mariadb1"))
 }
 
 func TestMariaDB_dumpArgsWithAdditionalOptions(t *testing.T) {
@@ -52,13 +53,14 @@ func TestMariaDB_dumpArgsWithAdditionalOptions(t *testing.T) {
 		},
 	)
 	db := &MariaDB{
-		Base:     base,
-		host:     "127.0.0.2",
-		port:     "6378",
-		password: "*&^92'",
-		database: "my_db2",
-		args:     "--datadir=/var/lib64/mysql",
+		Base:       base,
+		host:       "127.0.0.2",
+		port:       "6378",
+		password:   "*&^92'",
+		optionFile: "/tmp/gobackup-1.cnf",
+		database:   "my_db2",
+		args:       []string{"--datadir=/var/lib64/mysql"},
 	}
 
-	assert.Equal(t, db.build(), "mariadb-backup --backup --host 127.0.0.2 --port 6378 -p*&^92' --datadir=/var/lib64/mysql --databases=my_db2 --target-dir=/data/backups/mariadb/mariadb1")
+	assert.Equal(t, db.build(), helper.NewCommand("mariadb-backup", "--defaults-extra-file=/tmp/gobackup-1.cnf", "--backup", "--host", "127.0.0.2", "--port", "6378", "--datadir=/var/lib64/mysql", "--databases=my_db2", "--target-dir=/data/backups/mariadb/mariadb1"))
 }
\ No newline at end of file
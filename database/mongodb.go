@@ -1,8 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"path"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -34,11 +35,14 @@ type MongoDB struct {
 	excludeTables       []string
 	excludeTablesPrefix []string
 	oplog               bool
-	args                string
+	args                []string
+	// configFile is the temp config file with the uri and password, it exists while performing
+	configFile string
 }
 
 var (
-	mongodumpCli = "mongodump"
+	mongodumpCli    = "mongodump"
+	mongorestoreCli = "mongorestore"
 )
 
 func (db *MongoDB) init() (err error) {
@@ -57,57 +61,80 @@ func (db *MongoDB) init() (err error) {
 	db.authdb = viper.GetString("authdb")
 	db.excludeTables = viper.GetStringSlice("exclude_tables")
 	db.excludeTablesPrefix = viper.GetStringSlice("exclude_tables_prefix")
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	return nil
 }
 
-func (db *MongoDB) build() string {
+func (db *MongoDB) build() *helper.Command {
 	if len(db.uri) > 0 {
-		return mongodumpCli + " " +
-			"--uri=" + db.uri + " " +
-			db.additionOption() + " " +
-			"--out=" + db.dumpPath
-	}
-	return mongodumpCli + " " +
-		db.nameOption() + " " +
-		db.credentialOptions() + " " +
-		db.connectivityOptions() + " " +
-		db.additionOption() + " " +
-		"--out=" + db.dumpPath
+		dumpArgs := []string{db.uriOption()}
+		dumpArgs = append(dumpArgs, db.additionOption()...)
+		dumpArgs = append(dumpArgs, "--out="+db.dumpPath)
+		return helper.NewCommand(mongodumpCli, dumpArgs...)
+	}
+
+	dumpArgs := []string{db.nameOption()}
+	dumpArgs = append(dumpArgs, db.credentialOptions()...)
+	dumpArgs = append(dumpArgs, db.connectivityOptions()...)
+	dumpArgs = append(dumpArgs, db.additionOption()...)
+	dumpArgs = append(dumpArgs, "--out="+db.dumpPath)
+	return helper.NewCommand(mongodumpCli, dumpArgs...)
+}
+
+func (db *MongoDB) restoreBuild() *helper.Command {
+	if len(db.uri) > 0 {
+		return helper.NewCommand(mongorestoreCli, db.uriOption(), "--dir="+db.dumpPath)
+	}
+
+	restoreArgs := []string{db.nameOption()}
+	restoreArgs = append(restoreArgs, db.credentialOptions()...)
+	restoreArgs = append(restoreArgs, db.connectivityOptions()...)
+	restoreArgs = append(restoreArgs, "--dir="+path.Join(db.dumpPath, db.database))
+	return helper.NewCommand(mongorestoreCli, restoreArgs...)
 }
 
 func (db *MongoDB) nameOption() string {
 	return "--db=" + db.database
 }
 
-func (db *MongoDB) credentialOptions() string {
+// uriOption return the uri, or the config file when the uri is in it
+func (db *MongoDB) uriOption() string {
+	if len(db.configFile) > 0 {
+		return "--config=" + db.configFile
+	}
+	return "--uri=" + db.uri
+}
+
+func (db *MongoDB) credentialOptions() []string {
 	opts := []string{}
 	if len(db.username) > 0 {
 		opts = append(opts, "--username="+db.username)
 	}
-	if len(db.password) > 0 {
-		opts = append(opts, `--password=`+db.password)
+	if len(db.configFile) > 0 {
+		opts = append(opts, "--config="+db.configFile)
 	}
 	if len(db.authdb) > 0 {
 		opts = append(opts, "--authenticationDatabase="+db.authdb)
 	}
-	return strings.Join(opts, " ")
+	return opts
 }
 
-func (db *MongoDB) connectivityOptions() string {
+func (db *MongoDB) connectivityOptions() []string {
 	opts := []string{}
 	if len(db.host) > 0 {
-		opts = append(opts, "--host="+db.host+"")
+		opts = append(opts, "--host="+db.host)
 	}
 	if len(db.port) > 0 {
-		opts = append(opts, "--port="+db.port+"")
+		opts = append(opts, "--port="+db.port)
 	}
 
-	return strings.Join(opts, " ")
+	return opts
 }
 
-func (db *MongoDB) additionOption() string {
+func (db *MongoDB) additionOption() []string {
 	opts := []string{}
 	if db.oplog {
 		opts = append(opts, "--oplog")
@@ -121,17 +148,34 @@ func (db *MongoDB) additionOption() string {
 		opts = append(opts, "--excludeCollectionsWithPrefix="+table)
 	}
 
-	if len(db.args) > 0 {
-		opts = append(opts, db.args)
+	return append(opts, db.args...)
+}
+
+// writeConfigFile write the uri or the password into the temp config file of `--config`,
+// as both of them may have the password. Nothing is written if they are not set.
+func (db *MongoDB) writeConfigFile() (err error) {
+	var content string
+	if len(db.uri) > 0 {
+		content = "uri: " + yamlString(db.uri) + "\n"
+	} else if len(db.password) > 0 {
+		content = "password: " + yamlString(db.password) + "\n"
+	} else {
+		return nil
 	}
 
-	return strings.Join(opts, " ")
+	db.configFile, err = helper.WriteSecretFile("gobackup-mongo-*.yml", content)
+	return err
 }
 
-func (db *MongoDB) perform() error {
-	logger := logger.Tag("MongoDB")
+func (db *MongoDB) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "MongoDB")
+
+	if err := db.writeConfigFile(); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.configFile)
 
-	out, err := helper.Exec(db.build())
+	out, err := helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
@@ -139,3 +183,19 @@ func (db *MongoDB) perform() error {
 	logger.Info("dump path:", db.dumpPath)
 	return nil
 }
+
+func (db *MongoDB) restore(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "MongoDB")
+
+	if err := db.writeConfigFile(); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.configFile)
+
+	out, err := helper.ExecCommand(ctx, helper.ExecOptions{}, db.restoreBuild())
+	if err != nil {
+		return fmt.Errorf("-> Restore error: %s", err)
+	}
+	logger.Info(out)
+	return nil
+}
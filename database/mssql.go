@@ -1,8 +1,8 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -26,7 +26,9 @@ type MSSQL struct {
 	username               string
 	password               string
 	trustServerCertificate bool
-	args                   string
+	args                   []string
+	// responseFile is the temp response file of sqlpackage with the password, it exists while performing
+	responseFile string
 }
 
 var (
@@ -46,34 +48,35 @@ func (db *MSSQL) init() (err error) {
 	db.username = viper.GetString("username")
 	db.password = viper.GetString("password")
 	db.trustServerCertificate = viper.GetBool("trustServerCertificate")
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	return nil
 }
 
-func (db *MSSQL) build() string {
-	return sqlpackageCli + " " +
-		"/Action:Export " +
-		db.nameOption() + " " +
-		db.credentialOptions() + " " +
-		db.connectivityOptions() + " " +
-		db.additionOption() + " " +
-		"/TargetFile:" + db.dumpPath + "/" + db.database + ".bacpac"
+func (db *MSSQL) build() *helper.Command {
+	dumpArgs := []string{"/Action:Export", db.nameOption()}
+	dumpArgs = append(dumpArgs, db.credentialOptions()...)
+	dumpArgs = append(dumpArgs, db.connectivityOptions())
+	dumpArgs = append(dumpArgs, db.additionOption()...)
+	dumpArgs = append(dumpArgs, "/TargetFile:"+db.dumpPath+"/"+db.database+".bacpac")
+	return helper.NewCommand(sqlpackageCli, dumpArgs...)
 }
 
 func (db *MSSQL) nameOption() string {
 	return "/SourceDatabaseName:" + db.database
 }
 
-func (db *MSSQL) credentialOptions() string {
+func (db *MSSQL) credentialOptions() []string {
 	opts := []string{}
 	if len(db.username) > 0 {
 		opts = append(opts, "/SourceUser:"+db.username)
 	}
-	if len(db.password) > 0 {
-		opts = append(opts, "/SourcePassword:"+db.password)
+	if len(db.responseFile) > 0 {
+		opts = append(opts, "@"+db.responseFile)
 	}
-	return strings.Join(opts, " ")
+	return opts
 }
 
 func (db *MSSQL) connectivityOptions() string {
@@ -90,23 +93,27 @@ func (db *MSSQL) connectivityOptions() string {
 	return "/SourceServerName:" + host + "," + port
 }
 
-func (db *MSSQL) additionOption() string {
+func (db *MSSQL) additionOption() []string {
 	opts := []string{}
 	if db.trustServerCertificate {
 		opts = append(opts, "/SourceTrustServerCertificate:True")
 	}
 
-	if len(db.args) > 0 {
-		opts = append(opts, db.args)
-	}
-
-	return strings.Join(opts, " ")
+	return append(opts, db.args...)
 }
 
-func (db *MSSQL) perform() error {
-	logger := logger.Tag("MSSQL")
+func (db *MSSQL) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "MSSQL")
+
+	if len(db.password) > 0 {
+		var err error
+		if db.responseFile, err = helper.WriteSecretFile("gobackup-mssql-*.rsp", "/SourcePassword:"+db.password+"\n"); err != nil {
+			return err
+		}
+		defer removeOptionFile(db.responseFile)
+	}
 
-	out, err := helper.Exec(db.build())
+	out, err := helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
 )
@@ -37,7 +38,7 @@ func TestMSSQL_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 
-	assert.Equal(t, db.build(), "sqlpackage /Action:Export /SourceDatabaseName:AdventureWorks /SourceUser:AdminUser /SourcePassword:AdminPassword1 /SourceServerName:1.2.3.4,1234 /SourceTrustServerCertificate:True /OverwriteFiles:True /MaxParallelism:4 /TargetFile:/data/backups/mssql/mssql1/AdventureWorks.bacpac")
+	assert.Equal(t, db.build(), helper.NewCommand("sqlpackage", "/Action:Export", "/SourceDatabaseName:AdventureWorks", "/SourceUser:AdminUser", "/SourceServerName:1.2.3.4,1234", "/SourceTrustServerCertificate:True", "/OverwriteFiles:True", "/MaxParallelism:4", "/TargetFile:/data/backups/mssql/mssql1/AdventureWorks.bacpac"))
 }
 
 func TestMSSQL_credentialOptions(t *testing.T) {
@@ -46,7 +47,7 @@ func TestMSSQL_credentialOptions(t *testing.T) {
 		password: "AdminPassword1",
 	}
 
-	assert.Equal(t, db.credentialOptions(), "/SourceUser:AdminUser /SourcePassword:AdminPassword1")
+	assert.Equal(t, db.credentialOptions(), []string{"/SourceUser:AdminUser"})
 }
 
 func TestMSSQL_connectivityOptions(t *testing.T) {
@@ -72,11 +73,11 @@ func TestMSSQL_connectivityOptions(t *testing.T) {
 
 func TestMSSQL_trustServerCertificateOption(t *testing.T) {
 	db := &MSSQL{trustServerCertificate: true}
-	assert.Equal(t, db.additionOption(), "/SourceTrustServerCertificate:True")
+	assert.Equal(t, db.additionOption(), []string{"/SourceTrustServerCertificate:True"})
 	db.trustServerCertificate = false
-	assert.Equal(t, db.additionOption(), "")
+	assert.Equal(t, db.additionOption(), []string{})
 	db = &MSSQL{}
-	assert.Equal(t, db.additionOption(), "")
+	assert.Equal(t, db.additionOption(), []string{})
 }
 
 func TestMSSQL_sqlpackage(t *testing.T) {
@@ -92,5 +93,9 @@ func TestMSSQL_sqlpackage(t *testing.T) {
 		password:               "xxxYYY2$",
 		trustServerCertificate: true,
 	}
-	assert.Equal(t, db.build(), "sqlpackage /Action:Export /SourceDatabaseName:test /SourceUser:testUser /SourcePassword:xxxYYY2$ /SourceServerName:127.0.0.1,1433 /SourceTrustServerCertificate:True /TargetFile:/tmp/gobackup/test/test.bacpac")
+	assert.Equal(t, db.build(), helper.NewCommand("sqlpackage", "/Action:Export", "/SourceDatabaseName:test", "/SourceUser:testUser", "/SourceServerName:127.0.0.1,1433", "/SourceTrustServerCertificate:True", "/TargetFile:/tmp/gobackup/test/test.bacpac"))
+
+	// the password is in the response file
+	db.responseFile = "/tmp/gobackup-mssql-1.rsp"
+	assert.Equal(t, db.build(), helper.NewCommand("sqlpackage", "/Action:Export", "/SourceDatabaseName:test", "/SourceUser:testUser", "@/tmp/gobackup-mssql-1.rsp", "/SourceServerName:127.0.0.1,1433", "/SourceTrustServerCertificate:True", "/TargetFile:/tmp/gobackup/test/test.bacpac"))
 }
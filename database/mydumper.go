@@ -1,8 +1,8 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -33,7 +33,9 @@ type MyDumper struct {
 	tables   []string
 	threads  int
 	compress bool
-	args     string
+	args     []string
+	// optionFile is the temp option file with the password, it exists while performing
+	optionFile string
 }
 
 func (db *MyDumper) init() (err error) {
@@ -55,8 +57,8 @@ func (db *MyDumper) init() (err error) {
 	db.threads = viper.GetInt("threads")
 	db.compress = viper.GetBool("compress")
 
-	if len(viper.GetString("args")) > 0 {
-		db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
 	}
 
 	// socket
@@ -68,8 +70,11 @@ func (db *MyDumper) init() (err error) {
 	return nil
 }
 
-func (db *MyDumper) build() string {
+func (db *MyDumper) build() *helper.Command {
 	dumpArgs := []string{}
+	if len(db.optionFile) > 0 {
+		dumpArgs = append(dumpArgs, "--defaults-extra-file="+db.optionFile)
+	}
 	if len(db.host) > 0 {
 		dumpArgs = append(dumpArgs, "--host", db.host)
 	}
@@ -82,9 +87,6 @@ func (db *MyDumper) build() string {
 	if len(db.username) > 0 {
 		dumpArgs = append(dumpArgs, "--user", db.username)
 	}
-	if len(db.password) > 0 {
-		dumpArgs = append(dumpArgs, "--password", db.password)
-	}
 
 	if len(db.database) > 0 {
 		dumpArgs = append(dumpArgs, "--database", db.database)
@@ -106,20 +108,23 @@ func (db *MyDumper) build() string {
 		dumpArgs = append(dumpArgs, "--compress")
 	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 
 	dumpArgs = append(dumpArgs, "--outputdir", db.dumpPath)
 
-	return "mydumper" + " " + strings.Join(dumpArgs, " ")
+	return helper.NewCommand("mydumper", dumpArgs...)
 }
 
-func (db *MyDumper) perform() error {
-	logger := logger.Tag("MyDumper")
+func (db *MyDumper) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "MyDumper")
 
 	logger.Info("-> Dumping MySQL with mydumper...")
-	_, err := helper.Exec(db.build())
+	if db.optionFile, err = writeMySQLOptionFile(db.password, "mydumper"); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		logger.Errorf("-> Dump error: %s", err)
 		return err
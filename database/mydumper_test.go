@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/spf13/viper"
 
 	"testing"
@@ -38,7 +39,7 @@ func TestMyDumper_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	script := db.build()
-	assert.Equal(t, script, "mydumper --host 1.2.3.4 --port 1234 --user user1 --password pass1 --database my_db --threads 8 --compress --a1 --a2 --a3 --outputdir /data/backups/mydumper/mydumper1")
+	assert.Equal(t, script, helper.NewCommand("mydumper", "--host", "1.2.3.4", "--port", "1234", "--user", "user1", "--database", "my_db", "--threads", "8", "--compress", "--a1", "--a2", "--a3", "--outputdir", "/data/backups/mydumper/mydumper1"))
 }
 
 func TestMyDumper_dumpArgsWithAdditionalOptions(t *testing.T) {
@@ -52,15 +53,16 @@ func TestMyDumper_dumpArgsWithAdditionalOptions(t *testing.T) {
 		},
 	)
 	db := &MyDumper{
-		Base:     base,
-		database: "dummy_test",
-		host:     "127.0.0.2",
-		port:     "6378",
-		password: "*&^92'",
-		threads:  4,
-		compress: false,
-		args:     "--long-query-guard 300",
+		Base:       base,
+		database:   "dummy_test",
+		host:       "127.0.0.2",
+		port:       "6378",
+		password:   "*&^92'",
+		optionFile: "/tmp/gobackup-1.cnf",
+		threads:    4,
+		compress:   false,
+		args:       []string{"--long-query-guard", "300"},
 	}
 
-	assert.Equal(t, db.build(), "mydumper --host 127.0.0.2 --port 6378 --password *&^92' --database dummy_test --threads 4 --long-query-guard 300 --outputdir /data/backups/mydumper/mydumper1")
+	assert.Equal(t, db.build(), helper.NewCommand("mydumper", "--defaults-extra-file=/tmp/gobackup-1.cnf", "--host", "127.0.0.2", "--port", "6378", "--database", "dummy_test", "--threads", "4", "--long-query-guard", "300", "--outputdir", "/data/backups/mydumper/mydumper1"))
 }
@@ -1,9 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -29,7 +29,9 @@ type MySQL struct {
 	password      string
 	tables        []string
 	excludeTables []string
-	args          string
+	args          []string
+	// optionFile is the temp option file with the password, it exists while performing
+	optionFile string
 }
 
 func (db *MySQL) init() (err error) {
@@ -48,8 +50,8 @@ func (db *MySQL) init() (err error) {
 	db.tables = viper.GetStringSlice("tables")
 	db.excludeTables = viper.GetStringSlice("exclude_tables")
 
-	if len(viper.GetString("args")) > 0 {
-		db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
 	}
 
 	// mysqldump command
@@ -66,51 +68,86 @@ func (db *MySQL) init() (err error) {
 	return nil
 }
 
-func (db *MySQL) build() string {
-	dumpArgs := []string{}
+func (db *MySQL) connectionOptions() []string {
+	opts := []string{}
+	if len(db.optionFile) > 0 {
+		opts = append(opts, "--defaults-extra-file="+db.optionFile)
+	}
 	if len(db.host) > 0 {
-		dumpArgs = append(dumpArgs, "--host", db.host)
+		opts = append(opts, "--host", db.host)
 	}
 	if len(db.port) > 0 {
-		dumpArgs = append(dumpArgs, "--port", db.port)
+		opts = append(opts, "--port", db.port)
 	}
 	if len(db.socket) > 0 {
-		dumpArgs = append(dumpArgs, "--socket", db.socket)
+		opts = append(opts, "--socket", db.socket)
 	}
 	if len(db.username) > 0 {
-		dumpArgs = append(dumpArgs, "-u", db.username)
-	}
-	if len(db.password) > 0 {
-		dumpArgs = append(dumpArgs, `-p`+db.password)
+		opts = append(opts, "-u", db.username)
 	}
 
+	return opts
+}
+
+func (db *MySQL) build() *helper.Command {
+	dumpArgs := db.connectionOptions()
+
 	for _, table := range db.excludeTables {
 		dumpArgs = append(dumpArgs, "--ignore-table="+db.database+"."+table)
 	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 
 	dumpArgs = append(dumpArgs, db.database)
 	if len(db.tables) > 0 {
 		dumpArgs = append(dumpArgs, db.tables...)
 	}
 
-	dumpFilePath := path.Join(db.dumpPath, db.database+".sql")
-	dumpArgs = append(dumpArgs, "--result-file="+dumpFilePath)
+	dumpArgs = append(dumpArgs, "--result-file="+db.dumpFilePath())
+
+	return helper.NewCommand("mysqldump", dumpArgs...)
+}
+
+func (db *MySQL) dumpFilePath() string {
+	return path.Join(db.dumpPath, db.database+".sql")
+}
 
-	return "mysqldump" + " " + strings.Join(dumpArgs, " ")
+func (db *MySQL) restoreArgs() []string {
+	restoreArgs := db.connectionOptions()
+	restoreArgs = append(restoreArgs, db.database, "-e", "source "+db.dumpFilePath())
+	return restoreArgs
 }
 
-func (db *MySQL) perform() error {
-	logger := logger.Tag("MySQL")
+func (db *MySQL) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "MySQL")
 
 	logger.Info("-> Dumping MySQL...")
-	_, err := helper.Exec(db.build())
+	if db.optionFile, err = writeMySQLOptionFile(db.password); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
 	logger.Info("dump path:", db.dumpPath)
 	return nil
 }
+
+func (db *MySQL) restore(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "MySQL")
+
+	logger.Info("-> Restoring MySQL...")
+	if db.optionFile, err = writeMySQLOptionFile(db.password); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecContext(ctx, "mysql", db.restoreArgs()...)
+	if err != nil {
+		return fmt.Errorf("-> Restore error: %s", err)
+	}
+	logger.Info("restored from:", db.dumpFilePath())
+	return nil
+}
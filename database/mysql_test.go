@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/spf13/viper"
 
 	// "github.com/spf13/viper"
@@ -40,10 +41,33 @@ func TestMySQL_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	script := db.build()
-	assert.Equal(t, script, "mysqldump --host 1.2.3.4 --port 1234 -u user1 -ppass1 --ignore-table=my_db.aa --ignore-table=my_db.bb --a1 --a2 --a3 my_db foo bar --result-file=/data/backups/mysql/mysql1/my_db.sql")
+	assert.Equal(t, script, helper.NewCommand("mysqldump", "--host", "1.2.3.4", "--port", "1234", "-u", "user1", "--ignore-table=my_db.aa", "--ignore-table=my_db.bb", "--a1", "--a2", "--a3", "my_db", "foo", "bar", "--result-file=/data/backups/mysql/mysql1/my_db.sql"))
 }
 
 func TestMySQL_dumpArgsWithAdditionalOptions(t *testing.T) {
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: "/data/backups/",
+		},
+		config.SubConfig{
+			Type: "mysql",
+			Name: "mysql1",
+		},
+	)
+	db := &MySQL{
+		Base:       base,
+		database:   "dummy_test",
+		host:       "127.0.0.2",
+		port:       "6378",
+		password:   "*&^92'",
+		optionFile: "/tmp/gobackup-1.cnf",
+		args:       []string{"--single-transaction", "--quick"},
+	}
+
+	assert.Equal(t, db.build(), helper.NewCommand("mysqldump", "--defaults-extra-file=/tmp/gobackup-1.cnf", "--host", "127.0.0.2", "--port", "6378", "--single-transaction", "--quick", "dummy_test", "--result-file=/data/backups/mysql/mysql1/dummy_test.sql"))
+}
+
+func TestMySQL_restoreArgs(t *testing.T) {
 	base := newBase(
 		config.ModelConfig{
 			DumpPath: "/data/backups/",
@@ -58,9 +82,39 @@ func TestMySQL_dumpArgsWithAdditionalOptions(t *testing.T) {
 		database: "dummy_test",
 		host:     "127.0.0.2",
 		port:     "6378",
-		password: "*&^92'",
-		args:     "--single-transaction --quick",
+		username: "root",
+		password: "pass1",
 	}
 
-	assert.Equal(t, db.build(), "mysqldump --host 127.0.0.2 --port 6378 -p*&^92' --single-transaction --quick dummy_test --result-file=/data/backups/mysql/mysql1/dummy_test.sql")
+	assert.Equal(t, db.restoreArgs(), []string{"--host", "127.0.0.2", "--port", "6378", "-u", "root", "dummy_test", "-e", "source /data/backups/mysql/mysql1/dummy_test.sql"})
+
+	db.optionFile = "/tmp/gobackup-1.cnf"
+	assert.Equal(t, db.restoreArgs(), []string{"--defaults-extra-file=/tmp/gobackup-1.cnf", "--host", "127.0.0.2", "--port", "6378", "-u", "root", "dummy_test", "-e", "source /data/backups/mysql/mysql1/dummy_test.sql"})
+}
+
+func TestMySQL_buildWithSpaces(t *testing.T) {
+	viper := viper.New()
+	viper.Set("database", "my db")
+	viper.Set("tables", []string{"order items"})
+	viper.Set("args", []any{"--where", "id > 10"})
+
+	base := newBase(
+		config.ModelConfig{
+			DumpPath: "/data/my backups",
+		},
+		config.SubConfig{
+			Type:  "mysql",
+			Name:  "mysql1",
+			Viper: viper,
+		},
+	)
+	db := &MySQL{
+		Base: base,
+	}
+
+	assert.NoError(t, db.init())
+	assert.Equal(t, db.build(), helper.NewCommand("mysqldump", "--host", "127.0.0.1", "--port", "3306", "-u", "root", "--where", "id > 10", "my db", "order items", "--result-file=/data/my backups/mysql/mysql1/my db.sql"))
+
+	viper.Set("args", `--where "id > 10`)
+	assert.EqualError(t, db.init(), `invalid args: unclosed quote " in: --where "id > 10`)
 }
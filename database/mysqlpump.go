@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -33,7 +34,9 @@ type MySQLPump struct {
 	tables        []string
 	excludeTables []string
 	parallel      int
-	args          string
+	args          []string
+	// optionFile is the temp option file with the password, it exists while performing
+	optionFile string
 }
 
 func (db *MySQLPump) init() (err error) {
@@ -54,8 +57,8 @@ func (db *MySQLPump) init() (err error) {
 	db.excludeTables = viper.GetStringSlice("exclude_tables")
 	db.parallel = viper.GetInt("parallel")
 
-	if len(viper.GetString("args")) > 0 {
-		db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
 	}
 
 	// mysqlpump command
@@ -72,8 +75,11 @@ func (db *MySQLPump) init() (err error) {
 	return nil
 }
 
-func (db *MySQLPump) build() string {
+func (db *MySQLPump) build() *helper.Command {
 	dumpArgs := []string{}
+	if len(db.optionFile) > 0 {
+		dumpArgs = append(dumpArgs, "--defaults-extra-file="+db.optionFile)
+	}
 	if len(db.host) > 0 {
 		dumpArgs = append(dumpArgs, "--host="+db.host)
 	}
@@ -86,9 +92,6 @@ func (db *MySQLPump) build() string {
 	if len(db.username) > 0 {
 		dumpArgs = append(dumpArgs, "--user="+db.username)
 	}
-	if len(db.password) > 0 {
-		dumpArgs = append(dumpArgs, `--password=`+db.password)
-	}
 
 	for _, table := range db.excludeTables {
 		dumpArgs = append(dumpArgs, "--exclude-tables="+table)
@@ -98,9 +101,7 @@ func (db *MySQLPump) build() string {
 		dumpArgs = append(dumpArgs, fmt.Sprintf("--default-parallelism=%d", db.parallel))
 	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 
 	dumpArgs = append(dumpArgs, "--databases", db.database)
 	if len(db.tables) > 0 {
@@ -110,14 +111,19 @@ func (db *MySQLPump) build() string {
 	dumpFilePath := path.Join(db.dumpPath, db.database+".sql")
 	dumpArgs = append(dumpArgs, "--result-file="+dumpFilePath)
 
-	return "mysqlpump" + " " + strings.Join(dumpArgs, " ")
+	return helper.NewCommand("mysqlpump", dumpArgs...)
 }
 
-func (db *MySQLPump) perform() error {
-	logger := logger.Tag("MySQLPump")
+func (db *MySQLPump) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "MySQLPump")
 
 	logger.Info("-> Dumping MySQL with mysqlpump...")
-	_, err := helper.Exec(db.build())
+	if db.optionFile, err = writeMySQLOptionFile(db.password); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		return fmt.Errorf("-> Dump error: %s", err)
 	}
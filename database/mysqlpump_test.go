@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/spf13/viper"
 
 	"testing"
@@ -39,7 +40,7 @@ func TestMySQLPump_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	script := db.build()
-	assert.Equal(t, script, "mysqlpump --host=1.2.3.4 --port=1234 --user=user1 --password=pass1 --exclude-tables=aa --exclude-tables=bb --default-parallelism=4 --a1 --a2 --a3 --databases my_db --include-tables=foo,bar --result-file=/data/backups/mysqlpump/mysqlpump1/my_db.sql")
+	assert.Equal(t, script, helper.NewCommand("mysqlpump", "--host=1.2.3.4", "--port=1234", "--user=user1", "--exclude-tables=aa", "--exclude-tables=bb", "--default-parallelism=4", "--a1", "--a2", "--a3", "--databases", "my_db", "--include-tables=foo,bar", "--result-file=/data/backups/mysqlpump/mysqlpump1/my_db.sql"))
 }
 
 func TestMySQLPump_dumpArgsWithAdditionalOptions(t *testing.T) {
@@ -53,14 +54,15 @@ func TestMySQLPump_dumpArgsWithAdditionalOptions(t *testing.T) {
 		},
 	)
 	db := &MySQLPump{
-		Base:     base,
-		database: "dummy_test",
-		host:     "127.0.0.2",
-		port:     "6378",
-		password: "*&^92'",
-		parallel: 2,
-		args:     "--single-transaction",
+		Base:       base,
+		database:   "dummy_test",
+		host:       "127.0.0.2",
+		port:       "6378",
+		password:   "*&^92'",
+		optionFile: "/tmp/gobackup-1.cnf",
+		parallel:   2,
+		args:       []string{"--single-transaction"},
 	}
 
-	assert.Equal(t, db.build(), "mysqlpump --host=127.0.0.2 --port=6378 --password=*&^92' --default-parallelism=2 --single-transaction --databases dummy_test --result-file=/data/backups/mysqlpump/mysqlpump1/dummy_test.sql")
+	assert.Equal(t, db.build(), helper.NewCommand("mysqlpump", "--defaults-extra-file=/tmp/gobackup-1.cnf", "--host=127.0.0.2", "--port=6378", "--default-parallelism=2", "--single-transaction", "--databases", "dummy_test", "--result-file=/data/backups/mysqlpump/mysqlpump1/dummy_test.sql"))
 }
@@ -1,8 +1,8 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path"
 	"path/filepath"
 	"strings"
@@ -40,7 +40,7 @@ type PostgreSQL struct {
 	password      string
 	compress      string
 	format        string
-	args          string
+	args          []string
 	_dumpFilePath string
 }
 
@@ -67,7 +67,9 @@ func (db *PostgreSQL) init() (err error) {
 	db.excludeTables = viper.GetStringSlice("exclude_tables")
 	db.compress = viper.GetString("compress")
 	db.format = ".sql"
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	if len(db.database) == 0 {
 		return fmt.Errorf("PostgreSQL database config is required")
@@ -92,60 +94,93 @@ func (db *PostgreSQL) init() (err error) {
 	return nil
 }
 
-func (db *PostgreSQL) build() string {
-	// pg_dump command
-	var dumpArgs []string
-
+func (db *PostgreSQL) connectionOptions() (opts []string) {
 	if len(db.host) > 0 {
-		dumpArgs = append(dumpArgs, "--host="+db.host)
+		opts = append(opts, "--host="+db.host)
 	}
 	if len(db.port) > 0 {
-		dumpArgs = append(dumpArgs, "--port="+db.port)
+		opts = append(opts, "--port="+db.port)
 	}
 	if len(db.socket) > 0 {
 		host := filepath.Dir(db.socket)
 		port := strings.TrimPrefix(filepath.Ext(db.socket), ".")
-		dumpArgs = append(dumpArgs, "--host="+host, "--port="+port)
+		opts = append(opts, "--host="+host, "--port="+port)
 	}
 	if len(db.username) > 0 {
-		dumpArgs = append(dumpArgs, "--username="+db.username)
+		opts = append(opts, "--username="+db.username)
 	}
 
+	return
+}
+
+func (db *PostgreSQL) build() *helper.Command {
+	// pg_dump command
+	dumpArgs := db.connectionOptions()
+
 	// include / exclude tables
-	if len(db.tables) > 0 {
-		dumpArgs = append(dumpArgs, "--table="+strings.Join(db.tables, " --table="))
+	for _, table := range db.tables {
+		dumpArgs = append(dumpArgs, "--table="+table)
 	}
 
-	if len(db.excludeTables) > 0 {
-		dumpArgs = append(dumpArgs, "--exclude-table="+strings.Join(db.excludeTables, " --exclude-table="))
+	for _, table := range db.excludeTables {
+		dumpArgs = append(dumpArgs, "--exclude-table="+table)
 	}
 
 	if len(db.compress) > 0 {
 		dumpArgs = append(dumpArgs, "--compress="+db.compress, "--format=custom")
 	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 
 	dumpArgs = append(dumpArgs, db.database)
 	dumpArgs = append(dumpArgs, "-f", db._dumpFilePath)
 
-	return "pg_dump " + strings.Join(dumpArgs, " ")
+	return helper.NewCommand("pg_dump", dumpArgs...)
+}
+
+// env of pg_dump and the restore commands, the password is passed by PGPASSWORD
+func (db *PostgreSQL) env() []string {
+	if len(db.password) == 0 {
+		return nil
+	}
+
+	return []string{"PGPASSWORD=" + db.password}
 }
 
-func (db *PostgreSQL) perform() error {
-	logger := logger.Tag("PostgreSQL")
+func (db *PostgreSQL) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "PostgreSQL")
 
 	logger.Info("-> Dumping PostgreSQL...")
-	if len(db.password) > 0 {
-		os.Setenv("PGPASSWORD", db.password)
+	_, err := helper.ExecCommand(ctx, helper.ExecOptions{Env: db.env()}, db.build())
+	if err != nil {
+		return err
+	}
+	logger.Info("dump path:", db._dumpFilePath)
+	return nil
+}
+
+// restoreBuild use pg_restore for custom format dump, otherwise psql
+func (db *PostgreSQL) restoreBuild() *helper.Command {
+	restoreArgs := db.connectionOptions()
+	restoreArgs = append(restoreArgs, "--dbname="+db.database)
+
+	if len(db.compress) > 0 {
+		restoreArgs = append(restoreArgs, db._dumpFilePath)
+		return helper.NewCommand("pg_restore", restoreArgs...)
 	}
 
-	_, err := helper.Exec(db.build())
+	restoreArgs = append(restoreArgs, "-f", db._dumpFilePath)
+	return helper.NewCommand("psql", restoreArgs...)
+}
+
+func (db *PostgreSQL) restore(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "PostgreSQL")
+
+	logger.Info("-> Restoring PostgreSQL...")
+	_, err := helper.ExecCommand(ctx, helper.ExecOptions{Env: db.env()}, db.restoreBuild())
 	if err != nil {
 		return err
 	}
-	logger.Info("dump path:", db._dumpFilePath)
+	logger.Info("restored from:", db._dumpFilePath)
 	return nil
 }
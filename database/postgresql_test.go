@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
 )
@@ -38,18 +39,18 @@ func TestPostgreSQL_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 
-	assert.Equal(t, db.build(), "pg_dump --host=1.2.3.4 --port=1234 --username=user1 --table=foo --table=bar --exclude-table=aa --exclude-table=bb --foo --bar --dar my_db -f /data/backups/postgresql/postgresql1/my_db.sql")
+	assert.Equal(t, db.build(), helper.NewCommand("pg_dump", "--host=1.2.3.4", "--port=1234", "--username=user1", "--table=foo", "--table=bar", "--exclude-table=aa", "--exclude-table=bb", "--foo", "--bar", "--dar", "my_db", "-f", "/data/backups/postgresql/postgresql1/my_db.sql"))
 }
 
 func Test_PostgreSQL_prepareForSocket(t *testing.T) {
 	db := &PostgreSQL{
 		database:      "foo",
 		socket:        "/var/run/postgresql/pg.5432",
-		args:          "--foo",
+		args:          []string{"--foo"},
 		_dumpFilePath: "/tmp/foo.sql",
 	}
 
-	assert.Equal(t, db.build(), "pg_dump --host=/var/run/postgresql --port=5432 --foo foo -f /tmp/foo.sql")
+	assert.Equal(t, db.build(), helper.NewCommand("pg_dump", "--host=/var/run/postgresql", "--port=5432", "--foo", "foo", "-f", "/tmp/foo.sql"))
 }
 
 func Test_PostgreSQL_compressionGzip(t *testing.T) {
@@ -80,7 +81,7 @@ func Test_PostgreSQL_compressionGzip(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 
-	assert.Equal(t, db.build(), "pg_dump --host=1.2.3.4 --port=1234 --username=user1 --compress=gzip:2 --format=custom my_db -f /data/backups/postgresql/postgresql1/my_db.sql.gz")
+	assert.Equal(t, db.build(), helper.NewCommand("pg_dump", "--host=1.2.3.4", "--port=1234", "--username=user1", "--compress=gzip:2", "--format=custom", "my_db", "-f", "/data/backups/postgresql/postgresql1/my_db.sql.gz"))
 }
 
 func Test_PostgreSQL_compressionNotSupported(t *testing.T) {
@@ -111,3 +112,32 @@ func Test_PostgreSQL_compressionNotSupported(t *testing.T) {
 	err := db.init()
 	assert.Error(t, err)
 }
+
+func Test_PostgreSQL_restoreBuild(t *testing.T) {
+	db := &PostgreSQL{
+		host:          "1.2.3.4",
+		port:          "1234",
+		username:      "user1",
+		database:      "my_db",
+		_dumpFilePath: "/tmp/my_db.sql",
+	}
+
+	assert.Equal(t, db.restoreBuild(), helper.NewCommand("psql", "--host=1.2.3.4", "--port=1234", "--username=user1", "--dbname=my_db", "-f", "/tmp/my_db.sql"))
+
+	db.compress = "gzip:9"
+	db._dumpFilePath = "/tmp/my_db.sql.gz"
+	assert.Equal(t, db.restoreBuild(), helper.NewCommand("pg_restore", "--host=1.2.3.4", "--port=1234", "--username=user1", "--dbname=my_db", "/tmp/my_db.sql.gz"))
+}
+
+func TestPostgreSQL_buildWithSpaces(t *testing.T) {
+	db := &PostgreSQL{
+		database:      "my db",
+		host:          "127.0.0.1",
+		tables:        []string{"order items", "users"},
+		excludeTables: []string{"audit log"},
+		args:          []string{"--section=data", "--exclude-schema=old schema"},
+		_dumpFilePath: "/tmp/my backups/my db.sql",
+	}
+
+	assert.Equal(t, db.build(), helper.NewCommand("pg_dump", "--host=127.0.0.1", "--table=order items", "--table=users", "--exclude-table=audit log", "--section=data", "--exclude-schema=old schema", "my db", "-f", "/tmp/my backups/my db.sql"))
+}
@@ -1,9 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -36,7 +36,7 @@ type Redis struct {
 	invokeSave bool
 	// path of rdb file, example: /var/lib/redis/dump.rdb
 	rdbPath string
-	args    string
+	args    []string
 
 	_dumpFilePath string
 }
@@ -56,7 +56,9 @@ func (db *Redis) init() (err error) {
 	db.password = viper.GetString("password")
 	db.rdbPath = viper.GetString("rdb_path")
 	db.invokeSave = viper.GetBool("invoke_save")
-	db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
+	}
 
 	// Force set invokeSave = false, when mode = copy
 	if viper.GetString("mode") == "copy" {
@@ -80,61 +82,60 @@ func (db *Redis) init() (err error) {
 	return nil
 }
 
-func (db *Redis) build() string {
+func (db *Redis) build() *helper.Command {
 	if db.mode == redisModeCopy {
-		return strings.Join([]string{
-			"cp",
-			db.rdbPath,
-			db._dumpFilePath,
-		}, " ")
+		return helper.NewCommand("cp", db.rdbPath, db._dumpFilePath)
 	}
 
 	// redis-cli command
-	args := []string{"redis-cli"}
+	args := []string{}
 	if len(db.host) > 0 {
-		args = append(args, "-h "+db.host)
+		args = append(args, "-h", db.host)
 	}
 	if len(db.port) > 0 {
-		args = append(args, "-p "+db.port)
+		args = append(args, "-p", db.port)
 	}
 	if len(db.socket) > 0 {
 		args = append(args, "-s", db.socket)
 	}
-	if len(db.password) > 0 {
-		args = append(args, `-a `+db.password)
-	}
-
-	if len(db.args) > 0 {
-		args = append(args, db.args)
-	}
 
+	args = append(args, db.args...)
 	args = append(args, "--rdb", db._dumpFilePath)
 
-	return strings.Join(args, " ")
+	return helper.NewCommand("redis-cli", args...)
+}
+
+// env of redis-cli, the password is passed by REDISCLI_AUTH
+func (db *Redis) env() []string {
+	if len(db.password) == 0 {
+		return nil
+	}
+
+	return []string{"REDISCLI_AUTH=" + db.password}
 }
 
-func (db *Redis) perform() (err error) {
+func (db *Redis) perform(ctx context.Context) (err error) {
 	if db.mode == redisModeCopy {
 		if !helper.IsExistsPath(db.rdbPath) {
 			return fmt.Errorf("Redis RDB file: %s does not exist", db.rdbPath)
 		}
 	}
 
-	if err = db.trySave(); err != nil {
+	if err = db.trySave(ctx); err != nil {
 		return
 	}
 
 	if db.mode == redisModeCopy {
-		err = db.copy()
+		err = db.copy(ctx)
 	} else {
-		err = db.sync()
+		err = db.sync(ctx)
 	}
 
 	return err
 }
 
-func (db *Redis) trySave() error {
-	logger := logger.Tag("Redis")
+func (db *Redis) trySave(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "Redis")
 
 	if !db.invokeSave {
 		return nil
@@ -142,7 +143,9 @@ func (db *Redis) trySave() error {
 
 	// FIXME: add retry
 	logger.Info("Perform redis-cli save...")
-	out, err := helper.Exec(db.build(), "SAVE")
+	cmd := db.build()
+	cmd.Args = append(cmd.Args, "SAVE")
+	out, err := helper.ExecCommand(ctx, helper.ExecOptions{Env: db.env()}, cmd)
 	if err != nil {
 		return fmt.Errorf("redis-cli SAVE failed %s", err)
 	}
@@ -150,11 +153,11 @@ func (db *Redis) trySave() error {
 	return nil
 }
 
-func (db *Redis) sync() error {
-	logger := logger.Tag("Redis")
+func (db *Redis) sync(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "Redis")
 
 	logger.Info("Syncing redis dump to", db._dumpFilePath)
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecCommand(ctx, helper.ExecOptions{Env: db.env()}, db.build())
 	if err != nil {
 		return fmt.Errorf("dump redis error: %s", err)
 	}
@@ -166,13 +169,30 @@ func (db *Redis) sync() error {
 	return nil
 }
 
-func (db *Redis) copy() error {
-	logger := logger.Tag("Redis")
+func (db *Redis) copy(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "Redis")
 
 	logger.Info("Copying redis dump to", db._dumpFilePath)
-	_, err := helper.Exec(db.build())
+	_, err := helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
+	if err != nil {
+		return fmt.Errorf("copy redis dump file error: %s", err)
+	}
+	return nil
+}
+
+// restore copy the dump RDB file back to rdb_path, Redis must be restarted to load it
+func (db *Redis) restore(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "Redis")
+
+	if !helper.IsExistsPath(db._dumpFilePath) {
+		return fmt.Errorf("dump file %s not found", db._dumpFilePath)
+	}
+
+	logger.Info("Copying redis dump to", db.rdbPath)
+	_, err := helper.ExecContext(ctx, "cp", db._dumpFilePath, db.rdbPath)
 	if err != nil {
 		return fmt.Errorf("copy redis dump file error: %s", err)
 	}
+	logger.Warn("Restart Redis to load the restored RDB file.")
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
 )
@@ -40,7 +41,7 @@ func TestRedis_init_for_copy(t *testing.T) {
 	assert.Equal(t, db.invokeSave, false)
 	assert.Equal(t, db.mode, redisModeCopy)
 	assert.Equal(t, db._dumpFilePath, "/data/backups/redis/redis1/dump.rdb")
-	assert.Equal(t, db.build(), "cp /var/db/redis/dump.rdb /data/backups/redis/redis1/dump.rdb")
+	assert.Equal(t, db.build(), helper.NewCommand("cp", "/var/db/redis/dump.rdb", "/data/backups/redis/redis1/dump.rdb"))
 }
 
 func TestRedis_init_for_sync(t *testing.T) {
@@ -73,5 +74,5 @@ func TestRedis_init_for_sync(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 
-	assert.Equal(t, db.build(), "redis-cli -h 1.2.3.4 -p 1234 -a pass1 --tls --cacert redis_ca.pem --rdb /data/backups/redis/redis1/dump.rdb")
+	assert.Equal(t, db.build(), helper.NewCommand("redis-cli", "-h", "1.2.3.4", "-p", "1234", "--tls", "--cacert", "redis_ca.pem", "--rdb", "/data/backups/redis/redis1/dump.rdb"))
 }
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -46,14 +47,34 @@ func (db *SQLite) buildArgs() []string {
 	return args
 }
 
-func (db *SQLite) perform() error {
-	logger := logger.Tag("SQLite")
+func (db *SQLite) restoreArgs() []string {
+	args := []string{
+		db.path,
+		fmt.Sprintf(".read %s", db._dumpFilePath),
+	}
+	return args
+}
+
+func (db *SQLite) perform(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "SQLite")
 
 	logger.Info("-> Dumping SQLite...")
-	if _, err := helper.Exec("sqlite3", db.buildArgs()...); err != nil {
+	if _, err := helper.ExecContext(ctx, "sqlite3", db.buildArgs()...); err != nil {
 		return err
 	}
 
 	logger.Info("dump path:", db._dumpFilePath)
 	return nil
 }
+
+func (db *SQLite) restore(ctx context.Context) error {
+	logger := logger.TagContext(ctx, "SQLite")
+
+	logger.Info("-> Restoring SQLite...")
+	if _, err := helper.ExecContext(ctx, "sqlite3", db.restoreArgs()...); err != nil {
+		return err
+	}
+
+	logger.Info("restored to:", db.path)
+	return nil
+}
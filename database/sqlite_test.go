@@ -33,4 +33,5 @@ func TestSQLite_init(t *testing.T) {
 
 	assert.Equal(t, db._dumpFilePath, "/data/backups/sqlite/sqlite1/my.sql")
 	assert.Equal(t, db.buildArgs(), []string{"/var/db/my.sqlite", ".output /data/backups/sqlite/sqlite1/my.sql", ".dump"})
+	assert.Equal(t, db.restoreArgs(), []string{"/var/db/my.sqlite", ".read /data/backups/sqlite/sqlite1/my.sql"})
 }
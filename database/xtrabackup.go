@@ -1,8 +1,8 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -30,7 +30,9 @@ type XtraBackup struct {
 	password string
 	parallel int
 	compress bool
-	args     string
+	args     []string
+	// optionFile is the temp option file with the password, it exists while performing
+	optionFile string
 }
 
 func (db *XtraBackup) init() (err error) {
@@ -50,8 +52,8 @@ func (db *XtraBackup) init() (err error) {
 	db.parallel = viper.GetInt("parallel")
 	db.compress = viper.GetBool("compress")
 
-	if len(viper.GetString("args")) > 0 {
-		db.args = viper.GetString("args")
+	if db.args, err = helper.ParseArgs(viper.Get("args")); err != nil {
+		return fmt.Errorf("invalid args: %v", err)
 	}
 
 	// socket
@@ -63,8 +65,12 @@ func (db *XtraBackup) init() (err error) {
 	return nil
 }
 
-func (db *XtraBackup) build() string {
-	dumpArgs := []string{"--backup"}
+func (db *XtraBackup) build() *helper.Command {
+	dumpArgs := []string{}
+	if len(db.optionFile) > 0 {
+		dumpArgs = append(dumpArgs, "--defaults-extra-file="+db.optionFile)
+	}
+	dumpArgs = append(dumpArgs, "--backup")
 	if len(db.host) > 0 {
 		dumpArgs = append(dumpArgs, "--host="+db.host)
 	}
@@ -77,9 +83,6 @@ func (db *XtraBackup) build() string {
 	if len(db.username) > 0 {
 		dumpArgs = append(dumpArgs, "--user="+db.username)
 	}
-	if len(db.password) > 0 {
-		dumpArgs = append(dumpArgs, "--password="+db.password)
-	}
 
 	if len(db.database) > 0 {
 		dumpArgs = append(dumpArgs, "--databases="+db.database)
@@ -93,20 +96,23 @@ func (db *XtraBackup) build() string {
 		dumpArgs = append(dumpArgs, "--compress")
 	}
 
-	if len(db.args) > 0 {
-		dumpArgs = append(dumpArgs, db.args)
-	}
+	dumpArgs = append(dumpArgs, db.args...)
 
 	dumpArgs = append(dumpArgs, "--target-dir="+db.dumpPath)
 
-	return "xtrabackup" + " " + strings.Join(dumpArgs, " ")
+	return helper.NewCommand("xtrabackup", dumpArgs...)
 }
 
-func (db *XtraBackup) perform() error {
-	logger := logger.Tag("XtraBackup")
+func (db *XtraBackup) perform(ctx context.Context) (err error) {
+	logger := logger.TagContext(ctx, "XtraBackup")
 
 	logger.Info("-> Dumping MySQL with xtrabackup...")
-	_, err := helper.Exec(db.build())
+	if db.optionFile, err = writeMySQLOptionFile(db.password, "xtrabackup"); err != nil {
+		return err
+	}
+	defer removeOptionFile(db.optionFile)
+
+	_, err = helper.ExecCommand(ctx, helper.ExecOptions{}, db.build())
 	if err != nil {
 		logger.Errorf("-> Dump error: %s", err)
 		return err
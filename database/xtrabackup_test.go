@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/spf13/viper"
 
 	"testing"
@@ -38,7 +39,7 @@ func TestXtraBackup_init(t *testing.T) {
 	err := db.init()
 	assert.NoError(t, err)
 	script := db.build()
-	assert.Equal(t, script, "xtrabackup --backup --host=1.2.3.4 --port=1234 --user=user1 --password=pass1 --databases=my_db --parallel=4 --compress --a1 --a2 --a3 --target-dir=/data/backups/xtrabackup/xtrabackup1")
+	assert.Equal(t, script, helper.NewCommand("xtrabackup", "--backup", "--host=1.2.3.4", "--port=1234", "--user=user1", "--databases=my_db", "--parallel=4", "--compress", "--a1", "--a2", "--a3", "--target-dir=/data/backups/xtrabackup/xtrabackup1"))
 }
 
 func TestXtraBackup_dumpArgsWithAdditionalOptions(t *testing.T) {
@@ -52,15 +53,16 @@ func TestXtraBackup_dumpArgsWithAdditionalOptions(t *testing.T) {
 		},
 	)
 	db := &XtraBackup{
-		Base:     base,
-		database: "dummy_test",
-		host:     "127.0.0.2",
-		port:     "6378",
-		password: "*&^92'",
-		parallel: 2,
-		compress: false,
-		args:     "--no-lock",
+		Base:       base,
+		database:   "dummy_test",
+		host:       "127.0.0.2",
+		port:       "6378",
+		password:   "*&^92'",
+		optionFile: "/tmp/gobackup-1.cnf",
+		parallel:   2,
+		compress:   false,
+		args:       []string{"--no-lock"},
 	}
 
-	assert.Equal(t, db.build(), "xtrabackup --backup --host=127.0.0.2 --port=6378 --password=*&^92' --databases=dummy_test --parallel=2 --no-lock --target-dir=/data/backups/xtrabackup/xtrabackup1")
+	assert.Equal(t, db.build(), helper.NewCommand("xtrabackup", "--defaults-extra-file=/tmp/gobackup-1.cnf", "--backup", "--host=127.0.0.2", "--port=6378", "--databases=dummy_test", "--parallel=2", "--no-lock", "--target-dir=/data/backups/xtrabackup/xtrabackup1"))
 }
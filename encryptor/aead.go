@@ -0,0 +1,355 @@
+package encryptor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The AEAD format is a versioned header followed by the chunks sealed with the STREAM construction,
+// every chunk is authenticated with the header, and the last chunk is flagged in the nonce,
+// so a wrong password, tampering, reordering or truncation are all detected.
+//
+//	header: magic(8) version(1) cipher(1) kdf(1) kdf_params(3*4) salt(16) nonce_prefix(7) chunk_size(4)
+//	chunk:  seal(plaintext[chunk_size]), nonce = nonce_prefix(7) || counter(4) || last(1)
+const (
+	aeadMagic      = "GOBACKUP"
+	aeadVersion    = 1
+	aeadHeaderSize = 8 + 1 + 1 + 1 + 3*4 + 16 + 7 + 4
+	aeadChunkSize  = 64 * 1024
+
+	aeadCipherAESGCM   = 1
+	aeadCipherChaCha20 = 2
+
+	aeadKDFArgon2id = 1
+	aeadKDFScrypt   = 2
+
+	// The kdf params are read from the header before it is authenticated, they are limited
+	// so a corrupt or tampered file can not OOM the process before the wrong password error.
+	aeadMaxKDFMemory  = 1 << 30 // 1GiB
+	aeadMaxKDFTime    = 64
+	aeadMaxKDFThreads = 255
+)
+
+var (
+	errAEADAuth      = errors.New("wrong password or the data is tampered")
+	errAEADTruncated = errors.New("the data is truncated")
+)
+
+// AEAD encryptor with AES-256-GCM or ChaCha20-Poly1305, the key is derived from the password
+// with Argon2id or scrypt.
+//
+// type: aes-gcm or chacha20
+// - password:
+// - kdf: argon2id (default) or scrypt
+type AEAD struct {
+	Base
+	cipher   byte
+	kdf      string
+	password string
+}
+
+func NewAEAD(base *Base) *AEAD {
+	base.viper.SetDefault("kdf", "argon2id")
+
+	enc := &AEAD{
+		Base:     *base,
+		cipher:   aeadCipherAESGCM,
+		kdf:      base.viper.GetString("kdf"),
+		password: base.viper.GetString("password"),
+	}
+	if base.model.EncryptWith.Type == "chacha20" {
+		enc.cipher = aeadCipherChaCha20
+	}
+
+	return enc
+}
+
+func (enc *AEAD) ext() string {
+	return ".aead"
+}
+
+func (enc *AEAD) perform(ctx context.Context) (encryptPath string, err error) {
+	encryptPath = enc.archivePath + enc.ext()
+	if err := encryptFile(ctx, enc, enc.archivePath, encryptPath); err != nil {
+		return "", fmt.Errorf("AEAD encrypt failed: %v", err)
+	}
+
+	return encryptPath, nil
+}
+
+func (enc *AEAD) decrypt(ctx context.Context) (decryptPath string, err error) {
+	if len(enc.password) == 0 {
+		return "", fmt.Errorf("password option is required")
+	}
+
+	decryptPath = strings.TrimSuffix(enc.archivePath, enc.ext())
+	err = decryptFile(ctx, func(r io.Reader) (io.Reader, error) {
+		return newAEADReader(r, enc.password)
+	}, enc.archivePath, decryptPath)
+	if err != nil {
+		return "", fmt.Errorf("AEAD decrypt failed: %v", err)
+	}
+
+	return decryptPath, nil
+}
+
+func (enc *AEAD) stream(ctx context.Context, w io.Writer) (io.WriteCloser, error) {
+	if len(enc.password) == 0 {
+		return nil, fmt.Errorf("password option is required")
+	}
+
+	header := make([]byte, aeadHeaderSize)
+	copy(header, aeadMagic)
+	header[8] = aeadVersion
+	header[9] = enc.cipher
+
+	params := header[11:23]
+	switch enc.kdf {
+	case "argon2id":
+		header[10] = aeadKDFArgon2id
+		// time, memory in KiB, threads
+		binary.BigEndian.PutUint32(params[0:], 3)
+		binary.BigEndian.PutUint32(params[4:], 64*1024)
+		binary.BigEndian.PutUint32(params[8:], 4)
+	case "scrypt":
+		header[10] = aeadKDFScrypt
+		// log2(N), r, p
+		binary.BigEndian.PutUint32(params[0:], 15)
+		binary.BigEndian.PutUint32(params[4:], 8)
+		binary.BigEndian.PutUint32(params[8:], 1)
+	default:
+		return nil, fmt.Errorf("Unsupported kdf: %s", enc.kdf)
+	}
+
+	// salt and nonce prefix
+	if _, err := rand.Read(header[23:46]); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(header[46:], aeadChunkSize)
+
+	aead, err := newAEADCipher(header, enc.password)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &aeadWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, aeadChunkSize),
+	}, nil
+}
+
+// newAEADCipher derive the key from the password with the header params, and return the cipher
+func newAEADCipher(header []byte, password string) (cipher.AEAD, error) {
+	if !bytes.Equal(header[:8], []byte(aeadMagic)) {
+		return nil, fmt.Errorf("not an AEAD encrypted file")
+	}
+	if header[8] != aeadVersion {
+		return nil, fmt.Errorf("unsupported AEAD version: %d", header[8])
+	}
+
+	p1 := binary.BigEndian.Uint32(header[11:])
+	p2 := binary.BigEndian.Uint32(header[15:])
+	p3 := binary.BigEndian.Uint32(header[19:])
+	salt := header[23:39]
+
+	var key []byte
+	switch header[10] {
+	case aeadKDFArgon2id:
+		// time, memory in KiB, threads
+		if p1 == 0 || p1 > aeadMaxKDFTime || p2 > aeadMaxKDFMemory/1024 || p3 == 0 || p3 > aeadMaxKDFThreads {
+			return nil, fmt.Errorf("invalid argon2id params")
+		}
+		key = argon2.IDKey([]byte(password), salt, p1, p2, uint8(p3), 32)
+	case aeadKDFScrypt:
+		// log2(N), r, p, scrypt uses 128 * N * r bytes
+		if p1 == 0 || p1 > 30 || p2 == 0 || p3 == 0 || uint64(p2)*uint64(p3) >= 1<<20 || 128*(uint64(1)<<p1)*uint64(p2) > aeadMaxKDFMemory {
+			return nil, fmt.Errorf("invalid scrypt params")
+		}
+		var err error
+		key, err = scrypt.Key([]byte(password), salt, 1<<p1, int(p2), int(p3), 32)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kdf: %d", header[10])
+	}
+
+	switch header[9] {
+	case aeadCipherAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case aeadCipherChaCha20:
+		return chacha20poly1305.New(key)
+	}
+
+	return nil, fmt.Errorf("unsupported cipher: %d", header[9])
+}
+
+// aeadNonce return the nonce of the chunk: nonce_prefix(7) || counter(4) || last(1)
+func aeadNonce(header []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, header[39:46])
+	binary.BigEndian.PutUint32(nonce[7:], counter)
+	if last {
+		nonce[11] = 1
+	}
+
+	return nonce
+}
+
+type aeadWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+}
+
+func (w *aeadWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		// seal the full chunk only when more data comes, so that the last chunk can be flagged in Close
+		if len(w.buf) == cap(w.buf) {
+			if err := w.seal(false); err != nil {
+				return n, err
+			}
+		}
+
+		size := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+size]
+		p = p[size:]
+		n += size
+	}
+
+	return n, nil
+}
+
+// Close seal the last chunk, it does not close the underlying writer
+func (w *aeadWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	return w.seal(true)
+}
+
+func (w *aeadWriter) seal(last bool) error {
+	if w.counter == ^uint32(0) {
+		return fmt.Errorf("too many chunks")
+	}
+
+	sealed := w.aead.Seal(nil, aeadNonce(w.header, w.counter, last), w.buf, w.header)
+	if _, err := w.w.Write(sealed); err != nil {
+		return err
+	}
+
+	w.counter++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+type aeadReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	chunk   []byte
+	buf     []byte
+	counter uint32
+	done    bool
+}
+
+func newAEADReader(r io.Reader, password string) (*aeadReader, error) {
+	header := make([]byte, aeadHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("not an AEAD encrypted file")
+	}
+
+	aead, err := newAEADCipher(header, password)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSize := binary.BigEndian.Uint32(header[46:])
+	if chunkSize == 0 || chunkSize > 16*1024*1024 {
+		return nil, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	return &aeadReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: header,
+		chunk:  make([]byte, int(chunkSize)+aead.Overhead()),
+	}, nil
+}
+
+func (r *aeadReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *aeadReader) open() error {
+	n, err := io.ReadFull(r.r, r.chunk)
+	if err == io.EOF || (err == io.ErrUnexpectedEOF && n < r.aead.Overhead()) {
+		return errAEADTruncated
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	// A short chunk or a full chunk at the end of the data is the last chunk
+	last := n < len(r.chunk)
+	if !last {
+		if _, err := r.r.Peek(1); err == io.EOF {
+			last = true
+		}
+	}
+
+	plaintext, err := r.aead.Open(nil, aeadNonce(r.header, r.counter, last), r.chunk[:n], r.header)
+	if err != nil {
+		if last {
+			// A valid chunk that is not flagged as last means the data is cut at the chunk boundary
+			if _, err := r.aead.Open(nil, aeadNonce(r.header, r.counter, false), r.chunk[:n], r.header); err == nil {
+				return errAEADTruncated
+			}
+		}
+		return errAEADAuth
+	}
+
+	r.counter++
+	r.buf = plaintext
+	r.done = last
+	return nil
+}
@@ -0,0 +1,203 @@
+package encryptor
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func aeadEncrypt(t *testing.T, enc *AEAD, plaintext []byte) []byte {
+	var out bytes.Buffer
+	w, err := enc.stream(context.Background(), &out)
+	assert.NoError(t, err)
+
+	// write in small pieces to cross the chunk boundaries
+	for i := 0; i < len(plaintext); i += 1000 {
+		end := i + 1000
+		if end > len(plaintext) {
+			end = len(plaintext)
+		}
+		_, err := w.Write(plaintext[i:end])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	return out.Bytes()
+}
+
+func aeadDecrypt(ciphertext []byte, password string) ([]byte, error) {
+	r, err := newAEADReader(bytes.NewReader(ciphertext), password)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func TestAEAD(t *testing.T) {
+	for _, encryptType := range []string{"aes-gcm", "chacha20"} {
+		for _, kdf := range []string{"argon2id", "scrypt"} {
+			model, archivePath := newEncryptTestModel(t, encryptType)
+			model.EncryptWith.Viper.Set("password", "gobackup-123")
+			model.EncryptWith.Viper.Set("kdf", kdf)
+			assertRoundTrip(t, model, archivePath, ".aead")
+		}
+	}
+}
+
+func TestAEAD_chunks(t *testing.T) {
+	model, _ := newEncryptTestModel(t, "chacha20")
+	model.EncryptWith.Viper.Set("password", "gobackup-123")
+	model.EncryptWith.Viper.Set("kdf", "scrypt")
+	enc := NewAEAD(newBase("", model))
+
+	sizes := []int{0, 1, aeadChunkSize, aeadChunkSize + 1, 3*aeadChunkSize - 7}
+	for _, size := range sizes {
+		plaintext := bytes.Repeat([]byte("gobackup"), size/8+1)[:size]
+		ciphertext := aeadEncrypt(t, enc, plaintext)
+
+		chunks := size/aeadChunkSize + 1
+		if size > 0 && size%aeadChunkSize == 0 {
+			chunks = size / aeadChunkSize
+		}
+		assert.Equal(t, aeadHeaderSize+size+chunks*16, len(ciphertext), size)
+
+		decrypted, err := aeadDecrypt(ciphertext, "gobackup-123")
+		assert.NoError(t, err, size)
+		assert.Equal(t, plaintext, decrypted, size)
+	}
+}
+
+func TestAEAD_tampered(t *testing.T) {
+	model, _ := newEncryptTestModel(t, "aes-gcm")
+	model.EncryptWith.Viper.Set("password", "gobackup-123")
+	model.EncryptWith.Viper.Set("kdf", "scrypt")
+	enc := NewAEAD(newBase("", model))
+
+	plaintext := bytes.Repeat([]byte("gobackup"), aeadChunkSize/4+100)
+	ciphertext := aeadEncrypt(t, enc, plaintext)
+
+	// wrong password
+	_, err := aeadDecrypt(ciphertext, "gobackup-456")
+	assert.Equal(t, errAEADAuth, err)
+
+	// flip a byte in the second chunk
+	tampered := append([]byte{}, ciphertext...)
+	tampered[aeadHeaderSize+aeadChunkSize+100] ^= 1
+	_, err = aeadDecrypt(tampered, "gobackup-123")
+	assert.Equal(t, errAEADAuth, err)
+
+	// flip a byte in the header
+	tampered = append([]byte{}, ciphertext...)
+	tampered[40] ^= 1
+	_, err = aeadDecrypt(tampered, "gobackup-123")
+	assert.Equal(t, errAEADAuth, err)
+
+	// drop the last chunk
+	truncated := ciphertext[:aeadHeaderSize+aeadChunkSize+16]
+	_, err = aeadDecrypt(truncated, "gobackup-123")
+	assert.Equal(t, errAEADTruncated, err)
+
+	// cut in the middle of a chunk
+	truncated = ciphertext[:len(ciphertext)-10]
+	_, err = aeadDecrypt(truncated, "gobackup-123")
+	assert.Error(t, err)
+
+	_, err = aeadDecrypt([]byte("Salted__"), "gobackup-123")
+	assert.EqualError(t, err, "not an AEAD encrypted file")
+}
+
+func TestAEAD_kdfParams(t *testing.T) {
+	header := func(kdf byte, p1, p2, p3 uint32) []byte {
+		header := make([]byte, aeadHeaderSize)
+		copy(header, aeadMagic)
+		header[8] = aeadVersion
+		header[9] = aeadCipherAESGCM
+		header[10] = kdf
+		binary.BigEndian.PutUint32(header[11:], p1)
+		binary.BigEndian.PutUint32(header[15:], p2)
+		binary.BigEndian.PutUint32(header[19:], p3)
+		return header
+	}
+
+	// the params which would OOM are rejected before the key is derived
+	for _, h := range [][]byte{
+		header(aeadKDFArgon2id, 3, 0xFFFFFFFF, 4),
+		header(aeadKDFArgon2id, 3, 2*1024*1024, 4),
+		header(aeadKDFArgon2id, 0xFFFFFFFF, 64*1024, 4),
+		header(aeadKDFArgon2id, 3, 64*1024, 0),
+	} {
+		_, err := newAEADCipher(h, "gobackup-123")
+		assert.EqualError(t, err, "invalid argon2id params")
+	}
+	for _, h := range [][]byte{
+		header(aeadKDFScrypt, 30, 8, 1),
+		header(aeadKDFScrypt, 15, 1<<20, 1),
+		header(aeadKDFScrypt, 10, 1024, 1024),
+		header(aeadKDFScrypt, 15, 0, 1),
+	} {
+		_, err := newAEADCipher(h, "gobackup-123")
+		assert.EqualError(t, err, "invalid scrypt params")
+	}
+
+	// the defaults
+	_, err := newAEADCipher(header(aeadKDFArgon2id, 3, 64*1024, 4), "gobackup-123")
+	assert.NoError(t, err)
+	_, err = newAEADCipher(header(aeadKDFScrypt, 15, 8, 1), "gobackup-123")
+	assert.NoError(t, err)
+}
+
+func TestAEAD_invalid(t *testing.T) {
+	model, archivePath := newEncryptTestModel(t, "aes-gcm")
+	_, err := Run(context.Background(), archivePath, model)
+	assert.EqualError(t, err, "AEAD encrypt failed: password option is required")
+
+	model.EncryptWith.Viper.Set("password", "gobackup-123")
+	model.EncryptWith.Viper.Set("kdf", "pbkdf2")
+	_, err = Run(context.Background(), archivePath, model)
+	assert.EqualError(t, err, "AEAD encrypt failed: Unsupported kdf: pbkdf2")
+
+	// the partial package is removed
+	_, err = os.Stat(archivePath + ".aead")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAEAD_decryptFailed(t *testing.T) {
+	model, archivePath := newEncryptTestModel(t, "aes-gcm")
+	model.EncryptWith.Viper.Set("password", "gobackup-123")
+	encryptPath, err := Run(context.Background(), archivePath, model)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Remove(archivePath))
+
+	// the partial plaintext is removed
+	model.EncryptWith.Viper.Set("password", "gobackup-456")
+	_, err = Decrypt(context.Background(), encryptPath, model)
+	assert.EqualError(t, err, "AEAD decrypt failed: "+errAEADAuth.Error())
+	_, err = os.Stat(archivePath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAEAD_stream(t *testing.T) {
+	model, _ := newEncryptTestModel(t, "chacha20")
+	model.EncryptWith.Viper.Set("password", "gobackup-123")
+
+	st, err := NewStream(context.Background(), model)
+	assert.NoError(t, err)
+	assert.Equal(t, ".tar.gz.aead", model.Viper.GetString("Ext"))
+
+	var out bytes.Buffer
+	w, err := st.Writer(context.Background(), &out)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("hello world"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	decrypted, err := aeadDecrypt(out.Bytes(), "gobackup-123")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(decrypted))
+}
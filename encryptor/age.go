@@ -0,0 +1,209 @@
+package encryptor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+
+	"github.com/gobackup/gobackup/helper"
+)
+
+// Age encryptor with X25519 or SSH public keys, or a passphrase
+//
+// - recipients: [age1..., ssh-ed25519 AAAA...]
+// - recipients_file: file with one recipient per line, `#` for comments
+// - passphrase: encrypt with passphrase instead of recipients
+// - armor: false
+// - identity_file: age or SSH private keys, only required to decrypt
+type Age struct {
+	Base
+	recipients     []string
+	recipientsFile string
+	passphrase     string
+	armor          bool
+	identityFile   string
+}
+
+func NewAge(base *Base) *Age {
+	base.viper.SetDefault("armor", false)
+
+	return &Age{
+		Base:           *base,
+		recipients:     base.viper.GetStringSlice("recipients"),
+		recipientsFile: base.viper.GetString("recipients_file"),
+		passphrase:     base.viper.GetString("passphrase"),
+		armor:          base.viper.GetBool("armor"),
+		identityFile:   base.viper.GetString("identity_file"),
+	}
+}
+
+func (enc *Age) ext() string {
+	return ".age"
+}
+
+func (enc *Age) perform(ctx context.Context) (encryptPath string, err error) {
+	encryptPath = enc.archivePath + enc.ext()
+	if err := encryptFile(ctx, enc, enc.archivePath, encryptPath); err != nil {
+		return "", fmt.Errorf("age encrypt failed: %v", err)
+	}
+
+	return encryptPath, nil
+}
+
+func (enc *Age) stream(ctx context.Context, w io.Writer) (io.WriteCloser, error) {
+	recipients, err := enc.parseRecipients()
+	if err != nil {
+		return nil, err
+	}
+
+	if !enc.armor {
+		return age.Encrypt(w, recipients...)
+	}
+
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	return &multiCloser{Writer: ew, closers: []io.Closer{ew, aw}}, nil
+}
+
+func (enc *Age) decrypt(ctx context.Context) (decryptPath string, err error) {
+	identities, err := enc.parseIdentities()
+	if err != nil {
+		return "", err
+	}
+
+	decryptPath = strings.TrimSuffix(enc.archivePath, enc.ext())
+	err = decryptFile(ctx, func(r io.Reader) (io.Reader, error) {
+		if enc.armor {
+			r = armor.NewReader(r)
+		}
+		return age.Decrypt(r, identities...)
+	}, enc.archivePath, decryptPath)
+	if err != nil {
+		return "", fmt.Errorf("age decrypt failed: %v", err)
+	}
+
+	return decryptPath, nil
+}
+
+func (enc *Age) parseRecipients() ([]age.Recipient, error) {
+	if len(enc.passphrase) > 0 {
+		if len(enc.recipients) > 0 || len(enc.recipientsFile) > 0 {
+			return nil, fmt.Errorf("passphrase cannot be used together with recipients")
+		}
+
+		r, err := age.NewScryptRecipient(enc.passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{r}, nil
+	}
+
+	lines := enc.recipients
+	if len(enc.recipientsFile) > 0 {
+		fileLines, err := readLines(helper.AbsolutePath(enc.recipientsFile))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fileLines...)
+	}
+
+	var recipients []age.Recipient
+	for _, line := range lines {
+		r, err := parseAgeRecipient(line)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("recipients or passphrase option is required")
+	}
+
+	return recipients, nil
+}
+
+func parseAgeRecipient(s string) (age.Recipient, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "ssh-") {
+		return agessh.ParseRecipient(s)
+	}
+
+	return age.ParseX25519Recipient(s)
+}
+
+func (enc *Age) parseIdentities() ([]age.Identity, error) {
+	if len(enc.identityFile) == 0 {
+		if len(enc.passphrase) > 0 {
+			identity, err := age.NewScryptIdentity(enc.passphrase)
+			if err != nil {
+				return nil, err
+			}
+			return []age.Identity{identity}, nil
+		}
+
+		return nil, fmt.Errorf("identity_file option is required to decrypt")
+	}
+
+	data, err := os.ReadFile(helper.AbsolutePath(enc.identityFile))
+	if err != nil {
+		return nil, err
+	}
+
+	// SSH private key
+	if strings.Contains(string(data), "PRIVATE KEY-----") {
+		identity, err := agessh.ParseIdentity(data)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	return age.ParseIdentities(strings.NewReader(string(data)))
+}
+
+// readLines read the non-empty lines of the file, skip the comments start with `#`
+func readLines(filePath string) (lines []string, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// multiCloser close the closers in order
+type multiCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package encryptor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newEncryptTestModel(t *testing.T, encryptType string) (config.ModelConfig, string) {
+	archivePath := filepath.Join(t.TempDir(), "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello world"), 0640))
+
+	model := config.ModelConfig{
+		Viper:       viper.New(),
+		EncryptWith: config.SubConfig{Type: encryptType, Viper: viper.New()},
+	}
+	model.Viper.Set("Ext", ".tar.gz")
+
+	return model, archivePath
+}
+
+func assertRoundTrip(t *testing.T, model config.ModelConfig, archivePath string, ext string) {
+	encryptPath, err := Run(context.Background(), archivePath, model)
+	assert.NoError(t, err)
+	assert.Equal(t, archivePath+ext, encryptPath)
+	assert.Equal(t, ".tar.gz"+ext, model.Viper.GetString("Ext"))
+	assert.Equal(t, ext, Ext(model))
+
+	data, err := os.ReadFile(encryptPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hello world")
+
+	assert.NoError(t, os.Remove(archivePath))
+	decryptPath, err := Decrypt(context.Background(), encryptPath, model)
+	assert.NoError(t, err)
+	assert.Equal(t, archivePath, decryptPath)
+
+	data, err = os.ReadFile(decryptPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+}
+
+func TestAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	model, archivePath := newEncryptTestModel(t, "age")
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	assert.NoError(t, os.WriteFile(identityFile, []byte("# created: 2022-12-04\n"+identity.String()+"\n"), 0600))
+
+	model.EncryptWith.Viper.Set("recipients", []string{identity.Recipient().String()})
+	model.EncryptWith.Viper.Set("identity_file", identityFile)
+	assertRoundTrip(t, model, archivePath, ".age")
+}
+
+func TestAge_armor(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	model, archivePath := newEncryptTestModel(t, "age")
+	recipientsFile := filepath.Join(t.TempDir(), "recipients.txt")
+	assert.NoError(t, os.WriteFile(recipientsFile, []byte("# backup key\n"+identity.Recipient().String()+"\n"), 0600))
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	assert.NoError(t, os.WriteFile(identityFile, []byte(identity.String()), 0600))
+
+	model.EncryptWith.Viper.Set("recipients_file", recipientsFile)
+	model.EncryptWith.Viper.Set("identity_file", identityFile)
+	model.EncryptWith.Viper.Set("armor", true)
+	assertRoundTrip(t, model, archivePath, ".age")
+}
+
+func TestAge_passphrase(t *testing.T) {
+	model, archivePath := newEncryptTestModel(t, "age")
+	model.EncryptWith.Viper.Set("passphrase", "gobackup-123")
+	assertRoundTrip(t, model, archivePath, ".age")
+}
+
+func TestAge_invalid(t *testing.T) {
+	model, archivePath := newEncryptTestModel(t, "age")
+	_, err := Run(context.Background(), archivePath, model)
+	assert.EqualError(t, err, "age encrypt failed: recipients or passphrase option is required")
+
+	model.EncryptWith.Viper.Set("recipients", []string{"age1foo"})
+	_, err = Run(context.Background(), archivePath, model)
+	assert.Error(t, err)
+
+	model.EncryptWith.Viper.Set("passphrase", "gobackup-123")
+	_, err = Run(context.Background(), archivePath, model)
+	assert.EqualError(t, err, "age encrypt failed: passphrase cannot be used together with recipients")
+}
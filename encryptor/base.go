@@ -1,7 +1,14 @@
 package encryptor
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
 	"github.com/spf13/viper"
 )
@@ -15,7 +22,12 @@ type Base struct {
 
 // Encryptor interface
 type Encryptor interface {
-	perform() (encryptPath string, err error)
+	// perform the encryption, it is stopped when ctx is done
+	perform(ctx context.Context) (encryptPath string, err error)
+	// decrypt the archive, it is stopped when ctx is done
+	decrypt(ctx context.Context) (decryptPath string, err error)
+	// ext is the extension appended to the archive
+	ext() string
 }
 
 func newBase(archivePath string, model config.ModelConfig) (base *Base) {
@@ -24,12 +36,45 @@ func newBase(archivePath string, model config.ModelConfig) (base *Base) {
 		model:       model,
 		viper:       model.EncryptWith.Viper,
 	}
+	if base.viper == nil {
+		base.viper = viper.New()
+	}
 	return
 }
 
+// newEncryptor return the encryptor of `encrypt_with` type, nil when the type is not supported
+func newEncryptor(base *Base) Encryptor {
+	switch base.model.EncryptWith.Type {
+	case "openssl":
+		return NewOpenSSL(base)
+	case "age":
+		return NewAge(base)
+	case "gpg":
+		return NewGPG(base)
+	case "aes-gcm", "chacha20":
+		return NewAEAD(base)
+	}
+
+	return nil
+}
+
+// Ext return the extension appended by the encryptor of the model, empty when no encryption configured
+func Ext(model config.ModelConfig) string {
+	if model.EncryptWith.Type == "" {
+		return ""
+	}
+
+	enc := newEncryptor(newBase("", model))
+	if enc == nil {
+		return ""
+	}
+
+	return enc.ext()
+}
+
 // Run encryptor on archive path
-func Run(archivePath string, model config.ModelConfig) (string, error) {
-	logger := logger.Tag("Encryptor")
+func Run(ctx context.Context, archivePath string, model config.ModelConfig) (string, error) {
+	logger := logger.TagContext(ctx, "Encryptor")
 
 	// If no encryption configured, return path as-is
 	if model.EncryptWith.Type == "" {
@@ -38,23 +83,114 @@ func Run(archivePath string, model config.ModelConfig) (string, error) {
 
 	logger.Info("encrypt | " + model.EncryptWith.Type)
 
-	base := newBase(archivePath, model)
-	var enc Encryptor
-	switch model.EncryptWith.Type {
-	case "openssl":
-		enc = NewOpenSSL(base)
-	default:
+	enc := newEncryptor(newBase(archivePath, model))
+	if enc == nil {
 		return archivePath, nil
 	}
 
-	encryptPath, err := enc.perform()
+	encryptPath, err := enc.perform(ctx)
 	if err != nil {
 		return "", err
 	}
 	logger.Info("encrypted:", encryptPath)
 
 	// save Extension
-	model.Viper.Set("Ext", model.Viper.GetString("Ext")+".enc")
+	model.Viper.Set("Ext", model.Viper.GetString("Ext")+enc.ext())
 
 	return encryptPath, nil
 }
+
+// Decrypt the encrypted archive with the `encrypt_with` config, return the decrypted path
+func Decrypt(ctx context.Context, encryptPath string, model config.ModelConfig) (string, error) {
+	logger := logger.TagContext(ctx, "Encryptor")
+
+	// If no encryption configured, return path as-is
+	if model.EncryptWith.Type == "" {
+		return encryptPath, nil
+	}
+
+	enc := newEncryptor(newBase(encryptPath, model))
+	if enc == nil {
+		return "", fmt.Errorf("Unsupported encrypt type: %s", model.EncryptWith.Type)
+	}
+
+	if !strings.HasSuffix(encryptPath, enc.ext()) {
+		return "", fmt.Errorf("%s is not an encrypted file", encryptPath)
+	}
+
+	logger.Info("decrypt | " + model.EncryptWith.Type)
+
+	decryptPath, err := enc.decrypt(ctx)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("decrypted:", decryptPath)
+
+	return decryptPath, nil
+}
+
+// encryptFile encrypt archivePath into encryptPath with the stream encryptor, the partial encryptPath is removed when it is failed
+func encryptFile(ctx context.Context, enc streamEncryptor, archivePath, encryptPath string) (err error) {
+	in, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(encryptPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(encryptPath)
+		}
+	}()
+
+	w, err := enc.stream(ctx, out)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, helper.ContextReader(ctx, in)); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// decryptFile decrypt encryptPath into decryptPath with the reader returned by open, the partial decryptPath is removed when it is failed
+func decryptFile(ctx context.Context, open func(r io.Reader) (io.Reader, error), encryptPath, decryptPath string) (err error) {
+	in, err := os.Open(encryptPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := open(helper.ContextReader(ctx, in))
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(decryptPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(decryptPath)
+		}
+	}()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
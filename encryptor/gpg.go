@@ -0,0 +1,155 @@
+package encryptor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+
+	"github.com/gobackup/gobackup/helper"
+)
+
+// GPG encryptor with OpenPGP public keys, the package can be decrypted by any of the keys
+//
+// - public_keys: armored public keys, or the paths of them
+// - armor: false, output ASCII armored `.asc` instead of binary `.gpg`
+// - private_key: armored private key or the path of it, only required to decrypt
+// - passphrase: passphrase of the private key
+type GPG struct {
+	Base
+	publicKeys []string
+	armor      bool
+	privateKey string
+	passphrase string
+}
+
+func NewGPG(base *Base) *GPG {
+	base.viper.SetDefault("armor", false)
+
+	return &GPG{
+		Base:       *base,
+		publicKeys: base.viper.GetStringSlice("public_keys"),
+		armor:      base.viper.GetBool("armor"),
+		privateKey: base.viper.GetString("private_key"),
+		passphrase: base.viper.GetString("passphrase"),
+	}
+}
+
+func (enc *GPG) ext() string {
+	if enc.armor {
+		return ".asc"
+	}
+
+	return ".gpg"
+}
+
+func (enc *GPG) perform(ctx context.Context) (encryptPath string, err error) {
+	encryptPath = enc.archivePath + enc.ext()
+	if err := encryptFile(ctx, enc, enc.archivePath, encryptPath); err != nil {
+		return "", fmt.Errorf("GPG encrypt failed: %v", err)
+	}
+
+	return encryptPath, nil
+}
+
+func (enc *GPG) stream(ctx context.Context, w io.Writer) (io.WriteCloser, error) {
+	var recipients openpgp.EntityList
+	for _, key := range enc.publicKeys {
+		entities, err := readArmoredKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %v", err)
+		}
+		recipients = append(recipients, entities...)
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("public_keys option is required")
+	}
+
+	hints := &openpgp.FileHints{IsBinary: true}
+	if !enc.armor {
+		return openpgp.Encrypt(w, recipients, nil, hints, nil)
+	}
+
+	aw, err := armor.Encode(w, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	ew, err := openpgp.Encrypt(aw, recipients, nil, hints, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &multiCloser{Writer: ew, closers: []io.Closer{ew, aw}}, nil
+}
+
+func (enc *GPG) decrypt(ctx context.Context) (decryptPath string, err error) {
+	if len(enc.privateKey) == 0 {
+		return "", fmt.Errorf("private_key option is required to decrypt")
+	}
+
+	keyring, err := readArmoredKey(enc.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %v", err)
+	}
+	if err := enc.unlock(keyring); err != nil {
+		return "", err
+	}
+
+	decryptPath = strings.TrimSuffix(enc.archivePath, enc.ext())
+	err = decryptFile(ctx, func(r io.Reader) (io.Reader, error) {
+		if enc.armor {
+			block, err := armor.Decode(r)
+			if err != nil {
+				return nil, err
+			}
+			r = block.Body
+		}
+
+		md, err := openpgp.ReadMessage(r, keyring, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return md.UnverifiedBody, nil
+	}, enc.archivePath, decryptPath)
+	if err != nil {
+		return "", fmt.Errorf("GPG decrypt failed: %v", err)
+	}
+
+	return decryptPath, nil
+}
+
+// unlock the encrypted private keys with the passphrase
+func (enc *GPG) unlock(keyring openpgp.EntityList) error {
+	for _, entity := range keyring {
+		if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+			if err := entity.PrivateKey.Decrypt([]byte(enc.passphrase)); err != nil {
+				return fmt.Errorf("unlock private key failed: %v", err)
+			}
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+				if err := subkey.PrivateKey.Decrypt([]byte(enc.passphrase)); err != nil {
+					return fmt.Errorf("unlock private key failed: %v", err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// readArmoredKey read the armored key, key can be the content or the path of the key file
+func readArmoredKey(key string) (openpgp.EntityList, error) {
+	if !strings.Contains(key, "-----BEGIN PGP") {
+		data, err := os.ReadFile(helper.AbsolutePath(key))
+		if err != nil {
+			return nil, err
+		}
+		key = string(data)
+	}
+
+	return openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+}
@@ -0,0 +1,84 @@
+package encryptor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/longbridgeapp/assert"
+)
+
+func generateGPGKeys(t *testing.T, passphrase string) (publicKey string, privateKey string) {
+	entity, err := openpgp.NewEntity("GoBackup", "", "test@gobackup.io", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	assert.NoError(t, err)
+
+	var pub bytes.Buffer
+	w, err := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(w))
+	assert.NoError(t, w.Close())
+
+	if len(passphrase) > 0 {
+		assert.NoError(t, entity.EncryptPrivateKeys([]byte(passphrase), nil))
+	}
+
+	var priv bytes.Buffer
+	w, err = armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	assert.NoError(t, w.Close())
+
+	return pub.String(), priv.String()
+}
+
+func TestGPG(t *testing.T) {
+	publicKey, privateKey := generateGPGKeys(t, "")
+	otherPublicKey, _ := generateGPGKeys(t, "")
+
+	model, archivePath := newEncryptTestModel(t, "gpg")
+	model.EncryptWith.Viper.Set("public_keys", []string{publicKey, otherPublicKey})
+	model.EncryptWith.Viper.Set("private_key", privateKey)
+	assertRoundTrip(t, model, archivePath, ".gpg")
+}
+
+func TestGPG_armorWithKeyFiles(t *testing.T) {
+	publicKey, privateKey := generateGPGKeys(t, "gobackup-123")
+
+	publicKeyFile := filepath.Join(t.TempDir(), "public.asc")
+	assert.NoError(t, os.WriteFile(publicKeyFile, []byte(publicKey), 0600))
+	privateKeyFile := filepath.Join(t.TempDir(), "private.asc")
+	assert.NoError(t, os.WriteFile(privateKeyFile, []byte(privateKey), 0600))
+
+	model, archivePath := newEncryptTestModel(t, "gpg")
+	model.EncryptWith.Viper.Set("public_keys", []string{publicKeyFile})
+	model.EncryptWith.Viper.Set("private_key", privateKeyFile)
+	model.EncryptWith.Viper.Set("passphrase", "gobackup-123")
+	model.EncryptWith.Viper.Set("armor", true)
+	assertRoundTrip(t, model, archivePath, ".asc")
+
+	data, err := os.ReadFile(archivePath + ".asc")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "-----BEGIN PGP MESSAGE-----")
+}
+
+func TestGPG_invalid(t *testing.T) {
+	model, archivePath := newEncryptTestModel(t, "gpg")
+	_, err := Run(context.Background(), archivePath, model)
+	assert.EqualError(t, err, "GPG encrypt failed: public_keys option is required")
+
+	publicKey, _ := generateGPGKeys(t, "")
+	model.EncryptWith.Viper.Set("public_keys", []string{publicKey})
+	encryptPath, err := Run(context.Background(), archivePath, model)
+	assert.NoError(t, err)
+
+	_, err = Decrypt(context.Background(), encryptPath, model)
+	assert.EqualError(t, err, "private_key option is required to decrypt")
+
+	_, err = Decrypt(context.Background(), archivePath, model)
+	assert.Contains(t, err.Error(), "is not an encrypted file")
+}
@@ -1,6 +1,7 @@
 package encryptor
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -19,7 +20,7 @@ type OpenSSL struct {
 	salt        bool
 	base64      bool
 	password    string
-	args        string
+	args        []string
 	chiper      string
 	encryptPath string
 }
@@ -27,21 +28,35 @@ type OpenSSL struct {
 func NewOpenSSL(base *Base) *OpenSSL {
 	base.viper.SetDefault("salt", true)
 	base.viper.SetDefault("base64", false)
-	base.viper.SetDefault("args", "")
 	base.viper.SetDefault("chiper", "aes-256-cbc")
 
+	// args are validated when the config is loaded
+	args, _ := helper.ParseArgs(base.viper.Get("args"))
+
 	return &OpenSSL{
 		Base:        *base,
 		salt:        base.viper.GetBool("salt"),
 		base64:      base.viper.GetBool("base64"),
 		password:    base.viper.GetString("password"),
-		args:        base.viper.GetString("args"),
+		args:        args,
 		chiper:      base.viper.GetString("chiper"),
 		encryptPath: base.archivePath + ".enc",
 	}
 }
 
-func (enc *OpenSSL) perform() (encryptPath string, err error) {
+// opensslPasswordEnv is the environment variable of the password, it is read by `-pass env:`
+const opensslPasswordEnv = "GOBACKUP_OPENSSL_PASSWORD"
+
+// env of openssl with the password
+func (enc *OpenSSL) env() []string {
+	return []string{opensslPasswordEnv + "=" + enc.password}
+}
+
+func (enc *OpenSSL) ext() string {
+	return ".enc"
+}
+
+func (enc *OpenSSL) perform(ctx context.Context) (encryptPath string, err error) {
 	if len(enc.password) == 0 {
 		err = fmt.Errorf("password option is required")
 		return
@@ -49,14 +64,43 @@ func (enc *OpenSSL) perform() (encryptPath string, err error) {
 
 	opts := enc.options()
 	opts = append(opts, "-in", enc.archivePath, "-out", enc.encryptPath)
-	_, err = helper.Exec("openssl", opts...)
+	_, err = helper.ExecWithOptions(ctx, helper.ExecOptions{Env: enc.env()}, "openssl", opts...)
 	if err != nil {
-		err = fmt.Errorf("OpenSSL encrypt failed: %s `openssl %s`", strings.TrimSpace(err.Error()), strings.Join(opts, " "))
+		err = fmt.Errorf("OpenSSL encrypt failed: %s `%s`", strings.TrimSpace(err.Error()), helper.NewCommand("openssl", opts...))
 		return "", err
 	}
 	return enc.encryptPath, nil
 }
 
+func (enc *OpenSSL) decrypt(ctx context.Context) (decryptPath string, err error) {
+	if len(enc.password) == 0 {
+		err = fmt.Errorf("password option is required")
+		return
+	}
+
+	decryptPath = strings.TrimSuffix(enc.archivePath, ".enc")
+
+	opts := enc.decryptOptions()
+	opts = append(opts, "-in", enc.archivePath, "-out", decryptPath)
+	_, err = helper.ExecWithOptions(ctx, helper.ExecOptions{Env: enc.env()}, "openssl", opts...)
+	if err != nil {
+		err = fmt.Errorf("OpenSSL decrypt failed: %s", strings.TrimSpace(err.Error()))
+		return "", err
+	}
+	return decryptPath, nil
+}
+
+func (enc *OpenSSL) decryptOptions() (opts []string) {
+	opts = append(opts, enc.chiper, "-d")
+	if enc.base64 {
+		opts = append(opts, "-base64")
+	}
+	opts = append(opts, enc.args...)
+
+	opts = append(opts, "-pass", "env:"+opensslPasswordEnv)
+	return opts
+}
+
 func (enc *OpenSSL) options() (opts []string) {
 	opts = append(opts, enc.chiper)
 	if enc.base64 {
@@ -65,10 +109,8 @@ func (enc *OpenSSL) options() (opts []string) {
 	if enc.salt {
 		opts = append(opts, "-salt")
 	}
-	if len(enc.args) > 0 {
-		opts = append(opts, enc.args)
-	}
+	opts = append(opts, enc.args...)
 
-	opts = append(opts, `-k`, enc.password)
+	opts = append(opts, "-pass", "env:"+opensslPasswordEnv)
 	return opts
 }
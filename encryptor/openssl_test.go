@@ -1,6 +1,10 @@
 package encryptor
 
 import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -19,9 +23,9 @@ func TestOpenSSL_options(t *testing.T) {
 	assert.Equal(t, true, enc.salt)
 	assert.Equal(t, "", enc.password)
 	assert.Equal(t, "/foo/bar.enc", enc.encryptPath)
-	assert.Equal(t, "", enc.args)
+	assert.Nil(t, enc.args)
 	assert.Equal(t, "aes-256-cbc", enc.chiper)
-	assert.Equal(t, "aes-256-cbc -salt -k ", strings.Join(enc.options(), " "))
+	assert.Equal(t, "aes-256-cbc -salt -pass env:GOBACKUP_OPENSSL_PASSWORD", strings.Join(enc.options(), " "))
 
 	base.viper.Set("base64", true)
 	base.viper.Set("salt", false)
@@ -34,7 +38,41 @@ func TestOpenSSL_options(t *testing.T) {
 	assert.Equal(t, false, enc.salt)
 	assert.Equal(t, "rc4", enc.chiper)
 	assert.Equal(t, "gobackup-123", enc.password)
-	assert.Equal(t, "-pbkdf2 -iter 1000", enc.args)
+	assert.Equal(t, []string{"-pbkdf2", "-iter", "1000"}, enc.args)
 
-	assert.Equal(t, "rc4 -base64 -pbkdf2 -iter 1000 -k gobackup-123", strings.Join(enc.options(), " "))
+	assert.Equal(t, "rc4 -base64 -pbkdf2 -iter 1000 -pass env:GOBACKUP_OPENSSL_PASSWORD", strings.Join(enc.options(), " "))
+}
+
+func TestOpenSSL_decryptOptions(t *testing.T) {
+	base := &Base{
+		viper:       viper.New(),
+		archivePath: "/foo/bar.tar.gz.enc",
+	}
+	base.viper.Set("password", "gobackup-123")
+
+	enc := NewOpenSSL(base)
+	assert.Equal(t, "aes-256-cbc -d -pass env:GOBACKUP_OPENSSL_PASSWORD", strings.Join(enc.decryptOptions(), " "))
+	assert.Equal(t, []string{"GOBACKUP_OPENSSL_PASSWORD=gobackup-123"}, enc.env())
+
+	base.viper.Set("base64", true)
+	base.viper.Set("args", "-pbkdf2 -iter 1000")
+
+	enc = NewOpenSSL(base)
+	assert.Equal(t, "aes-256-cbc -d -base64 -pbkdf2 -iter 1000 -pass env:GOBACKUP_OPENSSL_PASSWORD", strings.Join(enc.decryptOptions(), " "))
+}
+
+func TestOpenSSL_streamCanceled(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl is not installed")
+	}
+
+	base := &Base{viper: viper.New()}
+	base.viper.Set("password", "gobackup-123")
+	enc := NewOpenSSL(base)
+
+	// the command is bound to ctx, it is not started when ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := enc.stream(ctx, io.Discard)
+	assert.True(t, errors.Is(err, context.Canceled), err)
 }
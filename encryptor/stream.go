@@ -0,0 +1,79 @@
+package encryptor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// streamEncryptor is implemented by the encryptors which can encrypt on the fly
+type streamEncryptor interface {
+	// stream return a writer which encrypt the data into w, the command is killed when ctx is done
+	stream(ctx context.Context, w io.Writer) (io.WriteCloser, error)
+}
+
+// Stream encryptor for the streaming mode
+type Stream struct {
+	enc streamEncryptor
+}
+
+// NewStream prepare the encryptor with `encrypt_with` config for the streaming mode,
+// the extension is saved as Run does.
+func NewStream(ctx context.Context, model config.ModelConfig) (*Stream, error) {
+	st := &Stream{}
+
+	// If no encryption configured, the data is written as-is
+	if model.EncryptWith.Type == "" {
+		return st, nil
+	}
+
+	enc := newEncryptor(newBase("", model))
+	if enc == nil {
+		return st, nil
+	}
+
+	streamEnc, ok := enc.(streamEncryptor)
+	if !ok {
+		return nil, fmt.Errorf("%s encryptor does not support streaming", model.EncryptWith.Type)
+	}
+	st.enc = streamEnc
+
+	logger.TagContext(ctx, "Encryptor").Info("encrypt | " + model.EncryptWith.Type)
+
+	// save Extension
+	model.Viper.Set("Ext", model.Viper.GetString("Ext")+enc.ext())
+
+	return st, nil
+}
+
+// Writer return a writer which encrypt the data into w, it is stopped when ctx is done
+func (st *Stream) Writer(ctx context.Context, w io.Writer) (io.WriteCloser, error) {
+	if st.enc == nil {
+		return nopWriteCloser{w}, nil
+	}
+
+	return st.enc.stream(ctx, w)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func (enc *OpenSSL) stream(ctx context.Context, w io.Writer) (io.WriteCloser, error) {
+	if len(enc.password) == 0 {
+		return nil, fmt.Errorf("password option is required")
+	}
+
+	// openssl reads from stdin and writes to stdout without -in and -out
+	p, err := helper.ExecPipeWithOptions(ctx, w, helper.ExecOptions{Env: enc.env()}, "openssl", enc.options()...)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -4,11 +4,14 @@ go 1.21
 
 require (
 	cloud.google.com/go/storage v1.28.0
+	filippo.io/age v1.2.1
 	github.com/Azure/azure-sdk-for-go/sdk/azidentity v1.2.0
 	github.com/Azure/azure-sdk-for-go/sdk/storage/azblob v0.6.1
+	github.com/ProtonMail/go-crypto v1.1.6
 	github.com/aws/aws-sdk-go v1.34.0
 	github.com/bramvdbogaerde/go-scp v1.2.0
 	github.com/cheggaaa/pb/v3 v3.1.2
+	github.com/dsnet/compress v0.0.1
 	github.com/dustin/go-humanize v1.0.0
 	github.com/fatih/color v1.14.1
 	github.com/go-co-op/gocron v1.18.0
@@ -16,24 +19,37 @@ require (
 	github.com/invopop/jsonschema v0.13.0
 	github.com/jlaffaye/ftp v0.1.0
 	github.com/joho/godotenv v1.4.0
+	github.com/klauspost/compress v1.17.11
+	github.com/klauspost/pgzip v1.2.6
 	github.com/longbridgeapp/assert v1.1.0
 	github.com/pkg/sftp v1.13.5
+	github.com/prometheus/client_golang v1.14.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/sevlyar/go-daemon v0.1.6
 	github.com/spf13/viper v1.14.0
 	github.com/studio-b12/gowebdav v0.0.0-20221109171924-60ec5ad56012
+	github.com/ulikunitz/xz v0.5.12
 	github.com/urfave/cli/v2 v2.23.6
+	go.etcd.io/bbolt v1.3.10
 	golang.org/x/crypto v0.31.0
 	google.golang.org/api v0.103.0
 )
 
 	require (
+	filippo.io/edwards25519 v1.1.0 // indirect
 		github.com/VividCortex/ewma v1.2.0 // indirect
 		github.com/bahlo/generic-list-go v0.2.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
 		github.com/buger/jsonparser v1.1.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/cloudflare/circl v1.3.7 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mattn/go-runewidth v0.0.14 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
+	github.com/prometheus/common v0.37.0 // indirect
+	github.com/prometheus/procfs v0.8.0 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
-	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1 // indirect
 	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
 )
 
@@ -110,7 +126,7 @@ require (
 	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 replace github.com/jlaffaye/ftp => github.com/ncw/ftp v0.0.0-20221014105808-5da37698fc59
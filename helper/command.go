@@ -0,0 +1,150 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Command is the name and the arguments (argv) of a command.
+// The arguments are passed to the process as they are, so the password, table name or path with spaces are kept.
+type Command struct {
+	Name string
+	Args []string
+}
+
+// NewCommand return the Command of name with args
+func NewCommand(name string, args ...string) *Command {
+	return &Command{Name: name, Args: args}
+}
+
+// String return the command line with the arguments quoted like the shell, for the logs
+func (c *Command) String() string {
+	words := make([]string, 0, len(c.Args)+1)
+	words = append(words, ShellQuote(c.Name))
+	for _, arg := range c.Args {
+		words = append(words, ShellQuote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+var shellSafeRegexp = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// ShellQuote quote s with the single quotes if it has the special characters of the shell
+func ShellQuote(s string) string {
+	if shellSafeRegexp.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ParseCommand split the command line into the Command like the shell, args are appended as they are.
+//
+//	ParseCommand("head -n1", "a b.txt") => head ["-n1", "a b.txt"]
+func ParseCommand(line string, args ...string) (*Command, error) {
+	words, err := SplitArgs(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+
+	return NewCommand(words[0], append(words[1:], args...)...), nil
+}
+
+// SplitArgs split s into the arguments like the shell, the single quotes, double quotes and backslash escapes are supported,
+// but the variables and globs are not expanded.
+//
+//	SplitArgs(`--where "id > 10" --comment='a b'`) => ["--where", "id > 10", "--comment=a b"]
+func SplitArgs(s string) ([]string, error) {
+	args := []string{}
+
+	var (
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			// in double quotes, the backslash only escapes the special characters
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("unexpected backslash at the end of: %s", s)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote %c in: %s", quote, s)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+
+	return args, nil
+}
+
+// ParseArgs return the arguments of the `args` config, which is a YAML list, or a string split by SplitArgs.
+//
+//	args: --single-transaction --where "id > 10"
+//	args: ["--single-transaction", "--where", "id > 10"]
+func ParseArgs(value any) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return SplitArgs(value)
+	case []string:
+		return value, nil
+	case []any:
+		args := make([]string, 0, len(value))
+		for _, arg := range value {
+			switch arg := arg.(type) {
+			case string:
+				args = append(args, arg)
+			case int, int64, float64, bool:
+				args = append(args, fmt.Sprint(arg))
+			default:
+				return nil, fmt.Errorf("invalid args item: %v", arg)
+			}
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("invalid args: %v, must be a string or a list", value)
+	}
+}
@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	cases := map[string][]string{
+		"":                                   {},
+		"  --foo  --bar\t--baz\n":            {"--foo", "--bar", "--baz"},
+		`--where "id > 10" --comment='a b'`:  {"--where", "id > 10", "--comment=a b"},
+		`a\ b "c \"d\" \e" 'f\g' ""`:         {"a b", `c "d" \e`, `f\g`, ""},
+		`--password="it's"`:                  {"--password=it's"},
+		`--tables-list 'db.order items',foo`: {"--tables-list", "db.order items,foo"},
+	}
+
+	for s, expected := range cases {
+		args, err := SplitArgs(s)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, args)
+	}
+
+	_, err := SplitArgs(`--where "id > 10`)
+	assert.EqualError(t, err, `unclosed quote " in: --where "id > 10`)
+
+	_, err = SplitArgs(`--foo \`)
+	assert.EqualError(t, err, `unexpected backslash at the end of: --foo \`)
+}
+
+func TestParseArgs(t *testing.T) {
+	args, err := ParseArgs(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, args)
+
+	args, err = ParseArgs(`--single-transaction --where "id > 10"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--single-transaction", "--where", "id > 10"}, args)
+
+	args, err = ParseArgs([]any{"--where", "id > 10", "--threads", 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--where", "id > 10", "--threads", "4"}, args)
+
+	args, err = ParseArgs([]string{"--foo bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--foo bar"}, args)
+
+	_, err = ParseArgs([]any{map[string]any{"foo": "bar"}})
+	assert.EqualError(t, err, "invalid args item: map[foo:bar]")
+
+	_, err = ParseArgs(123)
+	assert.EqualError(t, err, "invalid args: 123, must be a string or a list")
+}
+
+func TestCommand(t *testing.T) {
+	cmd := NewCommand("mysqldump", "--host", "127.0.0.1", "--where", "id > 10", "it's", "")
+	assert.Equal(t, `mysqldump --host 127.0.0.1 --where 'id > 10' 'it'\''s' ''`, cmd.String())
+
+	cmd, err := ParseCommand("head  -n1", "a b.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, NewCommand("head", "-n1", "a b.txt"), cmd)
+
+	_, err = ParseCommand(" ")
+	assert.EqualError(t, err, "command is empty")
+}
@@ -2,20 +2,24 @@ package helper
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
-	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gobackup/gobackup/logger"
 	"github.com/google/uuid"
 )
 
 var (
-	spaceRegexp = regexp.MustCompile(`[\s]+`)
+	// waitDelay for the output of a killed command to be closed
+	waitDelay = 5 * time.Second
 )
 
 // Exec cli commands
@@ -23,30 +27,64 @@ func Exec(command string, args ...string) (output string, err error) {
 	return ExecWithStdio(command, false, args...)
 }
 
+// ExecContext exec cli commands, the command is killed when ctx is done
+func ExecContext(ctx context.Context, command string, args ...string) (output string, err error) {
+	return ExecWithStdioContext(ctx, command, false, args...)
+}
+
 func ExecWithStdio(command string, stdout bool, args ...string) (output string, err error) {
-	commands := spaceRegexp.Split(command, -1)
-	command = commands[0]
-	commandArgs := []string{}
-	if len(commands) > 1 {
-		commandArgs = commands[1:]
-	}
-	if len(args) > 0 {
-		commandArgs = append(commandArgs, args...)
+	return ExecWithStdioContext(context.Background(), command, stdout, args...)
+}
+
+// ExecWithStdioContext is ExecWithStdio with ctx, ctx.Err() is returned when the command is killed by ctx
+func ExecWithStdioContext(ctx context.Context, command string, stdout bool, args ...string) (output string, err error) {
+	return ExecWithOptions(ctx, ExecOptions{Stdout: stdout}, command, args...)
+}
+
+// ExecOptions of the command, they are used to pass the credentials without putting them in the arguments,
+// which are visible to the other users by `ps`.
+type ExecOptions struct {
+	// Env is appended to the environment of the current process, e.g. `PGPASSWORD=xxx`
+	Env []string
+	// Stdin of the command
+	Stdin io.Reader
+	// Stdout print the output of the command into os.Stdout instead of returning it
+	Stdout bool
+}
+
+// ExecWithOptions exec cli commands with opts, ctx.Err() is returned when the command is killed by ctx.
+// The command line is split like the shell, args are passed as they are.
+func ExecWithOptions(ctx context.Context, opts ExecOptions, command string, args ...string) (output string, err error) {
+	cmd, err := ParseCommand(command, args...)
+	if err != nil {
+		return "", err
 	}
 
-	fullCommand, err := exec.LookPath(command)
+	return ExecCommand(ctx, opts, cmd)
+}
+
+// ExecCommand exec the command with opts, ctx.Err() is returned when the command is killed by ctx
+func ExecCommand(ctx context.Context, opts ExecOptions, command *Command) (output string, err error) {
+	fullCommand, err := exec.LookPath(command.Name)
 	if err != nil {
-		return "", fmt.Errorf("%s cannot be found", command)
+		return "", fmt.Errorf("%s cannot be found", command.Name)
 	}
 
-	cmd := exec.Command(fullCommand, commandArgs...)
-	cmd.Env = os.Environ()
+	cmd := exec.CommandContext(ctx, fullCommand, command.Args...)
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdin = opts.Stdin
+	if opts.Stdin != nil {
+		// without the controlling terminal, the commands prompting the password (e.g. 7z) read it from stdin
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	}
+	killGroupOnCancel(ctx, cmd)
 
 	var stdErr bytes.Buffer
 	var stdOut bytes.Buffer
-	cmd.Stderr = &stdErr
+	// stderr is kept in the log of the run
+	cmd.Stderr = io.MultiWriter(&stdErr, logger.Captured(ctx))
 
-	if stdout {
+	if opts.Stdout {
 		cmd.Stdout = os.Stdout
 	} else {
 		cmd.Stdout = &stdOut
@@ -54,16 +92,117 @@ func ExecWithStdio(command string, stdout bool, args ...string) (output string,
 
 	err = cmd.Run()
 	if err != nil {
-		logger.Debug(fullCommand, " ", strings.Join(commandArgs, " "))
-		err = errors.New(stdErr.String())
+		logger.Debug(command.String())
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		err = errors.New(logger.Redact(stdErr.String()))
 	}
 	output = strings.Trim(stdOut.String(), "\n")
 
 	return
 }
 
+// killGroupOnCancel start the command in a new process group and kill the whole group when ctx is done,
+// so that the children of the command (e.g. the pipes in a script) are killed as well.
+func killGroupOnCancel(ctx context.Context, cmd *exec.Cmd) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	// the new session is also a new process group
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+}
+
+// PipeCommand is a running command, data written to it is piped into the stdin of the command
+type PipeCommand struct {
+	io.WriteCloser
+	ctx     context.Context
+	cmd     *exec.Cmd
+	command *Command
+	stdErr  *bytes.Buffer
+}
+
+// ExecPipe start the command which reads from the returned writer and writes the stdout into out,
+// the command exits after the writer is closed.
+func ExecPipe(out io.Writer, command string, args ...string) (*PipeCommand, error) {
+	return ExecPipeContext(context.Background(), out, command, args...)
+}
+
+// ExecPipeContext is ExecPipe with ctx, the command is killed when ctx is done
+func ExecPipeContext(ctx context.Context, out io.Writer, command string, args ...string) (*PipeCommand, error) {
+	return ExecPipeWithOptions(ctx, out, ExecOptions{}, command, args...)
+}
+
+// ExecPipeWithOptions is ExecPipeContext with opts, only the Env of opts is used,
+// as the stdin is the returned writer and the stdout is out.
+func ExecPipeWithOptions(ctx context.Context, out io.Writer, opts ExecOptions, command string, args ...string) (*PipeCommand, error) {
+	cmd, err := ParseCommand(command, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ExecPipeCommand(ctx, out, opts, cmd)
+}
+
+// ExecPipeCommand is ExecPipeWithOptions with the command
+func ExecPipeCommand(ctx context.Context, out io.Writer, opts ExecOptions, command *Command) (*PipeCommand, error) {
+	fullCommand, err := exec.LookPath(command.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s cannot be found", command.Name)
+	}
+
+	cmd := exec.CommandContext(ctx, fullCommand, command.Args...)
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdout = out
+	killGroupOnCancel(ctx, cmd)
+
+	var stdErr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(&stdErr, logger.Captured(ctx))
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &PipeCommand{WriteCloser: stdin, ctx: ctx, cmd: cmd, command: command, stdErr: &stdErr}, nil
+}
+
+// Close the stdin and wait for the command to exit
+func (p *PipeCommand) Close() error {
+	closeErr := p.WriteCloser.Close()
+
+	if err := p.cmd.Wait(); err != nil {
+		logger.Debug(p.command.String())
+		if p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+		if p.stdErr.Len() > 0 {
+			return errors.New(logger.Redact(p.stdErr.String()))
+		}
+		return err
+	}
+
+	return closeErr
+}
+
 // Execute multiple line script with stdio
 func ExecScriptWithStdio(script string, stdout bool) (string, error) {
+	return ExecScriptWithStdioContext(context.Background(), script, stdout)
+}
+
+// ExecScriptWithStdioContext is ExecScriptWithStdio with ctx, the script is killed when ctx is done
+func ExecScriptWithStdioContext(ctx context.Context, script string, stdout bool) (string, error) {
 	tmpFileName, _ := uuid.NewUUID()
 	tmpFile := path.Join(os.TempDir(), tmpFileName.String())
 
@@ -80,7 +219,7 @@ func ExecScriptWithStdio(script string, stdout bool) (string, error) {
 	defer f.Close()
 	defer os.Remove(tmpFile)
 
-	return ExecWithStdio("sh", stdout, tmpFile)
+	return ExecCommand(ctx, ExecOptions{Stdout: stdout}, NewCommand("sh", tmpFile))
 }
 
 // Execute multiple line script
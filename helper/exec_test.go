@@ -1,9 +1,16 @@
 package helper
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/longbridgeapp/assert"
+
+	"github.com/gobackup/gobackup/logger"
 )
 
 func TestExec(t *testing.T) {
@@ -29,6 +36,34 @@ func TestExec(t *testing.T) {
 	assert.Empty(t, out)
 }
 
+func TestExecContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	startedAt := time.Now()
+	_, err := ExecContext(ctx, "sleep", "10")
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(startedAt) < 5*time.Second)
+
+	out, err := ExecContext(context.Background(), "head -n1", "./exec_test.go")
+	assert.Nil(t, err)
+	assert.Equal(t, out, "package helper")
+}
+
+func TestExecScriptWithStdioContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the children of the script hold the output until they are killed
+	startedAt := time.Now()
+	_, err := ExecScriptWithStdioContext(ctx, "sleep 10 | cat", false)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(startedAt) < 2*time.Second)
+}
+
 func TestExecWithStdio(t *testing.T) {
 	out, err := ExecWithStdio("head -n1", false, "./exec_test.go")
 	assert.Nil(t, err)
@@ -54,3 +89,62 @@ func TestExecScript(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, out, "package helper\nhello world")
 }
+
+func TestExecPipe(t *testing.T) {
+	var out bytes.Buffer
+	p, err := ExecPipe(&out, "tr a-z A-Z")
+	assert.Nil(t, err)
+
+	_, err = p.Write([]byte("hello world"))
+	assert.Nil(t, err)
+	assert.Nil(t, p.Close())
+	assert.Equal(t, "HELLO WORLD", out.String())
+
+	_, err = ExecPipe(&out, "not-found-command")
+	assert.Equal(t, err.Error(), "not-found-command cannot be found")
+}
+
+func TestExecWithOptions(t *testing.T) {
+	out, err := ExecWithOptions(context.Background(), ExecOptions{Env: []string{"GOBACKUP_TEST_PASSWORD=pass1"}}, "sh", "-c", "echo $GOBACKUP_TEST_PASSWORD")
+	assert.Nil(t, err)
+	assert.Equal(t, out, "pass1")
+
+	out, err = ExecWithOptions(context.Background(), ExecOptions{Stdin: strings.NewReader("hello world\n")}, "tr a-z A-Z")
+	assert.Nil(t, err)
+	assert.Equal(t, out, "HELLO WORLD")
+
+	logger.SetSecrets([]string{"pass1"})
+	defer logger.SetSecrets(nil)
+	_, err = ExecWithOptions(context.Background(), ExecOptions{}, "sh", "-c", "echo error pass1 >&2; exit 1")
+	assert.NotNil(t, err)
+	assert.False(t, strings.Contains(err.Error(), "pass1"))
+}
+
+func TestWriteSecretFile(t *testing.T) {
+	path, err := WriteSecretFile("gobackup-test-*", "pass1")
+	assert.Nil(t, err)
+	defer os.Remove(path)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass1", string(data))
+}
+
+func TestExecCommand(t *testing.T) {
+	// the argument with spaces is not split
+	out, err := ExecCommand(context.Background(), ExecOptions{}, NewCommand("printf", "%s|", "a  b", "c"))
+	assert.Nil(t, err)
+	assert.Equal(t, out, "a  b|c|")
+
+	var buf bytes.Buffer
+	p, err := ExecPipeCommand(context.Background(), &buf, ExecOptions{}, NewCommand("sed", "s/a b/c d/"))
+	assert.Nil(t, err)
+	_, err = p.Write([]byte("a b"))
+	assert.Nil(t, err)
+	assert.Nil(t, p.Close())
+	assert.Equal(t, "c d", buf.String())
+}
@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"context"
+	"time"
+
+	"github.com/gobackup/gobackup/logger"
+)
+
+// RetryPolicy of a failed operation, the interval is doubled after each attempt
+//
+// retry:
+//
+//	attempts: 3
+//	initial_interval: 1s
+//	max_interval: 30s
+type RetryPolicy struct {
+	// Attempts is the max number of the attempts, include the first one
+	Attempts        int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// Do call fn until it succeeds, the attempts are used up or ctx is done,
+// return the number of the attempts and the last error.
+func (p RetryPolicy) Do(ctx context.Context, name string, fn func(attempt int) error) (attempts int, err error) {
+	logger := logger.TagContext(ctx, "Retry")
+
+	interval := p.InitialInterval
+	for attempts = 1; ; attempts++ {
+		err = fn(attempts)
+		if err == nil || attempts >= p.Attempts || ctx.Err() != nil {
+			return attempts, err
+		}
+
+		logger.Warnf("%s failed (attempt %d/%d): %v, retry in %s", name, attempts, p.Attempts, err, interval)
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return attempts, err
+		}
+
+		interval *= 2
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
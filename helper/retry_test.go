@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	p := RetryPolicy{Attempts: 4, InitialInterval: 10 * time.Millisecond, MaxInterval: 15 * time.Millisecond}
+
+	var calledAt []time.Time
+	attempts, err := p.Do(context.Background(), "foo", func(attempt int) error {
+		calledAt = append(calledAt, time.Now())
+		return fmt.Errorf("failed %d", attempt)
+	})
+	assert.Equal(t, 4, attempts)
+	assert.EqualError(t, err, "failed 4")
+	assert.Equal(t, 4, len(calledAt))
+	assert.True(t, calledAt[1].Sub(calledAt[0]) >= 10*time.Millisecond)
+	// the interval is limited by MaxInterval, it is 40ms otherwise
+	assert.True(t, calledAt[3].Sub(calledAt[2]) >= 15*time.Millisecond)
+	assert.True(t, calledAt[3].Sub(calledAt[2]) < 35*time.Millisecond)
+
+	attempts, err = p.Do(context.Background(), "foo", func(attempt int) error {
+		if attempt < 2 {
+			return fmt.Errorf("failed %d", attempt)
+		}
+		return nil
+	})
+	assert.Equal(t, 2, attempts)
+	assert.NoError(t, err)
+
+	// no retry by default
+	attempts, err = RetryPolicy{}.Do(context.Background(), "foo", func(attempt int) error {
+		return fmt.Errorf("failed %d", attempt)
+	})
+	assert.Equal(t, 1, attempts)
+	assert.EqualError(t, err, "failed 1")
+
+	// stop waiting when ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	startedAt := time.Now()
+	attempts, _ = RetryPolicy{Attempts: 3, InitialInterval: time.Minute}.Do(ctx, "foo", func(attempt int) error {
+		return fmt.Errorf("failed %d", attempt)
+	})
+	assert.Equal(t, 1, attempts)
+	assert.True(t, time.Since(startedAt) < time.Second)
+}
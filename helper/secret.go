@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"os"
+)
+
+// WriteSecretFile write content into a new temp file which is only accessible by the current user (0600),
+// e.g. the option file with the password of the database. The caller should remove it after used.
+func WriteSecretFile(pattern string, content string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
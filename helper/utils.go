@@ -1,7 +1,10 @@
 package helper
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -36,3 +39,35 @@ func FormatEndpoint(endpoint string) string {
 
 	return endpoint
 }
+
+// ParseDuration parse duration like time.ParseDuration, with the `d` (day) and `w` (week) units, e.g. 90d, 2w, 1d12h
+func ParseDuration(s string) (time.Duration, error) {
+	var total time.Duration
+	rest := s
+	for len(rest) > 0 {
+		i := strings.IndexAny(rest, "dw")
+		if i < 0 {
+			d, err := time.ParseDuration(rest)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", s)
+			}
+			return total + d, nil
+		}
+
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		unit := 24 * time.Hour
+		if rest[i] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+
+	if len(s) == 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return total, nil
+}
@@ -3,6 +3,7 @@ package helper
 import (
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/longbridgeapp/assert"
 )
@@ -27,3 +28,23 @@ func TestFormatEndpoint(t *testing.T) {
 	assert.Equal(t, "https://foo.bar.com", FormatEndpoint("https://foo.bar.com"))
 	assert.Equal(t, "https://foo.bar.com", FormatEndpoint("https://foo.bar.com"))
 }
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"90d":    90 * 24 * time.Hour,
+		"2w":     14 * 24 * time.Hour,
+		"1d12h":  36 * time.Hour,
+		"1w1d":   8 * 24 * time.Hour,
+		"36h30m": 36*time.Hour + 30*time.Minute,
+	}
+	for s, expected := range cases {
+		d, err := ParseDuration(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, d, s)
+	}
+
+	for _, s := range []string{"", "d", "1.5d", "90", "foo", "1d2x"} {
+		_, err := ParseDuration(s)
+		assert.EqualError(t, err, "invalid duration: "+s)
+	}
+}
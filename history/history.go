@@ -0,0 +1,325 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// TriggerCLI run by `gobackup perform`
+	TriggerCLI = "cli"
+	// TriggerScheduler run by the schedule of the model
+	TriggerScheduler = "scheduler"
+	// TriggerWeb run by `POST /api/perform`
+	TriggerWeb = "web"
+
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+var (
+	// maxRuns to keep in the history, the older runs are removed
+	maxRuns    uint64 = 1000
+	dbPath            = filepath.Join(config.GoBackupDir, "history.db")
+	runsBucket        = []byte("runs")
+	// bbolt does not allow to open the same file twice in a process
+	mu sync.Mutex
+)
+
+// ErrNotFound is returned when the run is not found
+var ErrNotFound = fmt.Errorf("run not found")
+
+// Run is an execution of a model
+type Run struct {
+	ID         uint64    `json:"id"`
+	Model      string    `json:"model"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Stages     []Stage   `json:"stages"`
+	// ArchiveSize is the total size of the package files
+	ArchiveSize int64     `json:"archive_size"`
+	Storages    []Storage `json:"storages"`
+	Error       string    `json:"error,omitempty"`
+
+	stageStartedAt time.Time
+}
+
+// Stage of the run, e.g. database, archive, compressor, encryptor, splitter, storage
+type Stage struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Storage result of the run
+type Storage struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	DurationMs int64  `json:"duration_ms"`
+	// Removed is the number of the packages removed by the cycler
+	Removed int `json:"removed"`
+	// Attempts of the upload, it is more than 1 when retried
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Start record a new run of the model
+func Start(model string, trigger string) *Run {
+	if len(trigger) == 0 {
+		trigger = TriggerCLI
+	}
+
+	run := &Run{
+		Model:     model,
+		Trigger:   trigger,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		Stages:    []Stage{},
+		Storages:  []Storage{},
+	}
+	run.save()
+
+	return run
+}
+
+// Stage finish the current stage and start the next one
+func (run *Run) Stage(name string) {
+	run.finishStage()
+	run.Stages = append(run.Stages, Stage{Name: name})
+	run.stageStartedAt = time.Now()
+}
+
+func (run *Run) finishStage() {
+	if len(run.Stages) == 0 {
+		return
+	}
+
+	stage := &run.Stages[len(run.Stages)-1]
+	if stage.DurationMs == 0 {
+		stage.DurationMs = time.Since(run.stageStartedAt).Milliseconds()
+	}
+}
+
+// AddStorage record the result of a storage
+func (run *Run) AddStorage(name, storageType string, duration time.Duration, removed int, attempts int, err error) {
+	storage := Storage{Name: name, Type: storageType, DurationMs: duration.Milliseconds(), Removed: removed, Attempts: attempts}
+	if err != nil {
+		storage.Error = err.Error()
+	}
+	run.Storages = append(run.Storages, storage)
+}
+
+// Finish the run with err
+func (run *Run) Finish(err error) {
+	run.finishStage()
+
+	run.FinishedAt = time.Now()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	if err != nil {
+		run.Status = StatusFailure
+		run.Error = err.Error()
+	} else {
+		run.Status = StatusSuccess
+	}
+
+	run.save()
+}
+
+// LogPath return the path of the log file of the run
+func LogPath(id uint64) string {
+	return filepath.Join(filepath.Dir(dbPath), "runs", fmt.Sprintf("%d.log", id))
+}
+
+// save the run, a failure is only logged, so the backup is not affected
+func (run *Run) save() {
+	var removed []uint64
+	err := update(func(b *bolt.Bucket) error {
+		if run.ID == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			run.ID = id
+		}
+
+		data, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(itob(run.ID), data); err != nil {
+			return err
+		}
+
+		// remove the older runs
+		if run.ID > maxRuns {
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= run.ID-maxRuns; k, _ = c.Next() {
+				removed = append(removed, binary.BigEndian.Uint64(k))
+			}
+			for _, id := range removed {
+				if err := b.Delete(itob(id)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Tag("History").Warnf("Save run of %s failed: %v", run.Model, err)
+		return
+	}
+
+	for _, id := range removed {
+		if err := os.Remove(LogPath(id)); err != nil && !os.IsNotExist(err) {
+			logger.Tag("History").Warnf("Remove log of run %d failed: %v", id, err)
+		}
+	}
+}
+
+// List the runs in newest first, filter by model when it is not empty
+func List(model string, limit int) ([]Run, error) {
+	runs := []Run{}
+
+	err := view(func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if limit > 0 && len(runs) >= limit {
+				break
+			}
+
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			if len(model) > 0 && run.Model != model {
+				continue
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+
+	return runs, err
+}
+
+// Get the run of id
+func Get(id uint64) (*Run, error) {
+	var run *Run
+
+	err := view(func(b *bolt.Bucket) error {
+		v := b.Get(itob(id))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		run = &Run{}
+		return json.Unmarshal(v, run)
+	})
+	if err == nil && run == nil {
+		err = ErrNotFound
+	}
+
+	return run, err
+}
+
+// Last return the last finished run of the model, nil if there is no run
+func Last(model string) (*Run, error) {
+	return last(model, func(run *Run) bool {
+		return run.Status != StatusRunning
+	})
+}
+
+// LastSuccess return the last succeeded run of the model, nil if there is no run
+func LastSuccess(model string) (*Run, error) {
+	return last(model, func(run *Run) bool {
+		return run.Status == StatusSuccess
+	})
+}
+
+func last(model string, match func(run *Run) bool) (*Run, error) {
+	var last *Run
+
+	err := view(func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			if run.Model == model && match(&run) {
+				last = &run
+				return nil
+			}
+		}
+		return nil
+	})
+
+	return last, err
+}
+
+// open the database for each operation, so that `gobackup perform` and the daemon can share it
+func open() (*bolt.DB, error) {
+	if err := helper.MkdirP(filepath.Dir(dbPath)); err != nil {
+		return nil, err
+	}
+
+	return bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+}
+
+func update(fn func(b *bolt.Bucket) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	db, err := open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		return fn(b)
+	})
+}
+
+func view(fn func(b *bolt.Bucket) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	db, err := open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(runsBucket)
+		if b == nil {
+			return nil
+		}
+		return fn(b)
+	})
+}
+
+func itob(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func useTempDB(t *testing.T) {
+	originalDBPath := dbPath
+	dbPath = filepath.Join(t.TempDir(), "history.db")
+	t.Cleanup(func() {
+		dbPath = originalDBPath
+	})
+}
+
+func TestRun(t *testing.T) {
+	useTempDB(t)
+
+	run := Start("foo", "")
+	assert.Equal(t, uint64(1), run.ID)
+	assert.Equal(t, TriggerCLI, run.Trigger)
+
+	saved, err := Get(run.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusRunning, saved.Status)
+
+	run.Stage("database")
+	time.Sleep(10 * time.Millisecond)
+	run.Stage("storage")
+	run.ArchiveSize = 1024
+	run.AddStorage("s3", "s3", 2*time.Second, 2, 1, nil)
+	run.AddStorage("local", "local", time.Second, 0, 3, fmt.Errorf("disk full"))
+	run.Finish(fmt.Errorf("Storage errors: [disk full]"))
+
+	saved, err = Get(run.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", saved.Model)
+	assert.Equal(t, StatusFailure, saved.Status)
+	assert.Equal(t, "Storage errors: [disk full]", saved.Error)
+	assert.Equal(t, int64(1024), saved.ArchiveSize)
+	assert.Equal(t, 2, len(saved.Stages))
+	assert.Equal(t, "database", saved.Stages[0].Name)
+	assert.True(t, saved.Stages[0].DurationMs >= 10)
+	assert.Equal(t, []Storage{
+		{Name: "s3", Type: "s3", DurationMs: 2000, Removed: 2, Attempts: 1},
+		{Name: "local", Type: "local", DurationMs: 1000, Attempts: 3, Error: "disk full"},
+	}, saved.Storages)
+	assert.False(t, saved.FinishedAt.IsZero())
+
+	_, err = Get(100)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestList(t *testing.T) {
+	useTempDB(t)
+
+	runs, err := List("", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(runs))
+	last, err := Last("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, last)
+
+	Start("foo", TriggerScheduler).Finish(nil)
+	Start("bar", TriggerWeb).Finish(nil)
+	Start("foo", TriggerWeb).Finish(fmt.Errorf("failed"))
+	Start("foo", TriggerCLI)
+
+	runs, err = List("", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(runs))
+	assert.Equal(t, uint64(4), runs[0].ID)
+
+	runs, err = List("foo", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(runs))
+	assert.Equal(t, uint64(4), runs[0].ID)
+	assert.Equal(t, uint64(3), runs[1].ID)
+
+	last, err = Last("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), last.ID)
+	assert.Equal(t, StatusFailure, last.Status)
+
+	last, err = LastSuccess("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), last.ID)
+	last, err = LastSuccess("baz")
+	assert.NoError(t, err)
+	assert.Nil(t, last)
+}
+
+func TestMaxRuns(t *testing.T) {
+	useTempDB(t)
+
+	originalMaxRuns := maxRuns
+	maxRuns = 3
+	defer func() {
+		maxRuns = originalMaxRuns
+	}()
+
+	for i := 0; i < 5; i++ {
+		run := Start("foo", "")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(LogPath(run.ID)), 0750))
+		assert.NoError(t, os.WriteFile(LogPath(run.ID), []byte("log"), 0640))
+		run.Finish(nil)
+	}
+
+	runs, err := List("", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(runs))
+	assert.Equal(t, uint64(5), runs[0].ID)
+	assert.Equal(t, uint64(3), runs[2].ID)
+
+	_, err = os.Stat(LogPath(2))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(LogPath(3))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+package job
+
+import (
+	"slices"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/model"
+)
+
+// Step is a model performed in the chain of a job
+type Step struct {
+	Model  string `json:"model"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+
+	m model.Model
+	// after are the models in the chain which must succeed before this one
+	after []string
+}
+
+// resolveChain return the steps of the job in topological order, the models in `depends_on`
+// are performed before the model, and the models in `on_success_run` after it succeeds.
+//
+// The cycles are rejected when the config is loaded.
+func resolveChain(m model.Model) []*Step {
+	steps := map[string]*Step{}
+	var order []string
+
+	var add func(m model.Model, after string)
+	add = func(m model.Model, after string) {
+		if s, ok := steps[m.Config.Name]; ok {
+			if len(after) > 0 && !slices.Contains(s.after, after) {
+				s.after = append(s.after, after)
+			}
+			return
+		}
+
+		s := &Step{Model: m.Config.Name, Status: StatusQueued, m: m, after: slices.Clone(m.Config.DependsOn)}
+		if len(after) > 0 {
+			s.after = append(s.after, after)
+		}
+		steps[s.Model] = s
+		order = append(order, s.Model)
+
+		for _, name := range m.Config.DependsOn {
+			if c := config.GetModelConfigByName(name); c != nil {
+				add(model.Model{Config: *c, Trigger: m.Trigger}, "")
+			}
+		}
+		for _, name := range m.Config.OnSuccessRun {
+			if c := config.GetModelConfigByName(name); c != nil {
+				add(model.Model{Config: *c, Trigger: m.Trigger}, m.Config.Name)
+			}
+		}
+	}
+	add(m, "")
+
+	// ignore the models not found in the config
+	for _, s := range steps {
+		s.after = slices.DeleteFunc(s.after, func(after string) bool {
+			_, ok := steps[after]
+			return !ok
+		})
+	}
+
+	// sort in topological order, the model added earlier goes first when several of them are ready
+	var chain []*Step
+	done := map[string]bool{}
+	for len(chain) < len(order) {
+		progress := false
+		for _, name := range order {
+			s := steps[name]
+			if done[name] || slices.ContainsFunc(s.after, func(after string) bool { return !done[after] }) {
+				continue
+			}
+
+			chain = append(chain, s)
+			done[name] = true
+			progress = true
+			break
+		}
+
+		// unreachable as the cycles are rejected, keep the rest in order instead of hanging
+		if !progress {
+			for _, name := range order {
+				if !done[name] {
+					chain = append(chain, steps[name])
+					done[name] = true
+				}
+			}
+		}
+	}
+
+	return chain
+}
@@ -0,0 +1,118 @@
+package job
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/model"
+	"github.com/longbridgeapp/assert"
+)
+
+func setModels(t *testing.T, models ...config.ModelConfig) {
+	originalModels := config.Models
+	config.Models = models
+	t.Cleanup(func() {
+		config.Models = originalModels
+	})
+}
+
+func stepModels(steps []*Step) (names []string) {
+	for _, s := range steps {
+		names = append(names, s.Model)
+	}
+	return
+}
+
+func Test_resolveChain(t *testing.T) {
+	setModels(t,
+		config.ModelConfig{Name: "db", OnSuccessRun: []string{"report"}},
+		config.ModelConfig{Name: "files", DependsOn: []string{"db"}},
+		config.ModelConfig{Name: "sync", DependsOn: []string{"files", "db"}},
+		config.ModelConfig{Name: "report"},
+		config.ModelConfig{Name: "other"},
+	)
+
+	steps := resolveChain(model.Model{Config: *config.GetModelConfigByName("sync"), Trigger: "web"})
+	assert.Equal(t, []string{"db", "files", "sync", "report"}, stepModels(steps))
+	assert.Equal(t, []string{"db"}, steps[3].after)
+	assert.Equal(t, []string{"files", "db"}, steps[2].after)
+	assert.Equal(t, "web", steps[0].m.Trigger)
+
+	// the pushed model is after the pusher, even it is added before
+	setModels(t,
+		config.ModelConfig{Name: "a", DependsOn: []string{"c", "b"}},
+		config.ModelConfig{Name: "b", OnSuccessRun: []string{"c"}},
+		config.ModelConfig{Name: "c"},
+	)
+	steps = resolveChain(model.Model{Config: *config.GetModelConfigByName("a")})
+	assert.Equal(t, []string{"b", "c", "a"}, stepModels(steps))
+
+	steps = resolveChain(newModel("alone"))
+	assert.Equal(t, []string{"alone"}, stepModels(steps))
+}
+
+func TestRunnerChain(t *testing.T) {
+	setModels(t,
+		config.ModelConfig{Name: "db"},
+		config.ModelConfig{Name: "files", OnSuccessRun: []string{"sync"}},
+		config.ModelConfig{Name: "sync", DependsOn: []string{"db"}},
+	)
+
+	br := newBlockingRunner(2)
+	j, queued := br.Enqueue(model.Model{Config: *config.GetModelConfigByName("files")})
+	assert.True(t, queued)
+	assert.Equal(t, []string{"files", "db", "sync"}, []string{j.Steps[0].Model, j.Steps[1].Model, j.Steps[2].Model})
+
+	// the models in the chain are coalesced into the job
+	again, queued := br.Enqueue(newModel("db"))
+	assert.False(t, queued)
+	assert.Equal(t, j.ID, again.ID)
+
+	br.release("files", nil)
+	br.release("db", fmt.Errorf("dump failed"))
+	<-j.Done()
+
+	j, _ = br.Get(j.ID)
+	assert.Equal(t, StatusFailure, j.Status)
+	assert.Equal(t, "db: dump failed\nsync: skipped, as db is failure", j.Error)
+	assert.Equal(t, StatusSuccess, j.Steps[0].Status)
+	assert.Equal(t, StatusFailure, j.Steps[1].Status)
+	assert.Equal(t, StatusSkipped, j.Steps[2].Status)
+	assert.Equal(t, "db is failure", j.Steps[2].Error)
+	assert.Equal(t, []string{"files", "db"}, br.started)
+
+	// not chained
+	j, _ = br.Enqueue(newModel("db"))
+	br.release("db", nil)
+	<-j.Done()
+	j, _ = br.Get(j.ID)
+	assert.Equal(t, StatusSuccess, j.Status)
+	assert.Equal(t, 0, len(j.Steps))
+}
+
+func TestRunnerChainWithActiveStep(t *testing.T) {
+	setModels(t,
+		config.ModelConfig{Name: "db"},
+		config.ModelConfig{Name: "files", DependsOn: []string{"db"}},
+	)
+
+	br := newBlockingRunner(2)
+	db, queued := br.Enqueue(newModel("db"))
+	assert.True(t, queued)
+
+	// db is running, so the chain of files is not queued to run it again at the same time
+	j, queued := br.Enqueue(model.Model{Config: *config.GetModelConfigByName("files")})
+	assert.False(t, queued)
+	assert.Equal(t, db.ID, j.ID)
+
+	br.release("db", nil)
+	<-db.Done()
+
+	j, queued = br.Enqueue(model.Model{Config: *config.GetModelConfigByName("files")})
+	assert.True(t, queued)
+	br.release("db", nil)
+	br.release("files", nil)
+	<-j.Done()
+	assert.Equal(t, []string{"db", "db", "files"}, br.started)
+}
@@ -0,0 +1,390 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/model"
+	"github.com/gobackup/gobackup/notifier"
+)
+
+const (
+	StatusQueued   = "queued"
+	StatusRunning  = "running"
+	StatusSuccess  = "success"
+	StatusFailure  = "failure"
+	StatusCanceled = "canceled"
+	// StatusSkipped is the status of a step, when the models before it are not succeeded
+	StatusSkipped = "skipped"
+)
+
+var (
+	// maxFinished jobs are kept for listing
+	maxFinished = 100
+
+	// ErrNotFound is returned when the job is not found
+	ErrNotFound = fmt.Errorf("job not found")
+	// ErrFinished is returned when canceling a finished job
+	ErrFinished = fmt.Errorf("job is finished")
+
+	defaultRunner = NewRunner(1)
+)
+
+// Job is a perform of a model in the runner, with the models chained by `depends_on` and `on_success_run`
+type Job struct {
+	ID         uint64     `json:"id"`
+	Model      string     `json:"model"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	// Steps of the chain, empty when the model is not chained
+	Steps []Step `json:"steps,omitempty"`
+
+	m      model.Model
+	steps  []*Step
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Done is closed when the job is finished
+func (j Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// snapshot copy the job with its steps, the runner mu must be held
+func (j *Job) snapshot() Job {
+	job := *j
+	if len(j.steps) > 1 {
+		job.Steps = make([]Step, 0, len(j.steps))
+		for _, s := range j.steps {
+			job.Steps = append(job.Steps, *s)
+		}
+	}
+	return job
+}
+
+// Runner perform the jobs in queued order, at most concurrency jobs at the same time.
+//
+// A model has only one job queued or running, the duplicate triggers are coalesced into it,
+// including the job of the chain which the model is in.
+type Runner struct {
+	mu          sync.Mutex
+	concurrency int
+	lastID      uint64
+	queue       []*Job
+	running     map[uint64]*Job
+	// active is the queued or running job of the model
+	active   map[string]*Job
+	finished []*Job
+	perform  func(ctx context.Context, m model.Model) error
+	chain    func(m model.Model) []*Step
+}
+
+// NewRunner create a runner which performs at most concurrency jobs at the same time
+func NewRunner(concurrency int) *Runner {
+	r := &Runner{
+		running: map[uint64]*Job{},
+		active:  map[string]*Job{},
+		perform: func(ctx context.Context, m model.Model) error {
+			return m.Perform(ctx)
+		},
+		chain: resolveChain,
+	}
+	r.SetConcurrency(concurrency)
+
+	return r
+}
+
+// SetConcurrency change the max number of the running jobs, it is at least 1
+func (r *Runner) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.concurrency = concurrency
+	r.dispatch()
+}
+
+// Enqueue the model, queued is false when the model or any model in its chain already has a job
+// queued or running, and that job is returned.
+func (r *Runner) Enqueue(m model.Model) (job Job, queued bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if j, ok := r.active[m.Config.Name]; ok {
+		return j.snapshot(), false
+	}
+
+	steps := r.chain(m)
+	for _, s := range steps {
+		if j, ok := r.active[s.Model]; ok {
+			return j.snapshot(), false
+		}
+	}
+
+	r.lastID++
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &Job{
+		ID:       r.lastID,
+		Model:    m.Config.Name,
+		Trigger:  m.Trigger,
+		Status:   StatusQueued,
+		QueuedAt: time.Now(),
+		m:        m,
+		steps:    steps,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	r.queue = append(r.queue, j)
+	for _, s := range j.steps {
+		r.active[s.Model] = j
+	}
+	r.dispatch()
+
+	return j.snapshot(), true
+}
+
+// dispatch start the queued jobs while the concurrency allows, r.mu must be held
+func (r *Runner) dispatch() {
+	for len(r.queue) > 0 && len(r.running) < r.concurrency {
+		j := r.queue[0]
+		r.queue = r.queue[1:]
+
+		startedAt := time.Now()
+		j.Status = StatusRunning
+		j.StartedAt = &startedAt
+		r.running[j.ID] = j
+
+		go r.run(j)
+	}
+}
+
+func (r *Runner) run(j *Job) {
+	logger := logger.Tag(fmt.Sprintf("Job: %s", j.Model))
+
+	logger.Infof("Performing job %d...", j.ID)
+
+	var err error
+	if len(j.steps) == 1 {
+		err = r.runStep(j, j.steps[0])
+	} else {
+		var errs []error
+		for _, s := range j.steps {
+			// the rest steps are canceled in finish
+			if j.ctx.Err() != nil {
+				errs = append(errs, j.ctx.Err())
+				break
+			}
+			if stepErr := r.runStep(j, s); stepErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.Model, stepErr))
+			}
+		}
+		err = errors.Join(errs...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.running, j.ID)
+	r.finish(j, err)
+	logger.Infof("Job %d is %s.", j.ID, j.Status)
+	r.dispatch()
+}
+
+// runStep perform the model of the step, it is skipped when any model before it is not succeeded
+func (r *Runner) runStep(j *Job, s *Step) error {
+	logger := logger.Tag(fmt.Sprintf("Job: %s", j.Model))
+
+	for _, after := range s.after {
+		status := r.stepStatus(j, after)
+		if status == StatusSuccess {
+			continue
+		}
+
+		reason := fmt.Sprintf("%s is skipped, as %s is %s", s.Model, after, status)
+		logger.Warn(reason)
+		r.setStep(s, StatusSkipped, fmt.Errorf("%s is %s", after, status))
+		if modelConfig, err := config.ResolveSecrets(j.ctx, s.m.Config); err != nil {
+			logger.Errorf("Notify the skip of %s failed: %v", s.Model, err)
+		} else {
+			notifier.Skipped(modelConfig, reason)
+		}
+		return fmt.Errorf("skipped, as %s is %s", after, status)
+	}
+
+	r.setStep(s, StatusRunning, nil)
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("%v", p)
+			}
+		}()
+		return r.perform(j.ctx, s.m)
+	}()
+
+	switch {
+	case j.ctx.Err() != nil:
+		r.setStep(s, StatusCanceled, err)
+	case err != nil:
+		r.setStep(s, StatusFailure, err)
+	default:
+		r.setStep(s, StatusSuccess, nil)
+	}
+
+	return err
+}
+
+func (r *Runner) setStep(s *Step, status string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s.Status = status
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+func (r *Runner) stepStatus(j *Job, model string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range j.steps {
+		if s.Model == model {
+			return s.Status
+		}
+	}
+	return ""
+}
+
+// finish the job with err, r.mu must be held
+func (r *Runner) finish(j *Job, err error) {
+	finishedAt := time.Now()
+	j.FinishedAt = &finishedAt
+	switch {
+	case j.ctx.Err() != nil:
+		j.Status = StatusCanceled
+	case err != nil:
+		j.Status = StatusFailure
+	default:
+		j.Status = StatusSuccess
+	}
+	if err != nil {
+		j.Error = err.Error()
+	}
+	j.cancel()
+
+	for _, s := range j.steps {
+		if s.Status == StatusQueued {
+			s.Status = StatusCanceled
+		}
+		if r.active[s.Model] == j {
+			delete(r.active, s.Model)
+		}
+	}
+	r.finished = append(r.finished, j)
+	if len(r.finished) > maxFinished {
+		r.finished = r.finished[len(r.finished)-maxFinished:]
+	}
+	close(j.done)
+}
+
+// Cancel the job, a queued job is removed from the queue,
+// a running job is stopped and its status is changed after the perform returns.
+func (r *Runner) Cancel(id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, j := range r.queue {
+		if j.ID == id {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			j.cancel()
+			r.finish(j, context.Canceled)
+			return nil
+		}
+	}
+
+	if j, ok := r.running[id]; ok {
+		j.cancel()
+		return nil
+	}
+
+	for _, j := range r.finished {
+		if j.ID == id {
+			return ErrFinished
+		}
+	}
+
+	return ErrNotFound
+}
+
+// List the jobs in newest first
+func (r *Runner) List() []Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]Job, 0, len(r.queue)+len(r.running)+len(r.finished))
+	for _, j := range r.queue {
+		jobs = append(jobs, j.snapshot())
+	}
+	for _, j := range r.running {
+		jobs = append(jobs, j.snapshot())
+	}
+	for _, j := range r.finished {
+		jobs = append(jobs, j.snapshot())
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].ID > jobs[k].ID
+	})
+
+	return jobs
+}
+
+// Get the job of id
+func (r *Runner) Get(id uint64) (Job, error) {
+	for _, j := range r.List() {
+		if j.ID == id {
+			return j, nil
+		}
+	}
+
+	return Job{}, ErrNotFound
+}
+
+// SetConcurrency of the default runner
+func SetConcurrency(concurrency int) {
+	defaultRunner.SetConcurrency(concurrency)
+}
+
+// Enqueue the model into the default runner
+func Enqueue(m model.Model) (Job, bool) {
+	return defaultRunner.Enqueue(m)
+}
+
+// Cancel the job of the default runner
+func Cancel(id uint64) error {
+	return defaultRunner.Cancel(id)
+}
+
+// List the jobs of the default runner
+func List() []Job {
+	return defaultRunner.List()
+}
+
+// Get the job of the default runner
+func Get(id uint64) (Job, error) {
+	return defaultRunner.Get(id)
+}
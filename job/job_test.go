@@ -0,0 +1,179 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/model"
+	"github.com/longbridgeapp/assert"
+)
+
+// blockingRunner perform a job until it is released or canceled
+type blockingRunner struct {
+	*Runner
+
+	mu       sync.Mutex
+	started  []string
+	releases map[string]chan error
+}
+
+func newBlockingRunner(concurrency int) *blockingRunner {
+	br := &blockingRunner{Runner: NewRunner(concurrency), releases: map[string]chan error{}}
+	br.perform = func(ctx context.Context, m model.Model) error {
+		br.mu.Lock()
+		br.started = append(br.started, m.Config.Name)
+		br.mu.Unlock()
+
+		select {
+		case err := <-br.releaseOf(m.Config.Name):
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return br
+}
+
+func (br *blockingRunner) releaseOf(name string) chan error {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	if br.releases[name] == nil {
+		br.releases[name] = make(chan error, 1)
+	}
+	return br.releases[name]
+}
+
+// release the next perform of the model with err
+func (br *blockingRunner) release(name string, err error) {
+	br.releaseOf(name) <- err
+}
+
+func (br *blockingRunner) status(t *testing.T, id uint64) string {
+	t.Helper()
+
+	j, err := br.Get(id)
+	assert.NoError(t, err)
+	return j.Status
+}
+
+func newModel(name string) model.Model {
+	return model.Model{Config: config.ModelConfig{Name: name}, Trigger: "test"}
+}
+
+func TestRunnerConcurrency(t *testing.T) {
+	br := newBlockingRunner(1)
+
+	a, queued := br.Enqueue(newModel("a"))
+	assert.True(t, queued)
+	b, _ := br.Enqueue(newModel("b"))
+	c, _ := br.Enqueue(newModel("c"))
+
+	assert.Equal(t, StatusRunning, br.status(t, a.ID))
+	assert.Equal(t, StatusQueued, br.status(t, b.ID))
+	assert.Equal(t, StatusQueued, br.status(t, c.ID))
+
+	br.SetConcurrency(2)
+	assert.Equal(t, StatusRunning, br.status(t, b.ID))
+	assert.Equal(t, StatusQueued, br.status(t, c.ID))
+
+	br.release("a", nil)
+	<-a.Done()
+	assert.Equal(t, StatusSuccess, br.status(t, a.ID))
+
+	br.release("b", fmt.Errorf("dump failed"))
+	<-b.Done()
+	j, _ := br.Get(b.ID)
+	assert.Equal(t, StatusFailure, j.Status)
+	assert.Equal(t, "dump failed", j.Error)
+
+	br.release("c", nil)
+	<-c.Done()
+
+	jobs := br.List()
+	assert.Equal(t, 3, len(jobs))
+	assert.Equal(t, c.ID, jobs[0].ID)
+	assert.Equal(t, a.ID, jobs[2].ID)
+	assert.NotNil(t, jobs[2].StartedAt)
+	assert.NotNil(t, jobs[2].FinishedAt)
+}
+
+func TestRunnerCoalesce(t *testing.T) {
+	br := newBlockingRunner(1)
+
+	a, _ := br.Enqueue(newModel("a"))
+	dup, queued := br.Enqueue(newModel("a"))
+	assert.False(t, queued)
+	assert.Equal(t, a.ID, dup.ID)
+	assert.Equal(t, StatusRunning, dup.Status)
+
+	b, _ := br.Enqueue(newModel("b"))
+	dup, queued = br.Enqueue(newModel("b"))
+	assert.False(t, queued)
+	assert.Equal(t, b.ID, dup.ID)
+	assert.Equal(t, StatusQueued, dup.Status)
+
+	br.release("a", nil)
+	<-a.Done()
+
+	// a new job after the last one finished
+	again, queued := br.Enqueue(newModel("a"))
+	assert.True(t, queued)
+	assert.NotEqual(t, a.ID, again.ID)
+
+	br.release("b", nil)
+	br.release("a", nil)
+	<-again.Done()
+}
+
+func TestRunnerCancel(t *testing.T) {
+	br := newBlockingRunner(1)
+
+	a, _ := br.Enqueue(newModel("a"))
+	b, _ := br.Enqueue(newModel("b"))
+
+	// cancel a queued job
+	assert.NoError(t, br.Cancel(b.ID))
+	<-b.Done()
+	assert.Equal(t, StatusCanceled, br.status(t, b.ID))
+
+	// cancel a running job
+	assert.NoError(t, br.Cancel(a.ID))
+	<-a.Done()
+	j, _ := br.Get(a.ID)
+	assert.Equal(t, StatusCanceled, j.Status)
+	assert.Equal(t, context.Canceled.Error(), j.Error)
+	assert.Equal(t, []string{"a"}, br.started)
+
+	assert.Equal(t, ErrFinished, br.Cancel(a.ID))
+	assert.Equal(t, ErrNotFound, br.Cancel(100))
+}
+
+func TestRunnerMaxFinished(t *testing.T) {
+	originalMaxFinished := maxFinished
+	maxFinished = 2
+	defer func() {
+		maxFinished = originalMaxFinished
+	}()
+
+	r := NewRunner(1)
+	r.perform = func(ctx context.Context, m model.Model) error {
+		panic("boom")
+	}
+
+	var last Job
+	for _, name := range []string{"a", "b", "c"} {
+		last, _ = r.Enqueue(newModel(name))
+		<-last.Done()
+	}
+
+	jobs := r.List()
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, last.ID, jobs[0].ID)
+	assert.Equal(t, StatusFailure, jobs[0].Status)
+	assert.Equal(t, "boom", jobs[0].Error)
+}
@@ -1,10 +1,17 @@
 package logger
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
@@ -13,6 +20,48 @@ import (
 type Logger struct {
 	logFlag int
 	myLog   *log.Logger
+	// tag without color, for the json format
+	tag string
+	// run of the logger, nil when the logs are not of a run
+	run *RunLogger
+}
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+var levels = map[string]int{LevelDebug: 0, LevelInfo: 1, LevelWarn: 2, LevelError: 3, LevelFatal: 4}
+
+// Options of the logger, it is set by `log_format`, `log_level` and `log_max_*` in the config
+type Options struct {
+	// Format is text (default) or json, json writes one object per line
+	Format string
+	// Level is the min level to log: debug, info (default), warn or error
+	Level string
+	// MaxSize of the log file in bytes to rotate it, 0 is never rotate
+	MaxSize int64
+	// MaxAge of the rotated log files to keep, 0 is unlimited
+	MaxAge time.Duration
+	// MaxBackups is the number of the rotated log files to keep, 0 is unlimited
+	MaxBackups int
+}
+
+// Validate the format and the level
+func (opts Options) Validate() error {
+	if len(opts.Format) > 0 && opts.Format != FormatText && opts.Format != FormatJSON {
+		return fmt.Errorf("invalid log format %q, must be text or json", opts.Format)
+	}
+	if _, ok := levels[opts.Level]; len(opts.Level) > 0 && (!ok || opts.Level == LevelFatal) {
+		return fmt.Errorf("invalid log level %q, must be debug, info, warn or error", opts.Level)
+	}
+	return nil
 }
 
 var (
@@ -22,6 +71,10 @@ var (
 	sharedLogger Logger
 	isTest       = os.Getenv("GO_ENV") == "test"
 	isDebug      = os.Getenv("DEBUG") == "true"
+
+	options atomic.Pointer[Options]
+	// logFile is the rotated log file of SetLogger
+	logFile *rotateWriter
 )
 
 type writer struct {
@@ -43,26 +96,181 @@ func init() {
 		_myLog = log.New(logfile, "", _logFlag)
 	}
 	sharedLogger = newLogger()
+	options.Store(&Options{})
 }
 
 func SetLogger(logPath string) {
 	writers := make([]io.Writer, 0)
 	writers = append(writers, os.Stdout)
-	logfile, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	rw, err := newRotateWriter(logPath, *options.Load())
 	if err == nil {
-		writers = append(writers, logfile)
+		logFile = rw
+		writers = append(writers, rw)
 	}
 	multi := io.MultiWriter(writers...)
 	_myLog = log.New(&writer{multi, TimeFormat}, "", 0)
 	sharedLogger = newLogger()
 }
 
+// SetOptions change the format, level and rotation of the logger
+func SetOptions(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	options.Store(&opts)
+	if logFile != nil {
+		logFile.setOptions(opts)
+	}
+	return nil
+}
+
+// runs are the runs in progress
+var (
+	runsMu sync.Mutex
+	runs   = map[*RunLogger]bool{}
+)
+
+type runKey struct{}
+
+// WithRun return a copy of ctx with the run, the logs of TagContext(ctx) are written into it
+func WithRun(ctx context.Context, run *RunLogger) context.Context {
+	if run == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, runKey{}, run)
+}
+
+// runOf return the run of ctx, nil if ctx is not of a run
+func runOf(ctx context.Context) *RunLogger {
+	if ctx == nil {
+		return nil
+	}
+	run, _ := ctx.Value(runKey{}).(*RunLogger)
+	return run
+}
+
+// TagContext is Tag, but the logs are also written into the run of ctx
+func TagContext(ctx context.Context, tag string) Logger {
+	if run := runOf(ctx); run != nil {
+		return run.Tag(tag)
+	}
+	return Tag(tag)
+}
+
+// Captured return a writer which only writes into the run of ctx,
+// it is used for the output that is too noisy for the global log, e.g. stderr of the commands.
+func Captured(ctx context.Context) io.Writer {
+	if run := runOf(ctx); run != nil {
+		return capturedWriter{run}
+	}
+	return io.Discard
+}
+
+type capturedWriter struct {
+	run *RunLogger
+}
+
+func (w capturedWriter) Write(b []byte) (int, error) {
+	w.run.file.Write([]byte(Redact(string(b))))
+	return len(b), nil
+}
+
+// RunLogger write the log of a run into its own file, and still tee to the global log.
+//
+// Only the logs of the run are written into the file, the packages log with TagContext
+// by the context of the run.
+type RunLogger struct {
+	Logger
+	file  *os.File
+	model string
+	id    uint64
+	// stage in progress, guarded by runsMu
+	stage string
+}
+
+// NewRunLogger start to write the logs of the run id of model into logPath
+func NewRunLogger(logPath string, model string, id uint64) (*RunLogger, error) {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0750); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	// own *log.Logger, so that the tag of the run does not change the shared prefix,
+	// the time prefix of the text format is written into the file as well
+	var w io.Writer
+	if tw, ok := _myLog.Writer().(*writer); ok {
+		w = &writer{io.MultiWriter(tw.Writer, file), tw.timeFormat}
+	} else {
+		w = io.MultiWriter(_myLog.Writer(), file)
+	}
+	myLog := log.New(w, "", _myLog.Flags())
+	l := &RunLogger{Logger: Logger{logFlag: _logFlag, myLog: myLog}, file: file, model: model, id: id}
+	l.run = l
+
+	runsMu.Lock()
+	runs[l] = true
+	runsMu.Unlock()
+
+	return l, nil
+}
+
+// Tag the logs of the run, the same as the global Tag
+func (l *RunLogger) Tag(tag string) Logger {
+	return l.Logger.withTag(tag)
+}
+
+// Close stop writing the logs into the file
+func (l *RunLogger) Close() error {
+	runsMu.Lock()
+	delete(runs, l)
+	runsMu.Unlock()
+	removeSecrets(l)
+
+	return l.file.Close()
+}
+
+// SetStage set the stage in progress of the run id, it is logged in the json format
+func SetStage(id uint64, stage string) {
+	runsMu.Lock()
+	defer runsMu.Unlock()
+
+	for run := range runs {
+		if run.id == id {
+			run.stage = stage
+		}
+	}
+}
+
+// runFields return the model, id and stage of the run which the logger is bound to,
+// nothing is returned for the logs not of a run.
+func runFields(run *RunLogger) (model string, id uint64, stage string) {
+	if run == nil {
+		return "", 0, ""
+	}
+
+	runsMu.Lock()
+	defer runsMu.Unlock()
+
+	return run.model, run.id, run.stage
+}
+
 func newLogger() Logger {
-	return Logger{_logFlag, _myLog}
+	return Logger{logFlag: _logFlag, myLog: _myLog}
 }
 
 func Tag(tag string) Logger {
-	return sharedLogger.Tag(color.CyanString(fmt.Sprintf("[%s] ", tag)))
+	return sharedLogger.withTag(tag)
+}
+
+func (logger Logger) withTag(tag string) Logger {
+	logger = logger.Tag(color.CyanString(fmt.Sprintf("[%s] ", tag)))
+	logger.tag = tag
+	return logger
 }
 
 func (logger Logger) Prefix() string {
@@ -79,11 +287,94 @@ func (logger Logger) Tag(tag string) Logger {
 }
 
 func (logger Logger) log(v ...interface{}) {
-	logger.myLog.Print(v...)
+	if !enabled(LevelInfo) {
+		return
+	}
+	message := Redact(fmt.Sprint(v...))
+	if isJSON() {
+		logger.writeJSON(LevelInfo, message)
+		return
+	}
+	logger.myLog.Print(message)
 }
 
 func (logger Logger) logln(v ...interface{}) {
-	logger.myLog.Println(v...)
+	logger.output(LevelInfo, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// output the message in level, the secrets are masked, colorize is applied in the text format
+func (logger Logger) output(level string, message string, colorize func(format string, a ...interface{}) string) {
+	if !enabled(level) {
+		return
+	}
+	message = Redact(message)
+
+	if isJSON() {
+		logger.writeJSON(level, message)
+		return
+	}
+
+	if level == LevelDebug {
+		message = "[debug]  " + message
+	}
+	if colorize != nil {
+		message = colorize("%s", message)
+	}
+	logger.myLog.Println(message)
+}
+
+// jsonEntry is a line of the json format
+type jsonEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Tag     string `json:"tag,omitempty"`
+	Model   string `json:"model,omitempty"`
+	RunID   uint64 `json:"run_id,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+	Message string `json:"message"`
+}
+
+func (logger Logger) writeJSON(level string, message string) {
+	entry := jsonEntry{
+		Time:    time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
+		Level:   level,
+		Tag:     logger.tag,
+		Message: strings.TrimSuffix(message, "\n"),
+	}
+	entry.Model, entry.RunID, entry.Stage = runFields(logger.run)
+
+	// keep the `=>` of the messages as it is
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entry); err != nil {
+		return
+	}
+
+	// without the time prefix of the text format
+	w := logger.myLog.Writer()
+	if tw, ok := w.(*writer); ok {
+		w = tw.Writer
+	}
+	w.Write(buf.Bytes())
+}
+
+func isJSON() bool {
+	return options.Load().Format == FormatJSON
+}
+
+// enabled return true if the level is not lower than the level of the options,
+// debug is also enabled by `DEBUG=true` or in test.
+func enabled(level string) bool {
+	if level == LevelDebug && (isDebug || isTest) {
+		return true
+	}
+
+	minLevel := options.Load().Level
+	if len(minLevel) == 0 {
+		minLevel = LevelInfo
+	}
+	return levels[level] >= levels[minLevel]
 }
 
 // Print log
@@ -103,9 +394,7 @@ func (logger Logger) Printf(format string, v ...interface{}) {
 
 // Debug log
 func (logger Logger) Debug(v ...interface{}) {
-	if isDebug || isTest {
-		logger.logln("[debug] ", fmt.Sprint(v...))
-	}
+	logger.output(LevelDebug, fmt.Sprint(v...), nil)
 }
 
 // Debugf log
@@ -125,8 +414,7 @@ func (logger Logger) Infof(format string, v ...interface{}) {
 
 // Warn log
 func (logger Logger) Warn(v ...interface{}) {
-	c := color.YellowString(fmt.Sprint(v...))
-	logger.logln(c)
+	logger.output(LevelWarn, fmt.Sprint(v...), color.YellowString)
 }
 
 // Warnf log
@@ -136,8 +424,7 @@ func (logger Logger) Warnf(format string, v ...interface{}) {
 
 // Error log
 func (logger Logger) Error(v ...interface{}) {
-	c := color.RedString(fmt.Sprint(v...))
-	logger.logln(c)
+	logger.output(LevelError, fmt.Sprint(v...), color.RedString)
 }
 
 // Errorf log
@@ -147,8 +434,7 @@ func (logger Logger) Errorf(format string, v ...interface{}) {
 
 // Fatal log
 func (logger Logger) Fatal(v ...interface{}) {
-	c := color.MagentaString(fmt.Sprint(v...))
-	logger.logln(c)
+	logger.output(LevelFatal, fmt.Sprint(v...), color.MagentaString)
 	os.Exit(1)
 }
 
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestRunLogger(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "runs", "1.log")
+
+	runLogger, err := NewRunLogger(logPath, "foo", 1)
+	assert.NoError(t, err)
+	ctx := WithRun(context.Background(), runLogger)
+
+	// the other run at the same time
+	otherPath := filepath.Join(t.TempDir(), "runs", "3.log")
+	otherLogger, err := NewRunLogger(otherPath, "bar", 3)
+	assert.NoError(t, err)
+	otherCtx := WithRun(context.Background(), otherLogger)
+
+	runLogger.Tag("Model: foo").Info("run started")
+	TagContext(ctx, "Storage").Info("upload file")
+	TagContext(otherCtx, "Storage").Info("upload other file")
+	Tag("Config").Info("config is reloaded")
+	Captured(ctx).Write([]byte("stderr of the command\n"))
+	Captured(context.Background()).Write([]byte("stderr of no run\n"))
+	assert.NoError(t, runLogger.Close())
+	assert.NoError(t, otherLogger.Close())
+
+	Info("after the run")
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.True(t, strings.HasSuffix(lines[0], "[Model: foo] run started"))
+	assert.True(t, strings.HasSuffix(lines[1], "[Storage] upload file"))
+	assert.Equal(t, "stderr of the command", lines[2])
+
+	data, err = os.ReadFile(otherPath)
+	assert.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 1, len(lines))
+	assert.True(t, strings.HasSuffix(lines[0], "[Storage] upload other file"))
+}
+
+func TestJSONFormat(t *testing.T) {
+	assert.NoError(t, SetOptions(Options{Format: FormatJSON, Level: LevelWarn}))
+	defer SetOptions(Options{})
+
+	logPath := filepath.Join(t.TempDir(), "runs", "2.log")
+	runLogger, err := NewRunLogger(logPath, "foo", 2)
+	assert.NoError(t, err)
+
+	ctx := WithRun(context.Background(), runLogger)
+
+	SetStage(2, "storage")
+	runLogger.Tag("Model: foo").Info("filtered by level")
+	runLogger.Tag("Model: foo").Warnf("upload %s failed", "foo.tar.gz")
+	TagContext(ctx, "Storage").Error("connection reset")
+
+	// the other run at the same time doesn't change the fields
+	otherLogger, err := NewRunLogger(filepath.Join(t.TempDir(), "runs", "4.log"), "bar", 4)
+	assert.NoError(t, err)
+	SetStage(4, "database")
+	TagContext(ctx, "Storage").Error("upload failed")
+	assert.NoError(t, otherLogger.Close())
+	assert.NoError(t, runLogger.Close())
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 3, len(lines))
+
+	var entry jsonEntry
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, LevelWarn, entry.Level)
+	assert.Equal(t, "Model: foo", entry.Tag)
+	assert.Equal(t, "foo", entry.Model)
+	assert.Equal(t, uint64(2), entry.RunID)
+	assert.Equal(t, "storage", entry.Stage)
+	assert.Equal(t, "upload foo.tar.gz failed", entry.Message)
+	_, err = time.Parse(time.RFC3339, entry.Time)
+	assert.NoError(t, err)
+
+	// the log of the other packages by the context of the run
+	entry = jsonEntry{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, jsonEntry{Time: entry.Time, Level: LevelError, Tag: "Storage", Model: "foo", RunID: 2, Stage: "storage", Message: "connection reset"}, entry)
+
+	entry = jsonEntry{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &entry))
+	assert.Equal(t, jsonEntry{Time: entry.Time, Level: LevelError, Tag: "Storage", Model: "foo", RunID: 2, Stage: "storage", Message: "upload failed"}, entry)
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{}.Validate())
+	assert.NoError(t, Options{Format: FormatText, Level: LevelDebug}.Validate())
+	assert.EqualError(t, Options{Format: "xml"}.Validate(), `invalid log format "xml", must be text or json`)
+	assert.EqualError(t, Options{Level: "fatal"}.Validate(), `invalid log level "fatal", must be debug, info, warn or error`)
+	assert.EqualError(t, SetOptions(Options{Level: "trace"}), `invalid log level "trace", must be debug, info, warn or error`)
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Redacted is the text of the masked secrets
+const Redacted = "******"
+
+// minSecretLength of the secrets to mask, the shorter ones would mask the common words in the logs
+const minSecretLength = 4
+
+// redactor replace the secrets with Redacted, nil if there is no secret
+var redactor atomic.Pointer[strings.Replacer]
+
+// maxSecrets is the max number of the secrets added without a run, the oldest ones are dropped
+const maxSecrets = 64
+
+var (
+	secretsMu sync.Mutex
+	// configSecrets are set by SetSecrets
+	configSecrets []string
+	// runSecrets are added by AddSecrets with the context of a run, they are dropped when the run is closed
+	runSecrets = map[*RunLogger][]string{}
+	// addedSecrets are added by AddSecrets without a run, at most maxSecrets of them are kept
+	addedSecrets []string
+)
+
+// SetSecrets replace the secrets to mask in the logs, e.g. the passwords in the config.
+// The values shorter than 4 characters are ignored.
+func SetSecrets(secrets []string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	configSecrets = secrets
+	storeRedactor()
+}
+
+// AddSecrets add the secrets to mask in the logs, e.g. the secrets resolved when a model is performed.
+// They are kept until the run of ctx is closed, the last 64 of them are kept if ctx is not of a run.
+func AddSecrets(ctx context.Context, secrets []string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	run := runOf(ctx)
+	list := addedSecrets
+	if run != nil {
+		list = runSecrets[run]
+	}
+
+	changed := false
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength && !slices.Contains(list, secret) {
+			list = append(list, secret)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if run != nil {
+		runSecrets[run] = list
+	} else {
+		if len(list) > maxSecrets {
+			list = slices.Clone(list[len(list)-maxSecrets:])
+		}
+		addedSecrets = list
+	}
+	storeRedactor()
+}
+
+// removeSecrets drop the secrets added with the context of run
+func removeSecrets(run *RunLogger) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	if _, ok := runSecrets[run]; !ok {
+		return
+	}
+	delete(runSecrets, run)
+	storeRedactor()
+}
+
+// storeRedactor build the redactor of the secrets, secretsMu must be held
+func storeRedactor() {
+	values := []string{}
+	seen := map[string]bool{}
+	add := func(secrets []string) {
+		for _, secret := range secrets {
+			if len(secret) < minSecretLength || seen[secret] {
+				continue
+			}
+			seen[secret] = true
+			values = append(values, secret)
+		}
+	}
+	add(configSecrets)
+	add(addedSecrets)
+	for _, secrets := range runSecrets {
+		add(secrets)
+	}
+
+	if len(values) == 0 {
+		redactor.Store(nil)
+		return
+	}
+
+	// longer first, so the secret contains another one is masked as a whole
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	pairs := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		pairs = append(pairs, value, Redacted)
+	}
+	redactor.Store(strings.NewReplacer(pairs...))
+}
+
+// Redact mask the secrets in s
+func Redact(s string) string {
+	r := redactor.Load()
+	if r == nil {
+		return s
+	}
+
+	return r.Replace(s)
+}
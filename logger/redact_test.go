@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestRedact(t *testing.T) {
+	defer SetSecrets(nil)
+
+	assert.Equal(t, "password is pass1", Redact("password is pass1"))
+
+	SetSecrets([]string{"pass1", "pass1-long", "abc", "pass1"})
+	assert.Equal(t, "password is ******, ****** and abc", Redact("password is pass1, pass1-long and abc"))
+
+	logPath := filepath.Join(t.TempDir(), "runs", "3.log")
+	runLogger, err := NewRunLogger(logPath, "foo", 3)
+	assert.NoError(t, err)
+	ctx := WithRun(context.Background(), runLogger)
+	TagContext(ctx, "MySQL").Infof("mysqldump -ppass1")
+	Captured(ctx).Write([]byte("error: pass1-long\n"))
+	assert.NoError(t, runLogger.Close())
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "pass1"))
+	assert.True(t, strings.Contains(string(data), "mysqldump -p******"))
+
+	SetSecrets(nil)
+	assert.Equal(t, "pass1", Redact("pass1"))
+}
+
+func TestAddSecrets(t *testing.T) {
+	defer func() {
+		secretsMu.Lock()
+		addedSecrets = nil
+		secretsMu.Unlock()
+		SetSecrets(nil)
+	}()
+
+	AddSecrets(context.Background(), []string{"resolved-pass", "abc"})
+	AddSecrets(context.Background(), []string{"resolved-pass"})
+	assert.Equal(t, "****** and abc", Redact("resolved-pass and abc"))
+
+	// the added secrets are kept when the config is reloaded
+	SetSecrets([]string{"config-pass"})
+	assert.Equal(t, "****** and ******", Redact("resolved-pass and config-pass"))
+	assert.Equal(t, 1, len(addedSecrets))
+
+	// only the last ones are kept without a run
+	for i := 0; i < maxSecrets; i++ {
+		AddSecrets(context.Background(), []string{fmt.Sprintf("resolved-pass-%d", i)})
+	}
+	assert.Equal(t, maxSecrets, len(addedSecrets))
+	assert.Equal(t, "resolved-pass and ******", Redact("resolved-pass and resolved-pass-1"))
+}
+
+func TestAddSecrets_run(t *testing.T) {
+	defer SetSecrets(nil)
+
+	run, err := NewRunLogger(filepath.Join(t.TempDir(), "1.log"), "test", 1)
+	assert.NoError(t, err)
+	ctx := WithRun(context.Background(), run)
+
+	AddSecrets(ctx, []string{"run-pass"})
+	assert.Equal(t, "******", Redact("run-pass"))
+
+	// the secrets of the run are dropped when it is closed
+	assert.NoError(t, run.Close())
+	assert.Equal(t, "run-pass", Redact("run-pass"))
+	assert.Equal(t, 0, len(runSecrets))
+}
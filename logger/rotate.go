@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateTimeFormat is the suffix of the rotated log files, e.g. gobackup.log.20221204-070925.000
+const rotateTimeFormat = "20060102-150405.000"
+
+// rotateWriter write into the log file, the file is rotated when it is larger than maxSize,
+// and the rotated files beyond maxBackups or older than maxAge are removed.
+type rotateWriter struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	size       int64
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+}
+
+func newRotateWriter(path string, opts Options) (*rotateWriter, error) {
+	w := &rotateWriter{path: path}
+	w.setOptions(opts)
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotateWriter) setOptions(opts Options) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.maxSize = opts.MaxSize
+	w.maxAge = opts.MaxAge
+	w.maxBackups = opts.MaxBackups
+}
+
+func (w *rotateWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotateWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(b)) > w.maxSize {
+		// keep writing into the current file when failed to rotate
+		if err := w.rotate(); err != nil {
+			os.Stderr.WriteString("rotate log file failed: " + err.Error() + "\n")
+		}
+	}
+
+	n, err := w.file.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate rename the log file with the time suffix and open a new one, w.mu must be held
+func (w *rotateWriter) rotate() error {
+	if err := os.Rename(w.path, w.path+"."+time.Now().Format(rotateTimeFormat)); err != nil {
+		return err
+	}
+
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	old.Close()
+
+	w.cleanup()
+	return nil
+}
+
+// cleanup remove the rotated files beyond maxBackups or older than maxAge
+func (w *rotateWriter) cleanup() {
+	backups, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+
+	// newest first, as the suffix is the time
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	kept := 0
+	for _, backup := range backups {
+		if _, err := time.Parse(rotateTimeFormat, strings.TrimPrefix(backup, w.path+".")); err != nil {
+			continue
+		}
+
+		kept++
+		remove := w.maxBackups > 0 && kept > w.maxBackups
+		if info, err := os.Stat(backup); err == nil && w.maxAge > 0 && time.Since(info.ModTime()) > w.maxAge {
+			remove = true
+		}
+		if remove {
+			os.Remove(backup)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func TestRotateWriter(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "gobackup.log")
+	w, err := newRotateWriter(logPath, Options{MaxSize: 10, MaxBackups: 2})
+	assert.NoError(t, err)
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		_, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+		// the suffix of the rotated files is in milliseconds
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 4\n", string(data))
+
+	backups, err := filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(backups))
+	data, err = os.ReadFile(backups[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "line 3\n", string(data))
+
+	// older than max age
+	old := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(backups[0], old, old))
+	w.setOptions(Options{MaxSize: 10, MaxAge: 24 * time.Hour})
+	_, err = w.Write([]byte("line 5\n"))
+	assert.NoError(t, err)
+
+	backups, err = filepath.Glob(logPath + ".*")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(backups))
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		assert.NoError(t, err)
+		assert.False(t, strings.Contains(string(data), "line 2"))
+	}
+}
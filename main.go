@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	ossignal "os/signal"
 	"syscall"
 
 	"github.com/sevlyar/go-daemon"
@@ -11,6 +13,8 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/job"
 	"github.com/gobackup/gobackup/logger"
 	"github.com/gobackup/gobackup/model"
 	"github.com/gobackup/gobackup/scheduler"
@@ -59,6 +63,7 @@ func reloadHandler(sig os.Signal) error {
 func main() {
 	app := cli.NewApp()
 
+	config.Version = version
 	app.Version = version
 	app.Name = "gobackup"
 	app.Usage = usage
@@ -87,6 +92,142 @@ func main() {
 				return perform(modelNames)
 			},
 		},
+		{
+			Name:  "restore",
+			Usage: "Restore a backup package from storage",
+			Flags: buildFlags([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "model",
+					Aliases:  []string{"m"},
+					Usage:    "Model name of the backup",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "file",
+					Aliases:  []string{"f"},
+					Usage:    "File key of the package in storage",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    "storage",
+					Aliases: []string{"s"},
+					Usage:   "Storage name to download from, default is `default_storage`",
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Directory to unpack the package into",
+					Value:   ".",
+				},
+				&cli.BoolFlag{
+					Name:  "load-databases",
+					Usage: "Load the dumps back into the configured databases",
+				},
+			}),
+			Action: func(ctx *cli.Context) error {
+				err := initApplication()
+				if err != nil {
+					return err
+				}
+
+				m := model.GetModelByName(ctx.String("model"))
+				if m == nil {
+					return fmt.Errorf("model %s not found in %s", ctx.String("model"), viper.ConfigFileUsed())
+				}
+
+				signalCtx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				return m.Restore(signalCtx, model.RestoreOptions{
+					FileKey:       ctx.String("file"),
+					Storage:       ctx.String("storage"),
+					OutputPath:    ctx.String("output"),
+					LoadDatabases: ctx.Bool("load-databases"),
+				})
+			},
+		},
+		{
+			Name:  "verify",
+			Usage: "Verify the backup packages in storage",
+			Flags: buildFlags([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "model",
+					Aliases:  []string{"m"},
+					Usage:    "Model name of the backup",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    "storage",
+					Aliases: []string{"s"},
+					Usage:   "Storage name to verify, default is `default_storage`",
+				},
+				&cli.StringFlag{
+					Name:    "file",
+					Aliases: []string{"f"},
+					Usage:   "File key of the package to verify, default is all the packages",
+				},
+				&cli.BoolFlag{
+					Name:  "deep",
+					Usage: "Test-decrypt the package and list the archive contents",
+				},
+			}),
+			Action: func(ctx *cli.Context) error {
+				err := initApplication()
+				if err != nil {
+					return err
+				}
+
+				m := model.GetModelByName(ctx.String("model"))
+				if m == nil {
+					return fmt.Errorf("model %s not found in %s", ctx.String("model"), viper.ConfigFileUsed())
+				}
+
+				signalCtx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				_, err = m.Verify(signalCtx, model.VerifyOptions{
+					Storage: ctx.String("storage"),
+					FileKey: ctx.String("file"),
+					Deep:    ctx.Bool("deep"),
+				})
+				return err
+			},
+		},
+		{
+			Name:  "upload",
+			Usage: "Upload the packages kept in the spool by `keep_failed_artifacts` again",
+			Flags: buildFlags([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "model",
+					Aliases:  []string{"m"},
+					Usage:    "Model name of the backup",
+					Required: true,
+				},
+				&cli.Uint64Flag{
+					Name:  "spool",
+					Usage: "Spool id to upload, default is all the packages in the spool of the model",
+				},
+			}),
+			Action: func(ctx *cli.Context) error {
+				err := initApplication()
+				if err != nil {
+					return err
+				}
+
+				m := model.GetModelByName(ctx.String("model"))
+				if m == nil {
+					return fmt.Errorf("model %s not found in %s", ctx.String("model"), viper.ConfigFileUsed())
+				}
+
+				signalCtx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				if ctx.IsSet("spool") {
+					return m.Upload(signalCtx, ctx.Uint64("spool"))
+				}
+				return m.UploadSpool(signalCtx)
+			},
+		},
 		{
 			Name:  "start",
 			Usage: "Start as daemon",
@@ -162,8 +303,21 @@ func initApplication() error {
 func perform(modelNames []string) error {
 	var models []*model.Model
 	if len(modelNames) == 0 {
-		// perform all
-		models = model.GetModels()
+		// perform all, the models in `depends_on` or `on_success_run` are performed in the chains of the others
+		chained := map[string]bool{}
+		for _, m := range config.Models {
+			for _, name := range m.DependsOn {
+				chained[name] = true
+			}
+			for _, name := range m.OnSuccessRun {
+				chained[name] = true
+			}
+		}
+		for _, m := range model.GetModels() {
+			if !chained[m.Config.Name] {
+				models = append(models, m)
+			}
+		}
 	} else {
 		for _, name := range modelNames {
 			if m := model.GetModelByName(name); m == nil {
@@ -174,9 +328,36 @@ func perform(modelNames []string) error {
 		}
 	}
 
+	// stop the running commands on Ctrl-C, they are in their own process groups
+	ctx, stop := ossignal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// perform in the job runner, so the models are chained by `depends_on` and `on_success_run`
+	job.SetConcurrency(config.MaxConcurrentJobs)
+	var jobs []job.Job
 	for _, m := range models {
-		if err := m.Perform(); err != nil {
-			logger.Tag(fmt.Sprintf("Model %s", m.Config.Name)).Error(err)
+		m.Trigger = history.TriggerCLI
+		// the model in the chain of a queued model is coalesced into it
+		if j, queued := job.Enqueue(*m); queued {
+			jobs = append(jobs, j)
+		}
+	}
+
+	for _, j := range jobs {
+		select {
+		case <-j.Done():
+		case <-ctx.Done():
+			for _, j := range jobs {
+				job.Cancel(j.ID)
+			}
+			for _, j := range jobs {
+				<-j.Done()
+			}
+			return ctx.Err()
+		}
+
+		if j, err := job.Get(j.ID); err == nil && len(j.Error) > 0 {
+			logger.Tag(fmt.Sprintf("Model %s", j.Model)).Error(j.Error)
 		}
 	}
 
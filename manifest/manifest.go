@@ -0,0 +1,242 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/encryptor"
+	"github.com/gobackup/gobackup/logger"
+)
+
+// Ext of the manifest file, it is uploaded next to the package
+//
+// 2022.12.04.07.09.25.tar.xz -> 2022.12.04.07.09.25.tar.xz.manifest.json
+// 2022.12.04.07.09.47/ -> 2022.12.04.07.09.47.manifest.json
+const Ext = ".manifest.json"
+
+// Manifest describes a backup package, so that it can be restored or verified from any host
+type Manifest struct {
+	Model      string     `json:"model"`
+	Version    string     `json:"version"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	Databases  []Database `json:"databases,omitempty"`
+	Compressor Compressor `json:"compressor"`
+	Encryptor  string     `json:"encryptor,omitempty"`
+	// Package is the file key of the package, it is a directory when the package was split
+	Package string `json:"package"`
+	// Chunks are the file keys of split chunks, empty when the package is a single file
+	Chunks    []string `json:"chunks,omitempty"`
+	Files     []File   `json:"files"`
+	TotalSize int64    `json:"total_size"`
+}
+
+// Database dump info
+type Database struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Files []string `json:"files,omitempty"`
+}
+
+// Compressor info
+type Compressor struct {
+	Type string `json:"type"`
+	Ext  string `json:"ext"`
+}
+
+// File in the package with checksum
+type File struct {
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Path return the manifest path of the archivePath
+func Path(archivePath string) string {
+	return strings.TrimSuffix(archivePath, "/") + Ext
+}
+
+// New create the manifest of the package with the model info and database dump files,
+// the package files are added by the caller.
+func New(model config.ModelConfig, packageKey string, startedAt time.Time) (*Manifest, error) {
+	m := &Manifest{
+		Model:     model.Name,
+		Version:   config.Version,
+		StartedAt: startedAt,
+		Encryptor: model.EncryptWith.Type,
+		Package:   packageKey,
+	}
+
+	ext := strings.TrimSuffix(model.Viper.GetString("Ext"), encryptor.Ext(model))
+	m.Compressor = Compressor{
+		Type: model.CompressWith.Type,
+		Ext:  ext,
+	}
+	if len(m.Compressor.Type) == 0 {
+		m.Compressor.Type = "tar"
+	}
+
+	for name, dbConfig := range model.Databases {
+		files, err := listFiles(filepath.Join(model.DumpPath, dbConfig.Type, name))
+		if err != nil {
+			return nil, err
+		}
+		m.Databases = append(m.Databases, Database{
+			Name:  name,
+			Type:  dbConfig.Type,
+			Files: files,
+		})
+	}
+	sort.Slice(m.Databases, func(i, j int) bool {
+		return m.Databases[i].Name < m.Databases[j].Name
+	})
+
+	return m, nil
+}
+
+// Add file into the manifest
+func (m *Manifest) Add(file File) {
+	m.Files = append(m.Files, file)
+	m.TotalSize += file.Size
+}
+
+// JSON encode the manifest with indent
+func (m *Manifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Build the manifest of archivePath (single file or directory containing split files)
+func Build(model config.ModelConfig, archivePath string, startedAt time.Time) (*Manifest, error) {
+	m, err := New(model, filepath.Base(archivePath), startedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{m.Package}
+	if fi.IsDir() {
+		chunks, err := listFiles(archivePath)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = keys[:0]
+		for _, chunk := range chunks {
+			keys = append(keys, filepath.Join(m.Package, chunk))
+		}
+		m.Chunks = keys
+	}
+
+	for _, key := range keys {
+		file, err := checksum(filepath.Join(filepath.Dir(archivePath), key))
+		if err != nil {
+			return nil, err
+		}
+		file.Key = key
+
+		m.Add(file)
+	}
+
+	m.FinishedAt = time.Now()
+
+	return m, nil
+}
+
+// Write build the manifest of archivePath and save it next to archivePath, return the manifest path
+func Write(model config.ModelConfig, archivePath string, startedAt time.Time) (string, error) {
+	logger := logger.Tag("Manifest")
+
+	m, err := Build(model, archivePath, startedAt)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := m.JSON()
+	if err != nil {
+		return "", err
+	}
+
+	manifestPath := Path(archivePath)
+	if err := os.WriteFile(manifestPath, data, 0640); err != nil {
+		return "", err
+	}
+
+	logger.Info("->", manifestPath)
+	return manifestPath, nil
+}
+
+// Parse manifest from reader
+func Parse(r io.Reader) (*Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Checksum return the SHA-256 of the file by key, and whether the key exists in the manifest
+func (m *Manifest) Checksum(key string) (string, bool) {
+	for _, file := range m.Files {
+		if file.Key == key {
+			return file.SHA256, true
+		}
+	}
+
+	return "", false
+}
+
+func checksum(filePath string) (File, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return File{}, err
+	}
+
+	return File{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// listFiles return all files under dir with relative path in sorted
+func listFiles(dir string) (files []string, err error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+
+	sort.Strings(files)
+	return
+}
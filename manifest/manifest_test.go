@@ -0,0 +1,101 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newModel(t *testing.T) config.ModelConfig {
+	tempPath := t.TempDir()
+
+	v := viper.New()
+	v.Set("Ext", ".tar.gz.enc")
+
+	model := config.ModelConfig{
+		Name:         "foo",
+		TempPath:     tempPath,
+		DumpPath:     filepath.Join(tempPath, "foo"),
+		Viper:        v,
+		CompressWith: config.SubConfig{Type: "tgz"},
+		EncryptWith:  config.SubConfig{Type: "openssl"},
+		Databases: map[string]config.SubConfig{
+			"db1": {Name: "db1", Type: "postgresql"},
+		},
+	}
+
+	dumpPath := filepath.Join(model.DumpPath, "postgresql", "db1")
+	assert.NoError(t, os.MkdirAll(dumpPath, 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(dumpPath, "db1.sql"), []byte("select 1;"), 0640))
+
+	return model
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "/tmp/2022.12.04.07.09.25.tar.xz.manifest.json", Path("/tmp/2022.12.04.07.09.25.tar.xz"))
+	assert.Equal(t, "/tmp/2022.12.04.07.09.47.manifest.json", Path("/tmp/2022.12.04.07.09.47/"))
+}
+
+func TestBuild(t *testing.T) {
+	model := newModel(t)
+
+	archivePath := filepath.Join(model.TempPath, "2022.12.04.07.09.25.tar.gz.enc")
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello"), 0640))
+
+	startedAt := time.Now()
+	m, err := Build(model, archivePath, startedAt)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "foo", m.Model)
+	assert.Equal(t, startedAt, m.StartedAt)
+	assert.Equal(t, "tgz", m.Compressor.Type)
+	assert.Equal(t, ".tar.gz", m.Compressor.Ext)
+	assert.Equal(t, "openssl", m.Encryptor)
+	assert.Equal(t, "2022.12.04.07.09.25.tar.gz.enc", m.Package)
+	assert.Equal(t, 0, len(m.Chunks))
+	assert.Equal(t, []Database{{Name: "db1", Type: "postgresql", Files: []string{"db1.sql"}}}, m.Databases)
+	assert.Equal(t, []File{{
+		Key:    "2022.12.04.07.09.25.tar.gz.enc",
+		Size:   5,
+		SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}}, m.Files)
+	assert.Equal(t, int64(5), m.TotalSize)
+}
+
+func TestBuildChunks(t *testing.T) {
+	model := newModel(t)
+
+	archivePath := filepath.Join(model.TempPath, "2022.12.04.07.09.47")
+	assert.NoError(t, os.MkdirAll(archivePath, 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(archivePath, "2022.12.04.07.09.47.tar.gz.enc-000"), []byte("hel"), 0640))
+	assert.NoError(t, os.WriteFile(filepath.Join(archivePath, "2022.12.04.07.09.47.tar.gz.enc-001"), []byte("lo"), 0640))
+
+	manifestPath, err := Write(model, archivePath, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, archivePath+Ext, manifestPath)
+
+	data, err := os.ReadFile(manifestPath)
+	assert.NoError(t, err)
+
+	m, err := Parse(strings.NewReader(string(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"2022.12.04.07.09.47/2022.12.04.07.09.47.tar.gz.enc-000",
+		"2022.12.04.07.09.47/2022.12.04.07.09.47.tar.gz.enc-001",
+	}, m.Chunks)
+	assert.Equal(t, 2, len(m.Files))
+	assert.Equal(t, int64(5), m.TotalSize)
+
+	sum, ok := m.Checksum("2022.12.04.07.09.47/2022.12.04.07.09.47.tar.gz.enc-001")
+	assert.True(t, ok)
+	assert.Equal(t, 64, len(sum))
+
+	_, ok = m.Checksum("foo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/logger"
+)
+
+const namespace = "gobackup"
+
+var (
+	runsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "runs_total",
+		Help:      "Total number of the runs by result.",
+	}, []string{"model", "result"})
+	storageUploadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_upload_bytes_total",
+		Help:      "Total bytes of the packages uploaded to the storage.",
+	}, []string{"model", "storage", "type"})
+	cyclerDeletions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cycler_deletions_total",
+		Help:      "Total number of the packages removed by the cycler.",
+	}, []string{"model", "storage"})
+
+	lastSuccessDesc = prometheus.NewDesc(
+		namespace+"_last_success_timestamp_seconds",
+		"Unix time of the last succeeded run.",
+		[]string{"model"}, nil,
+	)
+	lastRunDurationDesc = prometheus.NewDesc(
+		namespace+"_last_run_duration_seconds",
+		"Duration of the last finished run.",
+		[]string{"model"}, nil,
+	)
+	lastArchiveSizeDesc = prometheus.NewDesc(
+		namespace+"_last_archive_size_bytes",
+		"Archive size of the last finished run.",
+		[]string{"model"}, nil,
+	)
+	storageUploadDurationDesc = prometheus.NewDesc(
+		namespace+"_storage_last_upload_duration_seconds",
+		"Upload duration of the storage in the last finished run.",
+		[]string{"model", "storage", "type"}, nil,
+	)
+
+	registry = prometheus.NewRegistry()
+)
+
+func init() {
+	registry.MustRegister(
+		runsTotal,
+		storageUploadBytes,
+		cyclerDeletions,
+		historyCollector{},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Observe the finished run, the counters are only in the process of the daemon.
+func Observe(run *history.Run) {
+	runsTotal.WithLabelValues(run.Model, run.Status).Inc()
+
+	for _, s := range run.Storages {
+		if len(s.Error) == 0 {
+			storageUploadBytes.WithLabelValues(run.Model, s.Name, s.Type).Add(float64(run.ArchiveSize))
+		}
+		if s.Removed > 0 {
+			cyclerDeletions.WithLabelValues(run.Model, s.Name).Add(float64(s.Removed))
+		}
+	}
+}
+
+// Handler serve the metrics in Prometheus text format
+func Handler() http.Handler {
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// export the runs of all the models, even there is no run yet
+		for _, m := range config.Models {
+			runsTotal.WithLabelValues(m.Name, history.StatusSuccess)
+			runsTotal.WithLabelValues(m.Name, history.StatusFailure)
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// historyCollector collect the last runs from the history when scraping,
+// so that the runs of `gobackup perform` are included, and they are kept after restart.
+type historyCollector struct{}
+
+func (historyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lastSuccessDesc
+	ch <- lastRunDurationDesc
+	ch <- lastArchiveSizeDesc
+	ch <- storageUploadDurationDesc
+}
+
+func (historyCollector) Collect(ch chan<- prometheus.Metric) {
+	logger := logger.Tag("Metrics")
+
+	for _, m := range config.Models {
+		lastSuccess, err := history.LastSuccess(m.Name)
+		if err != nil {
+			logger.Errorf("Load last success run of %s failed: %v", m.Name, err)
+			continue
+		}
+		if lastSuccess != nil {
+			ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, seconds(lastSuccess.FinishedAt.UnixMilli()), m.Name)
+		}
+
+		last, err := history.Last(m.Name)
+		if err != nil {
+			logger.Errorf("Load last run of %s failed: %v", m.Name, err)
+			continue
+		}
+		if last == nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(lastRunDurationDesc, prometheus.GaugeValue, seconds(last.DurationMs), m.Name)
+		ch <- prometheus.MustNewConstMetric(lastArchiveSizeDesc, prometheus.GaugeValue, float64(last.ArchiveSize), m.Name)
+		for _, s := range last.Storages {
+			ch <- prometheus.MustNewConstMetric(storageUploadDurationDesc, prometheus.GaugeValue, seconds(s.DurationMs), m.Name, s.Name, s.Type)
+		}
+	}
+}
+
+func seconds(ms int64) float64 {
+	return float64(ms) / 1000
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/longbridgeapp/assert"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+)
+
+func TestObserve(t *testing.T) {
+	run := &history.Run{
+		Model:       "foo",
+		Status:      history.StatusSuccess,
+		ArchiveSize: 1024,
+		Storages: []history.Storage{
+			{Name: "s3", Type: "s3", Removed: 2},
+			{Name: "local", Type: "local", Error: "disk full"},
+		},
+	}
+	Observe(run)
+	Observe(run)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(runsTotal.WithLabelValues("foo", "success")))
+	assert.Equal(t, float64(2048), testutil.ToFloat64(storageUploadBytes.WithLabelValues("foo", "s3", "s3")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(storageUploadBytes.WithLabelValues("foo", "local", "local")))
+	assert.Equal(t, float64(4), testutil.ToFloat64(cyclerDeletions.WithLabelValues("foo", "s3")))
+}
+
+func TestHandler(t *testing.T) {
+	modelName := fmt.Sprintf("metrics_test_%d", time.Now().UnixNano())
+
+	originalModels := config.Models
+	config.Models = []config.ModelConfig{{Name: modelName}}
+	defer func() {
+		config.Models = originalModels
+	}()
+
+	run := history.Start(modelName, history.TriggerScheduler)
+	run.ArchiveSize = 100
+	run.AddStorage("s3", "s3", 1500*time.Millisecond, 0, 1, nil)
+	run.Finish(nil)
+	history.Start(modelName, history.TriggerScheduler).Finish(fmt.Errorf("failed"))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, w.Code)
+	body, _ := io.ReadAll(w.Body)
+
+	lines := map[string]bool{}
+	for _, line := range strings.Split(string(body), "\n") {
+		lines[line] = true
+	}
+
+	assert.True(t, lines[fmt.Sprintf(`gobackup_runs_total{model="%s",result="success"} 0`, modelName)])
+	assert.True(t, lines[fmt.Sprintf(`gobackup_runs_total{model="%s",result="failure"} 0`, modelName)])
+	assert.True(t, lines[fmt.Sprintf(`gobackup_last_archive_size_bytes{model="%s"} 0`, modelName)])
+	assert.True(t, lines[fmt.Sprintf(`gobackup_last_success_timestamp_seconds{model="%s"} %g`, modelName, seconds(run.FinishedAt.UnixMilli()))])
+	assert.False(t, strings.Contains(string(body), fmt.Sprintf(`gobackup_storage_last_upload_duration_seconds{model="%s"`, modelName)))
+}
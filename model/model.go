@@ -1,8 +1,15 @@
 package model
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -12,83 +19,595 @@ import (
 	"github.com/gobackup/gobackup/database"
 	"github.com/gobackup/gobackup/encryptor"
 	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/history"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/manifest"
+	"github.com/gobackup/gobackup/metrics"
 	"github.com/gobackup/gobackup/notifier"
 	"github.com/gobackup/gobackup/splitter"
+	"github.com/gobackup/gobackup/spool"
 	"github.com/gobackup/gobackup/storage"
 )
 
 // Model class
 type Model struct {
 	Config config.ModelConfig
+	// Trigger of the run, e.g. cli, scheduler, web
+	Trigger string
 }
 
-// Perform model
-func (m Model) Perform() (err error) {
-	logger := logger.Tag(fmt.Sprintf("Model: %s", m.Config.Name))
+// tempPaths count the runs which have the temp paths in the workdir,
+// the temp workdir is only removed when none of them is running.
+var tempPaths = struct {
+	sync.Mutex
+	active int
+}{}
 
-	m.before()
+// TimeoutError is returned when the perform or a stage of it is timed out
+type TimeoutError struct {
+	// Stage in progress when timed out
+	Stage string
+	// Timeout that is expired, of the model or the stage
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s in %s stage", e.Timeout, e.Stage)
+}
+
+// newEvent of the run for the notifiers, it is called before the run is finished
+func newEvent(run *history.Run, results storage.Results, err error) notifier.Event {
+	event := notifier.Event{
+		RunID:       run.ID,
+		StartedAt:   run.StartedAt,
+		FinishedAt:  time.Now(),
+		ArchiveSize: run.ArchiveSize,
+		Storages:    make([]notifier.EventStorage, 0, len(results)),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	for _, r := range results {
+		s := notifier.EventStorage{
+			Name:       r.Name,
+			Type:       r.Type,
+			FileKey:    r.FileKey,
+			DurationMs: r.Duration.Milliseconds(),
+			Attempts:   r.Attempts,
+		}
+		if r.Err != nil {
+			s.Error = r.Err.Error()
+		}
+		event.Storages = append(event.Storages, s)
+	}
+
+	return event
+}
+
+// Perform model, it is stopped when ctx is done, e.g. the job is canceled or timed out
+func (m Model) Perform(ctx context.Context) (err error) {
+	startedAt := time.Now()
+	run := history.Start(m.Config.Name, m.Trigger)
 
+	runLogger, logErr := logger.NewRunLogger(history.LogPath(run.ID), m.Config.Name, run.ID)
+	ctx = logger.WithRun(ctx, runLogger)
+	logger := logger.TagContext(ctx, fmt.Sprintf("Model: %s", m.Config.Name))
+	if logErr != nil {
+		logger.Warnf("Capture log of run %d failed: %v", run.ID, logErr)
+	} else {
+		logger = runLogger.Tag(fmt.Sprintf("Model: %s", m.Config.Name))
+	}
+
+	// retry the packages kept by the failed runs before the scheduled run, it is not limited by the timeout
+	if m.Config.KeepFailedArtifacts && m.Trigger == history.TriggerScheduler {
+		if err := m.UploadSpool(ctx); err != nil {
+			logger.Warnf("Upload spool failed: %v", err)
+		}
+	}
+
+	if m.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Config.Timeout)
+		defer cancel()
+	}
+
+	m.before(ctx)
+
+	var results storage.Results
 	defer func() {
-		if err != nil {
+		for _, r := range results {
+			run.AddStorage(r.Name, r.Type, r.Duration, r.Removed, r.Attempts, r.Err)
+		}
+
+		event := newEvent(run, results, err)
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
 			logger.Error(err)
-			notifier.Failure(m.Config, err.Error())
+			notifier.Timeout(m.Config, event)
+		} else if err != nil {
+			logger.Error(err)
+			notifier.Failure(m.Config, event)
 		} else {
-			notifier.Success(m.Config)
+			notifier.Success(m.Config, event)
+		}
+
+		// the log is complete when the run is finished
+		if runLogger != nil {
+			runLogger.Close()
 		}
+		run.Finish(err)
+		metrics.Observe(run)
 	}()
 
+	if m.Config, err = config.ResolveSecrets(ctx, m.Config); err != nil {
+		return
+	}
+	if err = m.newTempPath(); err != nil {
+		return
+	}
 	logger.Info("WorkDir:", m.Config.DumpPath)
 
 	defer func() {
+		// the panic is recovered as the error of the run, and the temp files are cleaned up once
 		if r := recover(); r != nil {
-			m.after()
+			err = fmt.Errorf("panic: %v", r)
 		}
 
 		m.after()
 	}()
 
-	err = database.Run(m.Config)
+	err = m.runStage(ctx, run, "database", func(ctx context.Context) error {
+		return database.Run(ctx, m.Config)
+	})
 	if err != nil {
 		return
 	}
 
 	if m.Config.Archive != nil {
-		err = archive.Run(m.Config)
+		err = m.runStage(ctx, run, "archive", func(ctx context.Context) error {
+			return archive.Run(ctx, m.Config)
+		})
 		if err != nil {
 			return
 		}
 	}
 
+	if m.Config.Streaming {
+		err = m.runStage(ctx, run, "stream", func(ctx context.Context) (err error) {
+			results, err = m.performStream(ctx, startedAt, run)
+			return err
+		})
+		return
+	}
+
 	// It always to use compressor, default use tar, even not enable compress.
-	archivePath, err := compressor.Run(m.Config)
+	var archivePath string
+	err = m.runStage(ctx, run, "compressor", func(ctx context.Context) (err error) {
+		archivePath, err = compressor.Run(ctx, m.Config)
+		return err
+	})
 	if err != nil {
 		return
 	}
 
-	archivePath, err = encryptor.Run(archivePath, m.Config)
+	err = m.runStage(ctx, run, "encryptor", func(ctx context.Context) (err error) {
+		archivePath, err = encryptor.Run(ctx, archivePath, m.Config)
+		return err
+	})
 	if err != nil {
 		return
 	}
 
-	archivePath, err = splitter.Run(archivePath, m.Config)
+	err = m.runStage(ctx, run, "splitter", func(ctx context.Context) (err error) {
+		archivePath, err = splitter.Run(ctx, archivePath, m.Config)
+		return err
+	})
+	if err != nil {
+		return
+	}
+	run.ArchiveSize = packageSize(archivePath)
+
+	var manifestPath string
+	err = m.runStage(ctx, run, "storage", func(ctx context.Context) (err error) {
+		manifestPath, err = manifest.Write(m.Config, archivePath, startedAt)
+		if err != nil {
+			return err
+		}
+
+		results, err = storage.Run(ctx, m.Config, archivePath, manifestPath)
+		return err
+	})
 	if err != nil {
+		if m.Config.KeepFailedArtifacts {
+			err = m.keep(ctx, run.ID, archivePath, manifestPath, results, err)
+		}
 		return
 	}
 
-	err = storage.Run(m.Config, archivePath)
+	return nil
+}
+
+// keep the package in the spool before the temp path is removed, so it can be uploaded again
+// to the failed storages by `gobackup upload` or the next scheduled run. The returned error mentions the spool.
+func (m Model) keep(ctx context.Context, runID uint64, archivePath string, manifestPath string, results storage.Results, uploadErr error) error {
+	logger := logger.TagContext(ctx, fmt.Sprintf("Model: %s", m.Config.Name))
+
+	uploaded := map[string]bool{}
+	for _, r := range results {
+		if r.Err == nil {
+			uploaded[r.Name] = true
+		}
+	}
+
+	var storages []string
+	for name := range m.Config.Storages {
+		if !uploaded[name] {
+			storages = append(storages, name)
+		}
+	}
+	sort.Strings(storages)
+
+	pkg, err := spool.Keep(runID, m.Config.Name, archivePath, manifestPath, storages, uploadErr)
+	if err != nil {
+		logger.Errorf("Keep %s in spool failed: %v", filepath.Base(archivePath), err)
+		return uploadErr
+	}
+
+	logger.Infof("Kept %s in spool %d, run `gobackup upload --model %s --spool %d` to upload it again", pkg.Filename, pkg.ID, m.Config.Name, pkg.ID)
+	return fmt.Errorf("%w, the package is kept in spool %d", uploadErr, pkg.ID)
+}
+
+// Upload the package kept in the spool to the storages which it was failed to upload to,
+// it is removed from the spool when all of them succeeded.
+func (m Model) Upload(ctx context.Context, id uint64) error {
+	logger := logger.TagContext(ctx, fmt.Sprintf("Model: %s", m.Config.Name))
+
+	var err error
+	if m.Config, err = config.ResolveSecrets(ctx, m.Config); err != nil {
+		return err
+	}
+
+	pkg, err := spool.Get(m.Config.Name, id)
+	if err != nil {
+		return err
+	}
+
+	uploadConfig := m.Config
+	uploadConfig.Storages = map[string]config.SubConfig{}
+	for _, name := range pkg.Storages {
+		if storageConfig, ok := m.Config.Storages[name]; ok {
+			uploadConfig.Storages[name] = storageConfig
+		} else {
+			logger.Warnf("Storage %s of spool %d is not found, skipped", name, id)
+		}
+	}
+	if len(uploadConfig.Storages) == 0 {
+		return fmt.Errorf("none of the storages of spool %d is found in model %s", id, m.Config.Name)
+	}
+
+	logger.Infof("Uploading %s of spool %d to %s...", pkg.Filename, id, strings.Join(pkg.Storages, ", "))
+	results, err := storage.Run(ctx, uploadConfig, pkg.ArchivePath(), pkg.ManifestPath())
+	if s := results.String(); len(s) > 0 {
+		logger.Info(s)
+	}
+
+	pkg.Storages = []string{}
+	for _, r := range results {
+		if r.Err != nil {
+			pkg.Storages = append(pkg.Storages, r.Name)
+		}
+	}
+	if len(pkg.Storages) == 0 {
+		logger.Infof("Spool %d is uploaded, removed it", id)
+		return pkg.Remove()
+	}
+
+	pkg.Error = err.Error()
+	if saveErr := pkg.Save(); saveErr != nil {
+		logger.Errorf("Save spool %d failed: %v", id, saveErr)
+	}
+	return err
+}
+
+// UploadSpool upload all the packages in the spool of the model, in oldest first
+func (m Model) UploadSpool(ctx context.Context) error {
+	pkgs, err := spool.List(m.Config.Name)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pkg := range pkgs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := m.Upload(ctx, pkg.ID); err != nil {
+			errs = append(errs, fmt.Errorf("spool %d: %w", pkg.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runStage record the stage in run and perform it with the timeout of the stage,
+// a *TimeoutError is returned when the model or the stage is timed out.
+func (m Model) runStage(ctx context.Context, run *history.Run, stage string, perform func(ctx context.Context) error) error {
+	run.Stage(stage)
+	logger.SetStage(run.ID, stage)
+
+	stageCtx := ctx
+	if timeout := m.Config.StageTimeouts[stage]; timeout > 0 {
+		var cancel context.CancelFunc
+		stageCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := perform(stageCtx)
+	if err == nil || !errors.Is(stageCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	// the model timeout is expired when the parent is done as well
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Stage: stage, Timeout: m.Config.Timeout}
+	}
+	return &TimeoutError{Stage: stage, Timeout: m.Config.StageTimeouts[stage]}
+}
+
+// performStream pipe the dump path through compressor, encryptor and splitter into storages,
+// no temp file is written after the databases are dumped.
+func (m Model) performStream(ctx context.Context, startedAt time.Time, run *history.Run) (results storage.Results, err error) {
+	archive, err := compressor.NewStream(ctx, m.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := encryptor.NewStream(ctx, m.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := archive.Filename()
+	packageKey := splitter.Key(filename, m.Config)
+
+	st, err := storage.OpenStream(ctx, m.Config)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	w, err := splitter.NewWriter(filename, m.Config, st.Create)
+	if err != nil {
+		return nil, err
+	}
+
+	ew, err := enc.Writer(ctx, w)
+	if err != nil {
+		st.Abort(err)
+		w.Close()
+		return st.Results(), err
+	}
+
+	// Abort before closing the writers, so the uploads in progress will not be stored
+	if err = archive.Archive(ctx, ew); err != nil {
+		st.Abort(err)
+		ew.Close()
+		w.Close()
+		return st.Results(), err
+	}
+	if err = ew.Close(); err != nil {
+		st.Abort(err)
+		w.Close()
+		return st.Results(), err
+	}
+	if err = w.Close(); err != nil {
+		return st.Results(), err
+	}
+
+	pkg := storage.Package{FileKey: packageKey}
+	if m.Config.Splitter != nil {
+		for _, file := range st.Files {
+			pkg.FileKeys = append(pkg.FileKeys, file.Key)
+		}
+	}
+
+	mf, err := manifest.New(m.Config, packageKey, startedAt)
+	if err != nil {
+		return nil, err
+	}
+	mf.Chunks = pkg.FileKeys
+	for _, file := range st.Files {
+		mf.Add(file)
+		run.ArchiveSize += file.Size
+	}
+	mf.FinishedAt = time.Now()
+
+	err = st.Commit(pkg, mf)
+	return st.Results(), err
+}
+
+// packageSize return the size of the package file, or the total size of the chunks in the directory
+func packageSize(archivePath string) (size int64) {
+	filepath.Walk(archivePath, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size
+}
+
+// RestoreOptions for Model.Restore
+type RestoreOptions struct {
+	// FileKey of the package in storage
+	FileKey string
+	// Storage name to download from, default is `default_storage`
+	Storage string
+	// OutputPath to unpack the package into
+	OutputPath string
+	// LoadDatabases restore the dumps back into the databases
+	LoadDatabases bool
+}
+
+// Restore download a package and reverse the Perform pipeline:
+// fetch, join chunks, decrypt, extract, and load the databases optionally.
+// It is stopped when ctx is done, e.g. by Ctrl-C.
+func (m Model) Restore(ctx context.Context, opts RestoreOptions) (err error) {
+	logger := logger.TagContext(ctx, fmt.Sprintf("Model: %s", m.Config.Name))
+
+	if len(opts.FileKey) == 0 {
+		return fmt.Errorf("file is required")
+	}
+
+	if m.Config, err = config.ResolveSecrets(ctx, m.Config); err != nil {
+		return err
+	}
+	if err := m.newTempPath(); err != nil {
+		return err
+	}
+	defer m.cleanupTemp()
+
+	archivePath, err := storage.Fetch(m.Config, opts.Storage, opts.FileKey, m.Config.TempPath)
+	if err != nil {
+		return err
+	}
+
+	archivePath, err = splitter.Join(archivePath)
+	if err != nil {
+		return err
+	}
+
+	archivePath, err = encryptor.Decrypt(ctx, archivePath, m.Config)
+	if err != nil {
+		return err
+	}
+
+	outputPath := helper.AbsolutePath(opts.OutputPath)
+	if err := compressor.Extract(ctx, m.Config, archivePath, outputPath); err != nil {
+		return err
+	}
+
+	if opts.LoadDatabases {
+		restoreConfig := m.Config
+		restoreConfig.DumpPath = filepath.Join(outputPath, m.Config.Name)
+		if err := database.Restore(ctx, restoreConfig); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Restored to:", outputPath)
+	return nil
+}
+
+// VerifyOptions for Model.Verify
+type VerifyOptions struct {
+	// Storage name to verify, default is `default_storage`
+	Storage string
+	// FileKey of the package to verify, default is all the packages in the cycler
+	FileKey string
+	// Deep verify will test-decrypt the package and list the archive contents
+	Deep bool
+}
+
+// Verify the packages in storage are still readable and match their checksums,
+// the result will be sent to the notifiers. It is stopped when ctx is done.
+func (m Model) Verify(ctx context.Context, opts VerifyOptions) (results []storage.VerifyResult, err error) {
+	logger := logger.TagContext(ctx, fmt.Sprintf("Model: %s", m.Config.Name))
+
+	defer func() {
+		if err != nil {
+			logger.Error(err)
+			notifier.VerifyFailure(m.Config, err.Error())
+		} else {
+			notifier.VerifySuccess(m.Config, fmt.Sprintf("%d packages verified.", len(results)))
+		}
+	}()
+
+	if m.Config, err = config.ResolveSecrets(ctx, m.Config); err != nil {
+		return
+	}
+	results, err = storage.Verify(m.Config, opts.Storage, opts.FileKey)
 	if err != nil {
 		return
 	}
 
+	if opts.Deep {
+		if err = m.newTempPath(); err != nil {
+			return
+		}
+		defer m.cleanupTemp()
+
+		for i := range results {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			if !results[i].OK() {
+				continue
+			}
+			if deepErr := m.verifyArchive(ctx, opts.Storage, results[i].FileKey); deepErr != nil {
+				results[i].Error = deepErr.Error()
+			}
+		}
+	}
+
+	var errs []string
+	for _, result := range results {
+		if !result.OK() {
+			errs = append(errs, result.Err().Error())
+		}
+	}
+	if len(errs) > 0 {
+		err = fmt.Errorf("%d of %d packages failed to verify:\n%s", len(errs), len(results), strings.Join(errs, "\n"))
+	}
+
+	return
+}
+
+// verifyArchive fetch the package, test-decrypt it and list the archive contents
+func (m Model) verifyArchive(ctx context.Context, storageName string, fileKey string) error {
+	logger := logger.TagContext(ctx, "Verify")
+
+	tempDir, err := os.MkdirTemp(m.Config.TempPath, "verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	archivePath, err := storage.Fetch(m.Config, storageName, fileKey, tempDir)
+	if err != nil {
+		return err
+	}
+
+	archivePath, err = splitter.Join(archivePath)
+	if err != nil {
+		return err
+	}
+
+	archivePath, err = encryptor.Decrypt(ctx, archivePath, m.Config)
+	if err != nil {
+		return err
+	}
+
+	entries, err := compressor.List(ctx, m.Config, archivePath)
+	if err != nil {
+		return fmt.Errorf("list archive failed: %v", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("archive is empty")
+	}
+
+	logger.Infof("%s contains %d entries", fileKey, len(entries))
 	return nil
 }
 
-func (m Model) before() {
+func (m Model) before(ctx context.Context) {
 	// Execute before_script
 	if len(m.Config.BeforeScript) > 0 {
 		logger.Info("Executing before_script...")
-		_, err := helper.ExecScriptWithStdio(m.Config.BeforeScript, true)
+		_, err := helper.ExecScriptWithStdioContext(ctx, m.Config.BeforeScript, true)
 		if err != nil {
 			logger.Error(err)
 		}
@@ -97,16 +616,7 @@ func (m Model) before() {
 
 // Cleanup model temp files
 func (m Model) after() {
-	logger := logger.Tag("Model")
-
-	tempDir := m.Config.TempPath
-	if viper.GetBool("useTempWorkDir") {
-		tempDir = viper.GetString("workdir")
-	}
-	logger.Infof("Cleanup temp: %s/", tempDir)
-	if err := os.RemoveAll(tempDir); err != nil {
-		logger.Errorf("Cleanup temp dir %s error: %v", tempDir, err)
-	}
+	m.cleanupTemp()
 
 	// Execute after_script
 	if len(m.Config.AfterScript) > 0 {
@@ -118,6 +628,52 @@ func (m Model) after() {
 	}
 }
 
+// newTempPath create a new temp path in the workdir for the run,
+// so the runs of the same model at the same time don't share the dump and archive files.
+func (m *Model) newTempPath() error {
+	tempPaths.Lock()
+	defer tempPaths.Unlock()
+
+	workdir := filepath.Dir(m.Config.TempPath)
+	if err := helper.MkdirP(workdir); err != nil {
+		return err
+	}
+
+	tempPath, err := os.MkdirTemp(workdir, fmt.Sprintf("%d-", time.Now().UnixNano()))
+	if err != nil {
+		return err
+	}
+
+	m.Config.TempPath = tempPath
+	m.Config.DumpPath = filepath.Join(tempPath, m.Config.Name)
+	tempPaths.active++
+
+	return nil
+}
+
+// cleanupTemp remove the temp path of the run, and the temp workdir if no other run is using it
+func (m Model) cleanupTemp() {
+	logger := logger.Tag("Model")
+
+	tempPaths.Lock()
+	defer tempPaths.Unlock()
+
+	logger.Infof("Cleanup temp: %s/", m.Config.TempPath)
+	if err := os.RemoveAll(m.Config.TempPath); err != nil {
+		logger.Errorf("Cleanup temp dir %s error: %v", m.Config.TempPath, err)
+	}
+
+	tempPaths.active--
+	if tempPaths.active > 0 || !viper.GetBool("useTempWorkDir") {
+		return
+	}
+
+	workdir := filepath.Dir(m.Config.TempPath)
+	if err := os.RemoveAll(workdir); err != nil {
+		logger.Errorf("Cleanup temp dir %s error: %v", workdir, err)
+	}
+}
+
 // GetModelByName get model by name
 func GetModelByName(name string) *Model {
 	modelConfig := config.GetModelConfigByName(name)
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/history"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestRunStage(t *testing.T) {
+	m := Model{Config: config.ModelConfig{
+		Timeout:       time.Hour,
+		StageTimeouts: map[string]time.Duration{"database": 10 * time.Millisecond},
+	}}
+	run := &history.Run{}
+
+	wait := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	err := m.runStage(context.Background(), run, "database", wait)
+	assert.Equal(t, &TimeoutError{Stage: "database", Timeout: 10 * time.Millisecond}, err)
+	assert.Equal(t, "timed out after 10ms in database stage", err.Error())
+
+	// the model timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = m.runStage(ctx, run, "storage", wait)
+	assert.Equal(t, &TimeoutError{Stage: "storage", Timeout: time.Hour}, err)
+
+	// canceled is not a timeout
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = m.runStage(ctx, run, "storage", wait)
+	assert.Equal(t, context.Canceled, err)
+
+	err = m.runStage(context.Background(), run, "archive", func(ctx context.Context) error {
+		return fmt.Errorf("archive failed")
+	})
+	assert.EqualError(t, err, "archive failed")
+	assert.Equal(t, []string{"database", "storage", "storage", "archive"}, stageNames(run))
+}
+
+func stageNames(run *history.Run) (names []string) {
+	for _, stage := range run.Stages {
+		names = append(names, stage.Name)
+	}
+	return
+}
+
+func TestTempPath(t *testing.T) {
+	workdir := filepath.Join(t.TempDir(), "workdir")
+	viper.Set("useTempWorkDir", true)
+	t.Cleanup(func() {
+		viper.Set("useTempWorkDir", false)
+	})
+
+	m := Model{Config: config.ModelConfig{Name: "test", TempPath: filepath.Join(workdir, "123")}}
+	run1, run2 := m, m
+	assert.NoError(t, run1.newTempPath())
+	assert.NoError(t, run2.newTempPath())
+
+	assert.NotEqual(t, run1.Config.TempPath, run2.Config.TempPath)
+	assert.Equal(t, workdir, filepath.Dir(run1.Config.TempPath))
+	assert.Equal(t, filepath.Join(run1.Config.TempPath, "test"), run1.Config.DumpPath)
+	assert.True(t, helper.IsExistsPath(run1.Config.TempPath))
+
+	// the temp path of the other run is kept
+	run1.cleanupTemp()
+	assert.False(t, helper.IsExistsPath(run1.Config.TempPath))
+	assert.True(t, helper.IsExistsPath(run2.Config.TempPath))
+
+	// the temp workdir is removed after all the runs
+	run2.cleanupTemp()
+	assert.False(t, helper.IsExistsPath(workdir))
+}
+
+func TestPerformPanic(t *testing.T) {
+	m := Model{Config: config.ModelConfig{
+		Name:      "test-perform-panic",
+		TempPath:  filepath.Join(t.TempDir(), "123"),
+		Databases: map[string]config.SubConfig{"mysql": {Name: "mysql", Type: "mysql"}},
+	}}
+
+	// the nil viper of the database panics, the run is failed instead of succeeded
+	err := m.Perform(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "panic: ")
+
+	run, err := history.Last(m.Config.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, history.StatusFailure, run.Status)
+}
@@ -1,8 +1,11 @@
 package notifier
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"time"
+	"strings"
+	"text/template"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/logger"
@@ -14,10 +17,14 @@ type Base struct {
 	Name      string
 	onSuccess bool
 	onFailure bool
+	// titleTemplate and bodyTemplate render the event, they are nil if not set
+	titleTemplate *template.Template
+	bodyTemplate  *template.Template
 }
 
 type Notifier interface {
-	notify(title, message string) error
+	// notify the event, the title and message of it are rendered by the templates of the notifier
+	notify(event Event) error
 }
 
 var (
@@ -36,6 +43,14 @@ func newNotifier(name string, config config.SubConfig) (Notifier, *Base, error)
 	base.onSuccess = base.viper.GetBool("on_success")
 	base.onFailure = base.viper.GetBool("on_failure")
 
+	var err error
+	if base.titleTemplate, err = parseTemplate(name+".title_template", base.viper.GetString("title_template")); err != nil {
+		return nil, nil, fmt.Errorf("Notifier: %s invalid title_template: %w", name, err)
+	}
+	if base.bodyTemplate, err = parseTemplate(name+".body_template", base.viper.GetString("body_template")); err != nil {
+		return nil, nil, fmt.Errorf("Notifier: %s invalid body_template: %w", name, err)
+	}
+
 	switch config.Type {
 	case "mail":
 		mail, err := NewMail(base)
@@ -73,7 +88,7 @@ func newNotifier(name string, config config.SubConfig) (Notifier, *Base, error)
 	return nil, nil, fmt.Errorf("Notifier: %s is not supported", name)
 }
 
-func notify(model config.ModelConfig, title, message string, notifyType int) {
+func notify(model config.ModelConfig, event Event, notifyType int) {
 	logger := logger.Tag("Notifier")
 
 	logger.Infof("Running %d Notifiers", len(model.Notifiers))
@@ -84,31 +99,131 @@ func notify(model config.ModelConfig, title, message string, notifyType int) {
 			continue
 		}
 
-		if notifyType == notifyTypeSuccess {
-			if base.onSuccess {
-				if err := notifier.notify(title, message); err != nil {
-					logger.Error(err)
-				}
-			}
-		} else if notifyType == notifyTypeFailure {
-			if base.onFailure {
-				if err := notifier.notify(title, message); err != nil {
-					logger.Error(err)
-				}
+		if notifyType == notifyTypeSuccess && !base.onSuccess {
+			continue
+		}
+		if notifyType == notifyTypeFailure && !base.onFailure {
+			continue
+		}
+
+		event := base.render(event)
+		attempts, err := config.Retry.Do(context.Background(), fmt.Sprintf("Notify %s", name), func(attempt int) error {
+			return notifier.notify(event)
+		})
+		if err != nil {
+			if attempts > 1 {
+				logger.Errorf("%v, after %d attempts", err, attempts)
+			} else {
+				logger.Error(err)
 			}
 		}
 	}
 }
 
-func Success(model config.ModelConfig) {
-	title := fmt.Sprintf("[GoBackup] OK: Backup %s has successfully", model.Name)
-	message := fmt.Sprintf("Backup of %s completed successfully at %s", model.Name, time.Now().Local())
-	notify(model, title, message, notifyTypeSuccess)
+// render the title and message of the event with the templates of the notifier,
+// the default ones are kept when the template is not set or failed to execute.
+func (base *Base) render(event Event) Event {
+	logger := logger.Tag(fmt.Sprintf("Notifier: %s", base.Name))
+
+	if base.titleTemplate != nil {
+		if title, err := execTemplate(base.titleTemplate, event); err != nil {
+			logger.Errorf("Render title_template failed, use the default title: %v", err)
+		} else {
+			event.Title = strings.TrimSpace(title)
+		}
+	}
+
+	if base.bodyTemplate != nil {
+		if message, err := execTemplate(base.bodyTemplate, event); err != nil {
+			logger.Errorf("Render body_template failed, use the default body: %v", err)
+		} else {
+			event.Message = message
+		}
+	}
+
+	return event
+}
+
+func execTemplate(t *template.Template, event Event) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, event); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
 }
 
-func Failure(model config.ModelConfig, reason string) {
-	title := fmt.Sprintf("[GoBackup] Err: Backup %s has failed", model.Name)
-	message := fmt.Sprintf("Backup of %s failed at %s:\n\n%s", model.Name, time.Now().Local(), reason)
+// Success notify the backup is completed, the details of the storages are appended to the message
+func Success(model config.ModelConfig, event Event) {
+	event.Status = StatusSuccess
+	event.Model = model.Name
+	event.complete()
+
+	event.Title = fmt.Sprintf("[GoBackup] OK: Backup %s has successfully", model.Name)
+	event.Message = fmt.Sprintf("Backup of %s completed successfully at %s", model.Name, event.FinishedAt.Local())
+	if details := event.details(); len(details) > 0 {
+		event.Message += "\n\n" + details
+	}
+	notify(model, event, notifyTypeSuccess)
+}
+
+// Failure notify the backup is failed with event.Error, the details of the storages are appended to the message
+func Failure(model config.ModelConfig, event Event) {
+	event.Status = StatusFailure
+	event.Model = model.Name
+	event.complete()
+
+	event.Title = fmt.Sprintf("[GoBackup] Err: Backup %s has failed", model.Name)
+	event.Message = fmt.Sprintf("Backup of %s failed at %s:\n\n%s", model.Name, event.FinishedAt.Local(), event.Error)
+	if details := event.details(); len(details) > 0 {
+		event.Message += "\n\n" + details
+	}
+
+	notify(model, event, notifyTypeFailure)
+}
+
+// Timeout notify the backup is timed out, it is sent to the notifiers of failure
+func Timeout(model config.ModelConfig, event Event) {
+	event.Status = StatusTimeout
+	event.Model = model.Name
+	event.complete()
+
+	event.Title = fmt.Sprintf("[GoBackup] Timeout: Backup %s has timed out", model.Name)
+	event.Message = fmt.Sprintf("Backup of %s timed out at %s:\n\n%s", model.Name, event.FinishedAt.Local(), event.Error)
+	if details := event.details(); len(details) > 0 {
+		event.Message += "\n\n" + details
+	}
+
+	notify(model, event, notifyTypeFailure)
+}
+
+// Skipped notify the backup is skipped in the chain, as the models it depends on are not succeeded,
+// it is sent to the notifiers of failure
+func Skipped(model config.ModelConfig, reason string) {
+	event := Event{Status: StatusSkipped, Model: model.Name, Error: reason}
+	event.complete()
+
+	event.Title = fmt.Sprintf("[GoBackup] Skipped: Backup %s has been skipped", model.Name)
+	event.Message = fmt.Sprintf("Backup of %s skipped at %s:\n\n%s", model.Name, event.FinishedAt.Local(), reason)
+
+	notify(model, event, notifyTypeFailure)
+}
+
+func VerifySuccess(model config.ModelConfig, message string) {
+	event := Event{Kind: EventVerify, Status: StatusSuccess, Model: model.Name}
+	event.complete()
+
+	event.Title = fmt.Sprintf("[GoBackup] OK: Verify %s has successfully", model.Name)
+	event.Message = fmt.Sprintf("Verify of %s completed successfully at %s:\n\n%s", model.Name, event.FinishedAt.Local(), message)
+	notify(model, event, notifyTypeSuccess)
+}
+
+func VerifyFailure(model config.ModelConfig, reason string) {
+	event := Event{Kind: EventVerify, Status: StatusFailure, Model: model.Name, Error: reason}
+	event.complete()
+
+	event.Title = fmt.Sprintf("[GoBackup] Err: Verify %s has failed", model.Name)
+	event.Message = fmt.Sprintf("Verify of %s failed at %s:\n\n%s", model.Name, event.FinishedAt.Local(), reason)
 
-	notify(model, title, message, notifyTypeFailure)
+	notify(model, event, notifyTypeFailure)
 }
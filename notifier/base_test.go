@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func Test_notifyRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhookViper := viper.New()
+	webhookViper.Set("url", server.URL)
+	model := config.ModelConfig{
+		Name: "retry_test",
+		Notifiers: map[string]config.SubConfig{
+			"webhook": {
+				Name:  "webhook",
+				Type:  "webhook",
+				Viper: webhookViper,
+				Retry: helper.RetryPolicy{Attempts: 3, InitialInterval: time.Millisecond},
+			},
+		},
+	}
+
+	Failure(model, Event{Error: "dump failed"})
+	assert.Equal(t, 3, requests)
+
+	// on_failure is disabled
+	webhookViper.Set("on_failure", false)
+	Failure(model, Event{Error: "dump failed"})
+	assert.Equal(t, 3, requests)
+}
+
+func Test_notifyTemplate(t *testing.T) {
+	var payload Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhookViper := viper.New()
+	webhookViper.Set("url", server.URL)
+	webhookViper.Set("title_template", "{{ .Status }}: {{ .Model }}")
+	webhookViper.Set("body_template", "{{ range .Storages }}{{ .Name }} {{ .FileKey }}{{ end }} in {{ .Duration }}")
+	model := config.ModelConfig{
+		Name: "template_test",
+		Notifiers: map[string]config.SubConfig{
+			"webhook": {Name: "webhook", Type: "webhook", Viper: webhookViper},
+		},
+	}
+
+	startedAt := time.Now().Add(-time.Minute)
+	Success(model, Event{
+		RunID:      42,
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(90 * time.Second),
+		Storages:   []EventStorage{{Name: "local", Type: "local", FileKey: "foo.tar.gz"}},
+	})
+	assert.Equal(t, "success: template_test", payload.Title)
+	assert.Equal(t, "local foo.tar.gz in 1m30s", payload.Message)
+	assert.Equal(t, StatusSuccess, payload.Status)
+	assert.Equal(t, EventBackup, payload.Kind)
+	assert.Equal(t, uint64(42), payload.RunID)
+	assert.Equal(t, int64(90000), payload.DurationMs)
+	assert.NotEqual(t, "", payload.Hostname)
+
+	// the default is used when the template is failed to execute
+	webhookViper.Set("title_template", "{{ .Unknown }}")
+	Failure(model, Event{Error: "dump failed"})
+	assert.Equal(t, "[GoBackup] Err: Backup template_test has failed", payload.Title)
+	assert.Equal(t, "dump failed", payload.Error)
+}
@@ -0,0 +1,123 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// EventBackup is the event of `gobackup perform` or the schedule
+	EventBackup = "backup"
+	// EventVerify is the event of `gobackup verify`
+	EventVerify = "verify"
+
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	StatusTimeout = "timeout"
+	StatusSkipped = "skipped"
+)
+
+// Event of a backup or a verify, it is passed to the notifiers,
+// and it is the JSON payload of the webhook notifier.
+type Event struct {
+	// Kind is backup or verify
+	Kind string `json:"kind"`
+	// Status is success, failure, timeout or skipped
+	Status     string    `json:"status"`
+	Model      string    `json:"model"`
+	RunID      uint64    `json:"run_id,omitempty"`
+	Hostname   string    `json:"hostname"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	// ArchiveSize is the total size of the package files
+	ArchiveSize int64          `json:"archive_size"`
+	Storages    []EventStorage `json:"storages"`
+	Error       string         `json:"error,omitempty"`
+
+	// Title and Message are rendered by the default text or the templates of the notifier
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// EventStorage is the result of a storage in the event
+type EventStorage struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// FileKey of the package in the storage, it is empty when the upload is failed
+	FileKey    string `json:"file_key,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	// Attempts of the upload, it is more than 1 when retried
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Duration of the backup, e.g. `{{ .Duration }}` in the templates
+func (e Event) Duration() time.Duration {
+	return time.Duration(e.DurationMs) * time.Millisecond
+}
+
+// Success return true if the status is success, Healthchecks uses it to choose the endpoint
+func (e Event) Success() bool {
+	return e.Status == StatusSuccess
+}
+
+func (s EventStorage) String() string {
+	duration := (time.Duration(s.DurationMs) * time.Millisecond).String()
+	if s.Attempts > 1 {
+		duration += fmt.Sprintf(" in %d attempts", s.Attempts)
+	}
+
+	if len(s.Error) > 0 {
+		return fmt.Sprintf("%s (%s): failed after %s, %s", s.Name, s.Type, duration, s.Error)
+	}
+
+	return fmt.Sprintf("%s (%s): %s", s.Name, s.Type, duration)
+}
+
+// details of the storages, they are appended to the default message
+func (e Event) details() string {
+	if len(e.Storages) == 0 {
+		return ""
+	}
+
+	lines := []string{"Storages:"}
+	for _, s := range e.Storages {
+		lines = append(lines, "- "+s.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// complete fill the fields which are not set by the caller
+func (e *Event) complete() {
+	if len(e.Kind) == 0 {
+		e.Kind = EventBackup
+	}
+	if len(e.Hostname) == 0 {
+		e.Hostname, _ = os.Hostname()
+	}
+	if e.FinishedAt.IsZero() {
+		e.FinishedAt = time.Now()
+	}
+	if e.StartedAt.IsZero() {
+		e.StartedAt = e.FinishedAt
+	}
+	if e.DurationMs == 0 {
+		e.DurationMs = e.FinishedAt.Sub(e.StartedAt).Milliseconds()
+	}
+	if e.Storages == nil {
+		e.Storages = []EventStorage{}
+	}
+}
+
+// parseTemplate parse the `title_template` or `body_template` of the notifier, nil if it is not set
+func parseTemplate(name, text string) (*template.Template, error) {
+	if len(text) == 0 {
+		return nil, nil
+	}
+
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
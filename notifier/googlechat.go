@@ -72,7 +72,7 @@ func (s *GoogleChat) GoogleChatURL() (string, error) {
 	return s.buildGoogleChatURL(url)
 }
 
-func (s *GoogleChat) notify(title string, message string) error {
+func (s *GoogleChat) notify(event Event) error {
 	logger := s.getLogger()
 
 	url, err := s.GoogleChatURL()
@@ -80,7 +80,7 @@ func (s *GoogleChat) notify(title string, message string) error {
 		return err
 	}
 
-	payload, err := s.buildBody(title, message)
+	payload, err := s.buildBody(event.Title, event.Message)
 	if err != nil {
 		return err
 	}
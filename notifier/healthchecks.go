@@ -43,16 +43,23 @@ func (s *Healthchecks) getLogger() logger.Logger {
 	return logger.Tag(fmt.Sprintf("Notifier: %s", s.Service))
 }
 
-func (s *Healthchecks) notify(title string, message string) error {
+func (s *Healthchecks) notify(event Event) error {
 	logger := s.getLogger()
+
+	// the check is of the backups, a successful verify must not keep it up when the backups are stopped
+	if event.Kind == EventVerify {
+		logger.Info("Skip the notification of verify, the check is pinged by the backups only")
+		return nil
+	}
+
 	url := s.failureEndpoint
-	if strings.Contains(title, "OK") {
+	if event.Success() {
 		url = s.successEndpoint
 	}
 
 	payload, err := json.Marshal(map[string]string{
-		"title":   title,
-		"message": message,
+		"title":   event.Title,
+		"message": event.Message,
 	})
 	if err != nil {
 		logger.Error(err)
@@ -1,6 +1,8 @@
 package notifier
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/longbridgeapp/assert"
@@ -22,3 +24,26 @@ func Test_Healthchecks(t *testing.T) {
 	err = s.checkResult(403, []byte(respBody))
 	assert.EqualError(t, err, "status: 403, body: "+respBody)
 }
+
+func Test_Healthchecks_notify(t *testing.T) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	base := &Base{
+		viper: viper.New(),
+	}
+	base.viper.Set("url", server.URL+"/ping/uuid")
+	s := NewHealthchecks(base)
+
+	// verify does not ping the check
+	assert.NoError(t, s.notify(Event{Kind: EventVerify, Status: StatusSuccess}))
+	assert.NoError(t, s.notify(Event{Kind: EventVerify, Status: StatusFailure}))
+	assert.Equal(t, 0, len(paths))
+
+	assert.NoError(t, s.notify(Event{Kind: EventBackup, Status: StatusSuccess}))
+	assert.NoError(t, s.notify(Event{Kind: EventBackup, Status: StatusFailure}))
+	assert.Equal(t, []string{"/ping/uuid", "/ping/uuid/fail"}, paths)
+}
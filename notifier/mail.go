@@ -116,10 +116,10 @@ func (s Mail) buildBody(title string, message string) string {
 	return fmt.Sprintf("%s\n%s", strings.Join(headerTexts, "\n"), base64.StdEncoding.EncodeToString([]byte(message)))
 }
 
-func (s *Mail) notify(title string, message string) error {
+func (s *Mail) notify(event Event) error {
 	// Connect to the server, authenticate, set the sender and recipient,
 	// and send the email all in one step.
-	msg := s.buildBody(title, message)
+	msg := s.buildBody(event.Title, event.Message)
 
 	switch s.tls {
 	case TLSModeTLS:
@@ -58,11 +58,11 @@ func (s *SES) buildEmail(title, message string) *ses.SendEmailInput {
 	}
 }
 
-func (s *SES) notify(title string, message string) error {
+func (s *SES) notify(event Event) error {
 	logger := logger.Tag("Notifier: SES")
 
 	logger.Info("Sending notification...")
-	_, err := s.client.SendEmail(s.buildEmail(title, message))
+	_, err := s.client.SendEmail(s.buildEmail(event.Title, event.Message))
 	if err != nil {
 		return err
 	}
@@ -15,19 +15,16 @@ type Webhook struct {
 
 	Service string
 
-	method          string
-	contentType     string
-	buildBody       func(title, message string) ([]byte, error)
+	method      string
+	contentType string
+	buildBody   func(title, message string) ([]byte, error)
+	// buildEventBody is used instead of buildBody when it is set, to send the whole event
+	buildEventBody  func(event Event) ([]byte, error)
 	buildWebhookURL func(url string) (string, error)
 	checkResult     func(status int, responseBody []byte) error
 	buildHeaders    func() map[string]string
 }
 
-type webhookPayload struct {
-	Title   string `json:"title"`
-	Message string `json:"message"`
-}
-
 func NewWebhook(base *Base) *Webhook {
 	base.viper.SetDefault("method", "POST")
 
@@ -36,11 +33,8 @@ func NewWebhook(base *Base) *Webhook {
 		Service:     "Webhook",
 		method:      base.viper.GetString("method"),
 		contentType: "application/json",
-		buildBody: func(title, message string) ([]byte, error) {
-			return json.Marshal(webhookPayload{
-				Title:   title,
-				Message: message,
-			})
+		buildEventBody: func(event Event) ([]byte, error) {
+			return json.Marshal(event)
 		},
 		buildHeaders: func() map[string]string {
 			headers := make(map[string]string)
@@ -74,7 +68,7 @@ func (s *Webhook) webhookURL() (string, error) {
 	return s.buildWebhookURL(url)
 }
 
-func (s *Webhook) notify(title string, message string) error {
+func (s *Webhook) notify(event Event) error {
 	logger := s.getLogger()
 
 	url, err := s.webhookURL()
@@ -82,7 +76,12 @@ func (s *Webhook) notify(title string, message string) error {
 		return err
 	}
 
-	payload, err := s.buildBody(title, message)
+	var payload []byte
+	if s.buildEventBody != nil {
+		payload, err = s.buildEventBody(event)
+	} else {
+		payload, err = s.buildBody(event.Title, event.Message)
+	}
 	if err != nil {
 		return err
 	}
@@ -120,10 +119,8 @@ func (s *Webhook) notify(title string, message string) error {
 	}
 
 	if s.checkResult != nil {
-		err = s.checkResult(resp.StatusCode, body)
-		if err != nil {
-			logger.Error(err)
-			return nil
+		if err := s.checkResult(resp.StatusCode, body); err != nil {
+			return err
 		}
 	} else {
 		logger.Infof("Response body: %s", string(body))
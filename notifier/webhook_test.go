@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"testing"
+	"time"
 
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
@@ -25,9 +26,31 @@ func Test_Webhook(t *testing.T) {
 	s = NewWebhook(base)
 	assert.Equal(t, "PUT", s.method)
 
-	body, err := s.buildBody("This is title", "This is body")
+	startedAt := time.Date(2022, 12, 4, 7, 9, 25, 0, time.UTC)
+	body, err := s.buildEventBody(Event{
+		Kind:        EventBackup,
+		Status:      StatusFailure,
+		Model:       "my_backup",
+		RunID:       42,
+		Hostname:    "db1",
+		StartedAt:   startedAt,
+		FinishedAt:  startedAt.Add(90 * time.Second),
+		DurationMs:  90000,
+		ArchiveSize: 1024,
+		Storages: []EventStorage{
+			{Name: "local", Type: "local", FileKey: "2022.12.04.07.09.25.tar.gz", DurationMs: 10, Attempts: 1},
+			{Name: "s3", Type: "s3", DurationMs: 3000, Attempts: 3, Error: "connection reset"},
+		},
+		Error:   "connection reset",
+		Title:   "This is title",
+		Message: "This is body",
+	})
 	assert.NoError(t, err)
-	assert.Equal(t, `{"title":"This is title","message":"This is body"}`, string(body))
+	assert.Equal(t, `{"kind":"backup","status":"failure","model":"my_backup","run_id":42,"hostname":"db1",`+
+		`"started_at":"2022-12-04T07:09:25Z","finished_at":"2022-12-04T07:10:55Z","duration_ms":90000,"archive_size":1024,`+
+		`"storages":[{"name":"local","type":"local","file_key":"2022.12.04.07.09.25.tar.gz","duration_ms":10,"attempts":1},`+
+		`{"name":"s3","type":"s3","duration_ms":3000,"attempts":3,"error":"connection reset"}],`+
+		`"error":"connection reset","title":"This is title","message":"This is body"}`, string(body))
 
 	headers := s.buildHeaders()
 	assert.Equal(t, "Bearer this-is-token", headers["Authorization"])
@@ -40,7 +40,7 @@ func Test_WxWorkNotify(t *testing.T) {
 
 	s := NewWxWork(base)
 
-	err := s.notify("hello", "world")
+	err := s.notify(Event{Title: "hello", Message: "world"})
 
 	assert.NoError(t, err)
 }
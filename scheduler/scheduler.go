@@ -2,12 +2,14 @@ package scheduler
 
 import (
 	"fmt"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-co-op/gocron"
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/job"
 	superlogger "github.com/gobackup/gobackup/logger"
 	"github.com/gobackup/gobackup/model"
 )
@@ -28,7 +30,7 @@ func Start() error {
 
 	mycron = gocron.NewScheduler(time.Local)
 
-	mu := sync.Mutex{}
+	job.SetConcurrency(config.MaxConcurrentJobs)
 
 	for _, modelConfig := range config.Models {
 		if !modelConfig.Schedule.Enabled {
@@ -52,19 +54,25 @@ func Start() error {
 		}
 
 		if _, err := scheduler.Do(func(modelConfig config.ModelConfig) {
-			defer mu.Unlock()
 			logger := superlogger.Tag(fmt.Sprintf("Scheduler: %s", modelConfig.Name))
 
-			logger.Info("Performing...")
-
 			m := model.Model{
-				Config: modelConfig,
+				Config:  modelConfig,
+				Trigger: history.TriggerScheduler,
 			}
-			mu.Lock()
-			if err := m.Perform(); err != nil {
-				logger.Errorf("Failed to perform: %s", err.Error())
+			if j, queued := job.Enqueue(m); queued {
+				if len(j.Steps) > 0 {
+					var models []string
+					for _, s := range j.Steps {
+						models = append(models, s.Model)
+					}
+					logger.Infof("Queued job %d of chain %s", j.ID, strings.Join(models, " -> "))
+				} else {
+					logger.Infof("Queued job %d", j.ID)
+				}
+			} else {
+				logger.Warnf("Skipped, job %d of the model is %s", j.ID, j.Status)
 			}
-			logger.Info("Done.")
 		}, modelConfig); err != nil {
 			logger.Errorf("Failed to register job func: %s", err.Error())
 		}
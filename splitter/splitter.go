@@ -1,9 +1,12 @@
 package splitter
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,8 +17,8 @@ import (
 )
 
 // Run splitter on archive path
-func Run(archivePath string, model config.ModelConfig) (string, error) {
-	logger := logger.Tag("Splitter")
+func Run(ctx context.Context, archivePath string, model config.ModelConfig) (string, error) {
+	logger := logger.TagContext(ctx, "Splitter")
 
 	splitter := model.Splitter
 	if splitter == nil {
@@ -42,7 +45,7 @@ func Run(archivePath string, model config.ModelConfig) (string, error) {
 
 	opts := options(splitter)
 	opts = append(opts, archivePath, splitSuffix)
-	_, err := helper.Exec("split", opts...)
+	_, err := helper.ExecContext(ctx, "split", opts...)
 	if err != nil {
 		return "", err
 	}
@@ -59,6 +62,100 @@ func Run(archivePath string, model config.ModelConfig) (string, error) {
 	return archiveDirPath, nil
 }
 
+// Join chunks in archiveDirPath back into a single archive file, return the archive path.
+//
+// If archivePath is a file, it returns as-is.
+func Join(archivePath string) (string, error) {
+	logger := logger.Tag("Splitter")
+
+	fi, err := os.Stat(archivePath)
+	if err != nil {
+		return "", err
+	}
+	if !fi.IsDir() {
+		return archivePath, nil
+	}
+
+	entries, err := os.ReadDir(archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	var chunks []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			chunks = append(chunks, e.Name())
+		}
+	}
+	if len(chunks) == 0 {
+		return "", fmt.Errorf("no chunks found in %s", archivePath)
+	}
+	sort.Strings(chunks)
+
+	logger.Infof("Join %d chunks", len(chunks))
+
+	// 2022.12.04.07.24.08.tar.xz-000 -> 2022.12.04.07.24.08.tar.xz
+	// 2022.12.04.07.24.08.7z.001 -> 2022.12.04.07.24.08.7z
+	joinedPath := filepath.Join(filepath.Dir(archivePath), chunkBaseName(chunks[0]))
+	out, err := os.Create(joinedPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	for _, chunk := range chunks {
+		if err := appendFile(out, filepath.Join(archivePath, chunk)); err != nil {
+			return "", err
+		}
+	}
+
+	logger.Info("Join done")
+
+	return joinedPath, nil
+}
+
+// chunkBaseName return the archive name of the chunk, by removing the suffix of split (`-000` or `-aaa`),
+// or the suffix of 7z volumes (`.001`). The name is returned as it is if it has no such suffix.
+func chunkBaseName(chunk string) string {
+	if idx := strings.LastIndex(chunk, "."); idx > 0 && isChunkSuffix(chunk[idx+1:], true) {
+		return chunk[:idx]
+	}
+	if idx := strings.LastIndex(chunk, "-"); idx > 0 && (isChunkSuffix(chunk[idx+1:], true) || isChunkSuffix(chunk[idx+1:], false)) {
+		return chunk[:idx]
+	}
+
+	return chunk
+}
+
+// isChunkSuffix return true if suffix is made by chunkSuffix, numeric or alphabetic
+func isChunkSuffix(suffix string, numeric bool) bool {
+	if len(suffix) == 0 {
+		return false
+	}
+
+	for _, c := range suffix {
+		if numeric && (c < '0' || c > '9') {
+			return false
+		}
+		if !numeric && (c < 'a' || c > 'z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func appendFile(out io.Writer, chunkPath string) error {
+	f, err := os.Open(chunkPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(out, f)
+	return err
+}
+
 func options(splitter *viper.Viper) (opts []string) {
 	bytes := splitter.GetString("chunk_size")
 	opts = append(opts, "-b", bytes)
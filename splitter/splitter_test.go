@@ -0,0 +1,37 @@
+package splitter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func Test_chunkBaseName(t *testing.T) {
+	assert.Equal(t, "2022.12.04.07.24.08.tar.xz", chunkBaseName("2022.12.04.07.24.08.tar.xz-000"))
+	assert.Equal(t, "2022.12.04.07.24.08.tar.xz", chunkBaseName("2022.12.04.07.24.08.tar.xz-aab"))
+	assert.Equal(t, "2022.12.04.07.24.08.7z", chunkBaseName("2022.12.04.07.24.08.7z.001"))
+	// the dashes in the filename_format are not the suffixes
+	assert.Equal(t, "2022-12-04-07-24-08.tar.gz", chunkBaseName("2022-12-04-07-24-08.tar.gz-001"))
+	assert.Equal(t, "2022-12-04-07-24-08.tar.gz", chunkBaseName("2022-12-04-07-24-08.tar.gz"))
+	assert.Equal(t, "backup-2022.tar.gz", chunkBaseName("backup-2022.tar.gz"))
+}
+
+func TestJoin(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "2022.12.04.07.24.08")
+	assert.NoError(t, os.MkdirAll(dir, 0750))
+	for name, content := range map[string]string{
+		"2022.12.04.07.24.08.7z.001": "hello ",
+		"2022.12.04.07.24.08.7z.002": "world",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0640))
+	}
+
+	joinedPath, err := Join(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(dir), "2022.12.04.07.24.08.7z"), joinedPath)
+	data, err := os.ReadFile(joinedPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+}
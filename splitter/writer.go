@@ -0,0 +1,196 @@
+package splitter
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gobackup/gobackup/config"
+)
+
+var sizeRegexp = regexp.MustCompile(`^(\d+)([a-zA-Z]*)$`)
+
+// sizeUnits are the units supported by `split -b`
+var sizeUnits = map[string]int64{
+	"":   1,
+	"b":  512,
+	"K":  1 << 10,
+	"KB": 1000,
+	"M":  1 << 20,
+	"MB": 1000 * 1000,
+	"G":  1 << 30,
+	"GB": 1000 * 1000 * 1000,
+	"T":  1 << 40,
+	"TB": 1000 * 1000 * 1000 * 1000,
+}
+
+// Key return the package key of the archive filename,
+// it is the directory of the chunks when the splitter is enabled.
+//
+// 2022.12.04.07.24.08.tar.xz -> 2022.12.04.07.24.08
+func Key(filename string, model config.ModelConfig) string {
+	if model.Splitter == nil {
+		return filename
+	}
+
+	return strings.TrimSuffix(filename, model.Viper.GetString("Ext"))
+}
+
+// NewWriter return a writer of the archive filename, the data is split into chunks with `chunk_size`,
+// and each chunk is created by create with the same key as `split` does.
+//
+// 2022.12.04.07.24.08/2022.12.04.07.24.08.tar.xz-000
+//
+// When the splitter is not enabled, the archive is created as a single file.
+func NewWriter(filename string, model config.ModelConfig, create func(key string) (io.WriteCloser, error)) (io.WriteCloser, error) {
+	splitter := model.Splitter
+	if splitter == nil {
+		return create(filename)
+	}
+
+	splitter.SetDefault("suffix_length", 3)
+	splitter.SetDefault("numeric_suffixes", true)
+	if len(splitter.GetString("chunk_size")) == 0 {
+		return nil, fmt.Errorf("chunk_size option is required")
+	}
+
+	chunkSize, err := parseSize(splitter.GetString("chunk_size"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &chunkWriter{
+		prefix:          path.Join(Key(filename, model), filename+"-"),
+		chunkSize:       chunkSize,
+		suffixLength:    splitter.GetInt("suffix_length"),
+		numericSuffixes: splitter.GetBool("numeric_suffixes"),
+		create:          create,
+	}, nil
+}
+
+// chunkWriter split the data into chunks
+type chunkWriter struct {
+	prefix          string
+	chunkSize       int64
+	suffixLength    int
+	numericSuffixes bool
+	create          func(key string) (io.WriteCloser, error)
+
+	current io.WriteCloser
+	written int64
+	index   int
+}
+
+func (w *chunkWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if w.current == nil {
+			if err := w.next(); err != nil {
+				return n, err
+			}
+		}
+
+		size := w.chunkSize - w.written
+		if int64(len(p)) < size {
+			size = int64(len(p))
+		}
+
+		written, err := w.current.Write(p[:size])
+		n += written
+		w.written += int64(written)
+		if err != nil {
+			return n, err
+		}
+		p = p[written:]
+
+		if w.written >= w.chunkSize {
+			if err := w.closeChunk(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Close the last chunk, an empty archive still creates the first chunk as `split` does
+func (w *chunkWriter) Close() error {
+	if w.current == nil && w.index == 0 {
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+
+	return w.closeChunk()
+}
+
+func (w *chunkWriter) next() error {
+	suffix, err := chunkSuffix(w.index, w.suffixLength, w.numericSuffixes)
+	if err != nil {
+		return err
+	}
+
+	w.current, err = w.create(w.prefix + suffix)
+	if err != nil {
+		return err
+	}
+	w.written = 0
+	w.index++
+
+	return nil
+}
+
+func (w *chunkWriter) closeChunk() error {
+	if w.current == nil {
+		return nil
+	}
+
+	err := w.current.Close()
+	w.current = nil
+	return err
+}
+
+// parseSize parse the size of `chunk_size` in the same format as `split -b`
+func parseSize(size string) (int64, error) {
+	matches := sizeRegexp.FindStringSubmatch(strings.TrimSpace(size))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid chunk_size: %s", size)
+	}
+
+	unit, ok := sizeUnits[strings.TrimSuffix(matches[2], "iB")]
+	if !ok {
+		unit, ok = sizeUnits[strings.ToUpper(matches[2])]
+	}
+	if !ok {
+		return 0, fmt.Errorf("invalid chunk_size unit: %s", size)
+	}
+
+	n, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid chunk_size: %s", size)
+	}
+
+	return n * unit, nil
+}
+
+// chunkSuffix return the suffix of the chunk index as `split -a` does,
+// numeric: 000, 001, 002..., alphabetic: aaa, aab, aac...
+func chunkSuffix(index int, length int, numeric bool) (string, error) {
+	base, digits := 26, "abcdefghijklmnopqrstuvwxyz"
+	if numeric {
+		base, digits = 10, "0123456789"
+	}
+
+	suffix := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		suffix[i] = digits[index%base]
+		index /= base
+	}
+	if index > 0 {
+		return "", fmt.Errorf("output file suffixes exhausted, increase suffix_length")
+	}
+
+	return string(suffix), nil
+}
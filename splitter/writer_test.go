@@ -0,0 +1,98 @@
+package splitter
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferCloser) Close() error { return nil }
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"100":  100,
+		"2b":   1024,
+		"1K":   1024,
+		"1KB":  1000,
+		"1KiB": 1024,
+		"4M":   4 * 1024 * 1024,
+		"4MB":  4 * 1000 * 1000,
+		"1G":   1024 * 1024 * 1024,
+	}
+	for size, expected := range cases {
+		n, err := parseSize(size)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, n, size)
+	}
+
+	_, err := parseSize("1X")
+	assert.Error(t, err)
+	_, err = parseSize("M")
+	assert.Error(t, err)
+}
+
+func TestChunkSuffix(t *testing.T) {
+	suffix, err := chunkSuffix(12, 3, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "012", suffix)
+
+	suffix, err = chunkSuffix(27, 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "bb", suffix)
+
+	_, err = chunkSuffix(100, 2, true)
+	assert.Error(t, err)
+}
+
+func TestNewWriter(t *testing.T) {
+	model := config.ModelConfig{Viper: viper.New()}
+	model.Viper.Set("Ext", ".tar.gz")
+
+	chunks := map[string]*bufferCloser{}
+	var keys []string
+	create := func(key string) (io.WriteCloser, error) {
+		keys = append(keys, key)
+		chunks[key] = &bufferCloser{}
+		return chunks[key], nil
+	}
+
+	// no splitter
+	w, err := NewWriter("2022.12.04.07.24.08.tar.gz", model, create)
+	assert.NoError(t, err)
+	w.Write([]byte("hello"))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, []string{"2022.12.04.07.24.08.tar.gz"}, keys)
+	assert.Equal(t, "2022.12.04.07.24.08.tar.gz", Key("2022.12.04.07.24.08.tar.gz", model))
+
+	// split into chunks
+	keys = nil
+	model.Splitter = viper.New()
+	model.Splitter.Set("chunk_size", "4")
+	w, err = NewWriter("2022.12.04.07.24.08.tar.gz", model, create)
+	assert.NoError(t, err)
+	w.Write([]byte("hello "))
+	w.Write([]byte("world"))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"2022.12.04.07.24.08/2022.12.04.07.24.08.tar.gz-000",
+		"2022.12.04.07.24.08/2022.12.04.07.24.08.tar.gz-001",
+		"2022.12.04.07.24.08/2022.12.04.07.24.08.tar.gz-002",
+	}, keys)
+	assert.Equal(t, "hell", chunks[keys[0]].String())
+	assert.Equal(t, "o wo", chunks[keys[1]].String())
+	assert.Equal(t, "rld", chunks[keys[2]].String())
+	assert.Equal(t, "2022.12.04.07.24.08", Key("2022.12.04.07.24.08.tar.gz", model))
+
+	model.Splitter.Set("chunk_size", "")
+	_, err = NewWriter("2022.12.04.07.24.08.tar.gz", model, create)
+	assert.Error(t, err)
+}
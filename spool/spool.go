@@ -0,0 +1,216 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+)
+
+const metaFile = "spool.json"
+
+var (
+	spoolPath = filepath.Join(config.GoBackupDir, "spool")
+
+	// ErrNotFound is returned when the package is not in the spool
+	ErrNotFound = fmt.Errorf("spooled package not found")
+)
+
+// Package is kept in the spool when it is failed to upload to some storages,
+// so that it can be uploaded again without performing the backup.
+// There is no package in the streaming mode, so `keep_failed_artifacts` is rejected with `streaming`.
+//
+// ~/.gobackup/spool/my_backup/42/2022.12.04.07.09.25.tar.gz
+// ~/.gobackup/spool/my_backup/42/2022.12.04.07.09.25.tar.gz.manifest.json
+// ~/.gobackup/spool/my_backup/42/spool.json
+type Package struct {
+	// ID is the id of the run which created the package
+	ID        uint64    `json:"id"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	// Filename of the package, it is a directory when the package was split
+	Filename string `json:"filename"`
+	// Manifest filename, empty when the manifest was not written
+	Manifest string `json:"manifest,omitempty"`
+	// Storages which the package has not been uploaded to
+	Storages []string `json:"storages"`
+	// Error of the last upload
+	Error string `json:"error,omitempty"`
+}
+
+// Keep move the package and its manifest into the spool,
+// the id is the run id, or the current unix time when the run was not recorded.
+func Keep(id uint64, model string, archivePath string, manifestPath string, storages []string, uploadErr error) (*Package, error) {
+	if id == 0 {
+		id = uint64(time.Now().Unix())
+	}
+
+	pkg := &Package{
+		ID:        id,
+		Model:     model,
+		CreatedAt: time.Now(),
+		Filename:  filepath.Base(archivePath),
+		Storages:  storages,
+	}
+	if uploadErr != nil {
+		pkg.Error = uploadErr.Error()
+	}
+
+	if err := helper.MkdirP(pkg.Dir()); err != nil {
+		return nil, err
+	}
+
+	if err := move(archivePath, pkg.ArchivePath()); err != nil {
+		return nil, err
+	}
+	if len(manifestPath) > 0 {
+		pkg.Manifest = filepath.Base(manifestPath)
+		if err := move(manifestPath, pkg.ManifestPath()); err != nil {
+			return nil, err
+		}
+	}
+
+	return pkg, pkg.Save()
+}
+
+// Dir of the package in the spool
+func (pkg *Package) Dir() string {
+	return filepath.Join(spoolPath, pkg.Model, strconv.FormatUint(pkg.ID, 10))
+}
+
+// ArchivePath return the path of the package file or the directory of the chunks
+func (pkg *Package) ArchivePath() string {
+	return filepath.Join(pkg.Dir(), pkg.Filename)
+}
+
+// ManifestPath return the path of the manifest, empty when there is no manifest
+func (pkg *Package) ManifestPath() string {
+	if len(pkg.Manifest) == 0 {
+		return ""
+	}
+	return filepath.Join(pkg.Dir(), pkg.Manifest)
+}
+
+// Save the state of the package
+func (pkg *Package) Save() error {
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(pkg.Dir(), metaFile), data, 0640)
+}
+
+// Remove the package from the spool
+func (pkg *Package) Remove() error {
+	return os.RemoveAll(pkg.Dir())
+}
+
+// Get the package of id in the spool of model
+func Get(model string, id uint64) (*Package, error) {
+	data, err := os.ReadFile(filepath.Join(spoolPath, model, strconv.FormatUint(id, 10), metaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var pkg Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid %s of spool %d: %v", metaFile, id, err)
+	}
+
+	return &pkg, nil
+}
+
+// List the packages in the spool of model, in oldest first
+func List(model string) ([]*Package, error) {
+	entries, err := os.ReadDir(filepath.Join(spoolPath, model))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pkgs []*Package
+	for _, e := range entries {
+		id, err := strconv.ParseUint(e.Name(), 10, 64)
+		if !e.IsDir() || err != nil {
+			continue
+		}
+
+		pkg, err := Get(model, id)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].ID < pkgs[j].ID
+	})
+
+	return pkgs, nil
+}
+
+// move src to dst, copy it when they are not on the same device, e.g. the temp path is on tmpfs
+func move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return helper.MkdirP(target)
+		}
+		return copyFile(path, target)
+	})
+	if err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	return os.RemoveAll(src)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,64 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gobackup/gobackup/helper"
+	"github.com/longbridgeapp/assert"
+)
+
+func TestKeep(t *testing.T) {
+	originalSpoolPath := spoolPath
+	spoolPath = t.TempDir()
+	defer func() {
+		spoolPath = originalSpoolPath
+	}()
+
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "2022.12.04.07.09.25.tar.gz")
+	manifestPath := archivePath + ".manifest.json"
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello world"), 0640))
+	assert.NoError(t, os.WriteFile(manifestPath, []byte("{}"), 0640))
+
+	pkg, err := Keep(42, "base_test", archivePath, manifestPath, []string{"s3"}, fmt.Errorf("connection reset"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(spoolPath, "base_test", "42", "2022.12.04.07.09.25.tar.gz"), pkg.ArchivePath())
+	assert.Equal(t, filepath.Join(spoolPath, "base_test", "42", "2022.12.04.07.09.25.tar.gz.manifest.json"), pkg.ManifestPath())
+	assert.False(t, helper.IsExistsPath(archivePath))
+	assert.False(t, helper.IsExistsPath(manifestPath))
+
+	data, err := os.ReadFile(pkg.ArchivePath())
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	got, err := Get("base_test", 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s3"}, got.Storages)
+	assert.Equal(t, "connection reset", got.Error)
+	assert.Equal(t, pkg.CreatedAt.Unix(), got.CreatedAt.Unix())
+
+	// split package without manifest
+	chunksPath := filepath.Join(tempDir, "2022.12.04.07.09.47")
+	assert.NoError(t, os.MkdirAll(chunksPath, 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(chunksPath, "2022.12.04.07.09.47.tar.gz-000"), []byte("hello"), 0640))
+	_, err = Keep(7, "base_test", chunksPath, "", []string{"local", "s3"}, nil)
+	assert.NoError(t, err)
+
+	pkgs, err := List("base_test")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pkgs))
+	assert.Equal(t, uint64(7), pkgs[0].ID)
+	assert.Equal(t, "", pkgs[0].ManifestPath())
+	assert.True(t, helper.IsExistsPath(filepath.Join(pkgs[0].ArchivePath(), "2022.12.04.07.09.47.tar.gz-000")))
+
+	assert.NoError(t, pkgs[0].Remove())
+	_, err = Get("base_test", 7)
+	assert.Equal(t, ErrNotFound, err)
+
+	pkgs, err = List("other")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(pkgs))
+}
@@ -3,7 +3,9 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -75,10 +77,9 @@ func (s Azure) getBucketURL() string {
 	return fmt.Sprintf("https://%s.blob.core.windows.net", s.account)
 }
 
-func (s *Azure) upload(fileKey string) (err error) {
-	logger := logger.Tag("Azure")
+func (s *Azure) upload(ctx context.Context, fileKey string) (err error) {
+	logger := logger.TagContext(ctx, "Azure")
 
-	var ctx = context.Background()
 	var cancel context.CancelFunc
 
 	if s.timeout.Seconds() > 0 {
@@ -103,7 +104,7 @@ func (s *Azure) upload(fileKey string) (err error) {
 
 	for _, key := range fileKeys {
 		sourcePath := filepath.Join(filepath.Dir(s.archivePath), key)
-		remotePath := filepath.Join(s.path, key)
+		remotePath := s.objectKey(key)
 
 		// Open file
 		f, err := os.Open(sourcePath)
@@ -123,8 +124,30 @@ func (s *Azure) upload(fileKey string) (err error) {
 	return nil
 }
 
+// uploadStream write the reader into fileKey
+func (s *Azure) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, "Azure")
+
+	var cancel context.CancelFunc
+	if s.timeout.Seconds() > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	// Check to create Azure Storage Container, And ignore error
+	_, _ = s.client.CreateContainer(ctx, s.container, nil)
+
+	remotePath := s.objectKey(fileKey)
+	if _, err := s.client.UploadStream(ctx, s.container, remotePath, r, nil); err != nil {
+		return fmt.Errorf("Azure upload error: %v", err)
+	}
+	logger.Info("->", remotePath)
+
+	return nil
+}
+
 func (s *Azure) delete(fileKey string) (err error) {
-	remotePath := filepath.Join(s.path, fileKey)
+	remotePath := s.objectKey(fileKey)
 	var ctx = context.Background()
 
 	if _, err = s.client.DeleteBlob(ctx, s.container, remotePath, nil); err != nil {
@@ -137,7 +160,7 @@ func (s *Azure) delete(fileKey string) (err error) {
 // List the objects in the bucket with the prefix = parent
 // https://pkg.go.dev/github.com/Azure/azure-sdk-for-go/sdk/storage/azblob
 func (s *Azure) list(parent string) ([]FileItem, error) {
-	remotePath := filepath.Join(s.archivePath, parent)
+	remotePath := listPrefix(s.objectKey(parent))
 	var ctx = context.Background()
 
 	var fileItems []FileItem
@@ -155,7 +178,7 @@ func (s *Azure) list(parent string) ([]FileItem, error) {
 
 		for _, blob := range resp.Segment.BlobItems {
 			fileItems = append(fileItems, FileItem{
-				Filename:     *blob.Name,
+				Filename:     relativeKey(s.path, *blob.Name),
 				LastModified: *blob.Properties.LastModified,
 				Size:         *blob.Properties.ContentLength,
 			})
@@ -168,7 +191,7 @@ func (s *Azure) list(parent string) ([]FileItem, error) {
 // Get a client download URL
 func (s *Azure) download(fileKey string) (*DownloadResult, error) {
 	containerClient := s.client.ServiceClient().NewContainerClient(s.container)
-	blobClient := containerClient.NewBlobClient(fileKey)
+	blobClient := containerClient.NewBlobClient(s.objectKey(fileKey))
 
 	url, err := blobClient.GetSASURL(sas.BlobPermissions{Read: true}, time.Now(), time.Now().Add(time.Hour*1))
 	if err != nil {
@@ -177,3 +200,8 @@ func (s *Azure) download(fileKey string) (*DownloadResult, error) {
 
 	return &DownloadResult{RedirectURL: url}, nil
 }
+
+// objectKey return the name of the blob in the container, fileKey is relative to path
+func (s *Azure) objectKey(fileKey string) string {
+	return path.Join(s.path, fileKey)
+}
@@ -1,18 +1,28 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/manifest"
 	"github.com/spf13/viper"
 )
 
+// ErrInvalidFileKey is returned when the file key to download is out of the storage path
+var ErrInvalidFileKey = errors.New("invalid file key")
+
 // Base storage
 // When `archivePath` is a directory, `fileKeys` stores files in the `archivePath` with directory prefix
 type Base struct {
@@ -20,7 +30,7 @@ type Base struct {
 	archivePath string
 	fileKeys    []string
 	viper       *viper.Viper
-	keep        int
+	retention   Retention
 	cycler      *Cycler
 }
 
@@ -53,21 +63,14 @@ func (r *DownloadResult) Close() error {
 type Storage interface {
 	open() error
 	close()
-	upload(fileKey string) error
+	// upload the package, it is stopped when ctx is done
+	upload(ctx context.Context, fileKey string) error
 	delete(fileKey string) error
 	list(parent string) ([]FileItem, error)
 	download(fileKey string) (*DownloadResult, error)
 }
 
 func newBase(model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (base Base, err error) {
-	// Backward compatible with `store_with` config
-	var cyclerName string
-	if storageConfig.Name == "" {
-		cyclerName = model.Name
-	} else {
-		cyclerName = fmt.Sprintf("%s_%s", model.Name, storageConfig.Name)
-	}
-
 	var keys []string
 	if fi, err := os.Stat(archivePath); err == nil && fi.IsDir() {
 		// NOTE: ignore err is not nil scenario here to pass test and should be fine
@@ -90,16 +93,25 @@ func newBase(model config.ModelConfig, archivePath string, storageConfig config.
 		archivePath: archivePath,
 		fileKeys:    keys,
 		viper:       storageConfig.Viper,
-		cycler:      &Cycler{name: cyclerName},
+		cycler:      newCycler(model, storageConfig),
 	}
 
 	if base.viper != nil {
-		base.keep = base.viper.GetInt("keep")
+		base.retention = newRetention(model, base.viper)
 	}
 
 	return
 }
 
+func newCycler(model config.ModelConfig, storageConfig config.SubConfig) *Cycler {
+	// Backward compatible with `store_with` config
+	if storageConfig.Name == "" {
+		return &Cycler{name: model.Name}
+	}
+
+	return &Cycler{name: fmt.Sprintf("%s_%s", model.Name, storageConfig.Name)}
+}
+
 func new(model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (Base, Storage) {
 	base, err := newBase(model, archivePath, storageConfig)
 	if err != nil {
@@ -155,51 +167,200 @@ func new(model config.ModelConfig, archivePath string, storageConfig config.SubC
 	return base, s
 }
 
-// run storage
-func runModel(model config.ModelConfig, archivePath string, storageConfig config.SubConfig) (err error) {
-	logger := logger.Tag("Storage")
+// resumer is implemented by the storages which resume the failed upload in the next attempt
+type resumer interface {
+	// discardResume remove the state and the uploaded data of the failed upload, when there is no more attempt
+	discardResume()
+}
+
+// upload fileKey with the retry policy of the storage, the storage is reopened before each retry,
+// as the connection may be broken by the last failure. The storage is kept open when it succeeds.
+func upload(ctx context.Context, s Storage, storageConfig config.SubConfig, fileKey string) (attempts int, err error) {
+	opened := false
+	attempts, err = storageConfig.Retry.Do(ctx, fmt.Sprintf("Upload %s to %s", fileKey, storageConfig.Name), func(attempt int) error {
+		if opened {
+			s.close()
+			opened = false
+		}
+
+		if err := s.open(); err != nil {
+			return err
+		}
+		opened = true
+
+		return s.upload(ctx, fileKey)
+	})
+
+	if err != nil {
+		if r, ok := s.(resumer); ok {
+			// the last attempt may fail to open, reopen to discard the uploaded data of the former attempts
+			if !opened {
+				if openErr := s.open(); openErr != nil {
+					logger.TagContext(ctx, "Storage").Errorf("Discard the failed upload of %s to %s failed: %v", fileKey, storageConfig.Name, openErr)
+				} else {
+					opened = true
+				}
+			}
+			if opened {
+				r.discardResume()
+			}
+		}
+		if opened {
+			s.close()
+		}
+	}
+
+	return attempts, err
+}
+
+// run storage, return the number of the packages removed by the cycler and the attempts of the upload
+func runModel(ctx context.Context, model config.ModelConfig, archivePath string, manifestPath string, storageConfig config.SubConfig) (removed int, attempts int, err error) {
+	logger := logger.TagContext(ctx, "Storage")
 
 	base, s := new(model, archivePath, storageConfig)
 
 	newFileKey := filepath.Base(archivePath)
 	logger.Info("=> Storage | " + storageConfig.Type)
-	err = s.open()
+	attempts, err = upload(ctx, s, storageConfig, newFileKey)
 	if err != nil {
-		return err
+		return 0, attempts, err
 	}
 	defer s.close()
 
-	err = s.upload(newFileKey)
-	if err != nil {
+	pkg := Package{
+		FileKey:  newFileKey,
+		FileKeys: base.fileKeys,
+	}
+
+	if len(manifestPath) > 0 {
+		manifestKey := filepath.Base(manifestPath)
+		if err := uploadManifest(ctx, model, manifestPath, storageConfig); err != nil {
+			logger.Warnf("Upload manifest %s failed: %v", manifestKey, err)
+		} else {
+			pkg.ManifestKey = manifestKey
+		}
+	}
+
+	return base.cycler.run(ctx, pkg, base.retention, s), attempts, nil
+}
+
+func uploadManifest(ctx context.Context, model config.ModelConfig, manifestPath string, storageConfig config.SubConfig) error {
+	_, s := new(model, manifestPath, storageConfig)
+	if _, err := upload(ctx, s, storageConfig, filepath.Base(manifestPath)); err != nil {
 		return err
 	}
+	s.close()
 
-	base.cycler.run(newFileKey, base.fileKeys, base.keep, s.delete)
 	return nil
 }
 
-// Run storage
-func Run(model config.ModelConfig, archivePath string) (err error) {
-	var errors []error
+// Result of uploading the package to a storage
+type Result struct {
+	Name     string
+	Type     string
+	Duration time.Duration
+	// Removed is the number of the packages removed by the cycler
+	Removed int
+	// Attempts of the upload, it is more than 1 when retried
+	Attempts int
+	// FileKey of the package in the storage, it is empty when the upload is failed
+	FileKey string
+	Err     error
+}
 
-	n := len(model.Storages)
-	for _, storageConfig := range model.Storages {
-		err := runModel(model, archivePath, storageConfig)
-		if err != nil {
-			if n == 1 {
-				return err
-			} else {
-				errors = append(errors, err)
-				continue
-			}
+func (r Result) String() string {
+	duration := r.Duration.Round(time.Millisecond).String()
+	if r.Attempts > 1 {
+		duration += fmt.Sprintf(" in %d attempts", r.Attempts)
+	}
+
+	if r.Err != nil {
+		return fmt.Sprintf("%s (%s): failed after %s, %v", r.Name, r.Type, duration, r.Err)
+	}
+
+	return fmt.Sprintf("%s (%s): %s", r.Name, r.Type, duration)
+}
+
+// Results of the storages in name order
+type Results []Result
+
+func (results Results) String() string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	lines := []string{"Storages:"}
+	for _, r := range results {
+		lines = append(lines, "- "+r.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err return the errors of the failed storages, when there is only one storage, its error is returned directly.
+func (results Results) Err() error {
+	var errors []error
+	for _, r := range results {
+		if r.Err != nil {
+			errors = append(errors, r.Err)
 		}
 	}
 
-	if len(errors) != 0 {
-		return fmt.Errorf("Storage errors: %v", errors)
+	if len(errors) == 0 {
+		return nil
+	}
+	if len(results) == 1 {
+		return errors[0]
 	}
 
-	return nil
+	return fmt.Errorf("Storage errors: %v", errors)
+}
+
+// Run storage, upload the package in archivePath with the manifest in manifestPath (optional).
+//
+// The storages are uploaded at the same time, at most `max_parallel_storages` of them when it is set.
+// The uploads are stopped when ctx is done.
+func Run(ctx context.Context, model config.ModelConfig, archivePath string, manifestPath string) (Results, error) {
+	names := make([]string, 0, len(model.Storages))
+	for name := range model.Storages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	limit := model.MaxParallelStorages
+	if limit <= 0 || limit > len(names) {
+		limit = len(names)
+	}
+	sem := make(chan struct{}, limit)
+
+	results := make(Results, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		storageConfig := model.Storages[name]
+		results[i] = Result{Name: name, Type: storageConfig.Type}
+
+		wg.Add(1)
+		go func(r *Result, storageConfig config.SubConfig) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			startedAt := time.Now()
+			defer func() {
+				if p := recover(); p != nil {
+					r.Err = fmt.Errorf("%v", p)
+				}
+				r.Duration = time.Since(startedAt)
+			}()
+
+			r.Removed, r.Attempts, r.Err = runModel(ctx, model, archivePath, manifestPath, storageConfig)
+			if r.Err == nil {
+				r.FileKey = filepath.Base(archivePath)
+			}
+		}(&results[i], storageConfig)
+	}
+	wg.Wait()
+
+	return results, results.Err()
 }
 
 // List return file list of storage
@@ -277,3 +438,170 @@ func Download(model config.ModelConfig, fileKey string) (*DownloadResult, error)
 
 	return nil, fmt.Errorf("Storage %s not found", model.DefaultStorage)
 }
+
+// Fetch download the package of fileKey from storage into targetDir, and return the local path.
+//
+// When the package was split into chunks, fileKey is the directory and all the chunks
+// recorded in the cycler will be downloaded into the same directory under targetDir.
+func Fetch(model config.ModelConfig, storageName string, fileKey string, targetDir string) (string, error) {
+	logger := logger.Tag("Storage")
+
+	if len(storageName) == 0 {
+		storageName = model.DefaultStorage
+	}
+
+	storageConfig, ok := model.Storages[storageName]
+	if !ok {
+		return "", fmt.Errorf("Storage %s not found", storageName)
+	}
+
+	base, s := new(model, "", storageConfig)
+	if s == nil {
+		return "", fmt.Errorf("Storage %s is not supported", storageConfig.Type)
+	}
+	if err := s.open(); err != nil {
+		return "", err
+	}
+	defer s.close()
+
+	fileKey = strings.TrimSuffix(fileKey, "/")
+	chunks := []string{}
+	if pkg := base.cycler.find(fileKey); pkg != nil {
+		chunks = pkg.FileKeys
+	} else if m, err := fetchManifest(s, fileKey); err == nil {
+		// The cycler state is not on this host, use the chunks in the manifest
+		chunks = m.Chunks
+	}
+
+	if len(chunks) == 0 {
+		targetPath := filepath.Join(targetDir, path.Base(fileKey))
+		logger.Info("-> Fetching", fileKey)
+		if err := fetchFile(s, fileKey, targetPath); err != nil {
+			return "", err
+		}
+		return targetPath, nil
+	}
+
+	// 2022.12.04.07.09.47/2022.12.04.07.09.47.tar.xz-000
+	parent := path.Dir(fileKey)
+	for _, key := range chunks {
+		remoteKey := path.Join(parent, key)
+		logger.Info("-> Fetching", remoteKey)
+		if err := fetchFile(s, remoteKey, filepath.Join(targetDir, key)); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(targetDir, path.Base(fileKey)), nil
+}
+
+// relativeKey return the key listed by the object storages relative to the storage path,
+// as the file keys of all the storages are relative to it.
+func relativeKey(storagePath string, key string) string {
+	prefix := strings.Trim(storagePath, "/")
+	key = strings.TrimPrefix(key, "/")
+	if len(prefix) == 0 {
+		return key
+	}
+
+	return strings.TrimPrefix(key, prefix+"/")
+}
+
+// listPrefix return the prefix to list the objects in the directory key with a trailing slash,
+// so that the objects in the other directories with the same prefix (e.g. backups-old) are not listed.
+func listPrefix(key string) string {
+	key = strings.TrimSuffix(key, "/")
+	if len(key) == 0 {
+		return ""
+	}
+
+	return key + "/"
+}
+
+// fetchManifest download the manifest of the package fileKey
+func fetchManifest(s Storage, fileKey string) (*manifest.Manifest, error) {
+	reader, err := openFile(s, manifest.Path(fileKey))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return manifest.Parse(reader)
+}
+
+// openFile open a reader of fileKey, the redirect URL will be followed
+func openFile(s Storage, fileKey string) (io.ReadCloser, error) {
+	result, err := s.download(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("empty download result")
+	}
+
+	// The Reader is closed by the cleanup of DownloadResult
+	if result.Reader != nil {
+		return &downloadReader{Reader: result.Reader, result: result}, nil
+	}
+
+	if len(result.RedirectURL) == 0 {
+		result.Close()
+		return nil, fmt.Errorf("download is not available for file: %s", fileKey)
+	}
+
+	resp, err := http.Get(result.RedirectURL)
+	if err != nil {
+		result.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		result.Close()
+		return nil, fmt.Errorf("download %s failed with status: %s", fileKey, resp.Status)
+	}
+
+	return &downloadReader{Reader: resp.Body, body: resp.Body, result: result}, nil
+}
+
+// downloadReader close the DownloadResult together with the reader
+type downloadReader struct {
+	io.Reader
+	body   io.Closer
+	result *DownloadResult
+}
+
+func (r *downloadReader) Close() error {
+	var err error
+	if r.body != nil {
+		err = r.body.Close()
+	}
+	if cleanupErr := r.result.Close(); cleanupErr != nil && err == nil {
+		err = cleanupErr
+	}
+	return err
+}
+
+func fetchFile(s Storage, fileKey string, targetPath string) error {
+	reader, err := openFile(s, fileKey)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0750); err != nil {
+		return err
+	}
+
+	f, err := os.Create(targetPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, reader); err != nil {
+		return fmt.Errorf("download %s failed: %w", fileKey, err)
+	}
+
+	return nil
+}
@@ -1,10 +1,20 @@
 package storage
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/helper"
+	"github.com/gobackup/gobackup/manifest"
 	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
 )
 
 func TestBase_newBase(t *testing.T) {
@@ -15,5 +25,233 @@ func TestBase_newBase(t *testing.T) {
 	assert.Equal(t, s.archivePath, archivePath)
 	assert.Equal(t, s.model, model)
 	assert.Equal(t, s.viper, model.Viper)
-	assert.Equal(t, s.keep, 0)
+	assert.Equal(t, s.retention, Retention{})
+
+	storageConfig := config.SubConfig{Viper: viper.New()}
+	storageConfig.Viper.Set("keep", 10)
+	storageConfig.Viper.Set("keep_daily", 7)
+	storageConfig.Viper.Set("keep_monthly", 12)
+	storageConfig.Viper.Set("max_age", "400d")
+	s, _ = newBase(model, archivePath, storageConfig)
+	assert.Equal(t, Retention{Keep: 10, KeepDaily: 7, KeepMonthly: 12, MaxAge: 400 * 24 * time.Hour}, s.retention)
+}
+
+func TestRun(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	archivePath := filepath.Join(t.TempDir(), "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello world"), 0640))
+
+	model := newStreamTestModel(t, "local1", "local2", "local3")
+	model.MaxParallelStorages = 2
+
+	results, err := Run(context.Background(), model, archivePath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	for i, name := range []string{"local1", "local2", "local3"} {
+		assert.Equal(t, name, results[i].Name)
+		assert.Equal(t, "local", results[i].Type)
+		assert.NoError(t, results[i].Err)
+		assert.True(t, fileExists(filepath.Join(model.Storages[name].Viper.GetString("path"), "2022.12.04.07.09.25.tar.gz")))
+	}
+	assert.Contains(t, results.String(), "Storages:\n- local1 (local): ")
+
+	// a file blocks the storage path
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	assert.NoError(t, os.WriteFile(blocked, []byte{}, 0640))
+	model.Storages["local2"].Viper.Set("path", filepath.Join(blocked, "local2"))
+
+	results, err = Run(context.Background(), model, archivePath, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Storage errors: ")
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.Contains(t, results[1].String(), "local2 (local): failed after ")
+
+	// the error of the only storage is returned directly
+	model = newStreamTestModel(t, "local2")
+	model.Storages["local2"].Viper.Set("path", filepath.Join(blocked, "local2"))
+	results, err = Run(context.Background(), model, archivePath, "")
+	assert.Equal(t, results[0].Err, err)
+}
+
+func TestRun_Canceled(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	archivePath := filepath.Join(t.TempDir(), "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello world"), 0640))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	model := newStreamTestModel(t, "local1")
+	_, err := Run(ctx, model, archivePath, "")
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, fileExists(filepath.Join(model.Storages["local1"].Viper.GetString("path"), "2022.12.04.07.09.25.tar.gz")))
+}
+
+// flakyStorage fails the first failures uploads
+type flakyStorage struct {
+	Storage
+	failures int
+	// failedOpens are the opens which fail, from 1
+	failedOpens map[int]bool
+	opens       int
+	closes      int
+	uploads     int
+	discarded   bool
+}
+
+func (s *flakyStorage) open() error {
+	s.opens++
+	if s.failedOpens[s.opens] {
+		return fmt.Errorf("dial failed")
+	}
+	return nil
+}
+
+func (s *flakyStorage) close() {
+	s.closes++
+}
+
+func (s *flakyStorage) upload(ctx context.Context, fileKey string) error {
+	s.uploads++
+	if s.uploads <= s.failures {
+		return fmt.Errorf("connection reset")
+	}
+	return nil
+}
+
+func (s *flakyStorage) discardResume() {
+	s.discarded = true
+}
+
+func Test_upload(t *testing.T) {
+	storageConfig := config.SubConfig{
+		Name:  "s3",
+		Retry: helper.RetryPolicy{Attempts: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond},
+	}
+
+	s := &flakyStorage{failures: 2}
+	attempts, err := upload(context.Background(), s, storageConfig, "foo.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, s.opens)
+	// kept open for the cycler
+	assert.Equal(t, 2, s.closes)
+	assert.False(t, s.discarded)
+
+	s = &flakyStorage{failures: 3}
+	attempts, err = upload(context.Background(), s, storageConfig, "foo.tar.gz")
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, s.closes)
+	assert.True(t, s.discarded)
+
+	result := Result{Name: "s3", Type: "s3", Duration: 1500 * time.Millisecond, Attempts: attempts, Err: err}
+	assert.Equal(t, "s3 (s3): failed after 1.5s in 3 attempts, connection reset", result.String())
+
+	// the last attempt fails to open, it is reopened to discard the uploaded data
+	s = &flakyStorage{failures: 1, failedOpens: map[int]bool{2: true, 3: true}}
+	_, err = upload(context.Background(), s, storageConfig, "foo.tar.gz")
+	assert.EqualError(t, err, "dial failed")
+	assert.Equal(t, 4, s.opens)
+	assert.Equal(t, 2, s.closes)
+	assert.True(t, s.discarded)
+
+	// it is not discarded when it can not be reopened
+	s = &flakyStorage{failures: 1, failedOpens: map[int]bool{2: true, 3: true, 4: true}}
+	_, err = upload(context.Background(), s, storageConfig, "foo.tar.gz")
+	assert.EqualError(t, err, "dial failed")
+	assert.Equal(t, 4, s.opens)
+	assert.Equal(t, 1, s.closes)
+	assert.False(t, s.discarded)
+}
+
+// newS3TestModel return a model with the package and its manifest in the path of a fake S3 storage
+func newS3TestModel(t *testing.T, fileKey string, content string) config.ModelConfig {
+	t.Helper()
+
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	t.Cleanup(func() {
+		cyclerPath = originalCyclerPath
+	})
+
+	tempPath := t.TempDir()
+	modelViper := viper.New()
+	modelViper.Set("Ext", ".tar.gz")
+	model := config.ModelConfig{
+		Name:     "s3_test",
+		TempPath: tempPath,
+		DumpPath: filepath.Join(tempPath, "s3_test"),
+		Viper:    modelViper,
+	}
+
+	archivePath := filepath.Join(tempPath, fileKey)
+	assert.NoError(t, os.WriteFile(archivePath, []byte(content), 0640))
+	manifestPath, err := manifest.Write(model, archivePath, time.Now())
+	assert.NoError(t, err)
+
+	objects := map[string][]byte{}
+	for _, p := range []string{archivePath, manifestPath} {
+		data, err := os.ReadFile(p)
+		assert.NoError(t, err)
+		objects["/test-bucket/backups/"+filepath.Base(p)] = data
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	storageViper := viper.New()
+	storageViper.Set("bucket", "test-bucket")
+	storageViper.Set("path", "backups")
+	storageViper.Set("endpoint", server.URL)
+	storageViper.Set("access_key_id", "foo")
+	storageViper.Set("secret_access_key", "bar")
+	model.DefaultStorage = "s3"
+	model.Storages = map[string]config.SubConfig{
+		"s3": {Name: "s3", Type: "s3", Viper: storageViper},
+	}
+
+	return model
+}
+
+func TestFetch_S3WithPath(t *testing.T) {
+	model := newS3TestModel(t, "2022.12.04.07.09.25.tar.gz", "hello")
+
+	// the file key is relative to the path of the storage
+	targetPath, err := Fetch(model, "", "2022.12.04.07.09.25.tar.gz", t.TempDir())
+	assert.NoError(t, err)
+	data, err := os.ReadFile(targetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	result, err := Download(model, "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, err)
+	assert.Contains(t, result.RedirectURL, "/test-bucket/backups/2022.12.04.07.09.25.tar.gz")
+
+	_, err = Fetch(model, "", "backups/2022.12.04.07.09.25.tar.gz", t.TempDir())
+	assert.Error(t, err)
+}
+
+func Test_relativeKey(t *testing.T) {
+	assert.Equal(t, "2022.12.04.07.09.25.tar.gz", relativeKey("backups", "backups/2022.12.04.07.09.25.tar.gz"))
+	assert.Equal(t, "2022.12.04.07.09.47/2022.12.04.07.09.47.tar.gz-000", relativeKey("/data/backups/", "data/backups/2022.12.04.07.09.47/2022.12.04.07.09.47.tar.gz-000"))
+	assert.Equal(t, "2022.12.04.07.09.25.tar.gz", relativeKey("", "2022.12.04.07.09.25.tar.gz"))
 }
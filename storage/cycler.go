@@ -1,24 +1,30 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gobackup/gobackup/config"
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/spf13/viper"
 )
 
 type PackageList []Package
 
 // When `FileKeys` is not empty, `FileKey` is the directory
 type Package struct {
-	FileKey   string    `json:"file_key"`
-	FileKeys  []string  `json:"file_keys,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	FileKey     string    `json:"file_key"`
+	FileKeys    []string  `json:"file_keys,omitempty"`
+	ManifestKey string    `json:"manifest_key,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 var (
@@ -32,56 +38,272 @@ type Cycler struct {
 }
 
 func (c *Cycler) add(fileKey string, fileKeys []string) {
-	c.packages = append(c.packages, Package{
-		FileKey:   fileKey,
-		FileKeys:  fileKeys,
-		CreatedAt: time.Now(),
+	c.push(Package{
+		FileKey:  fileKey,
+		FileKeys: fileKeys,
 	})
 }
 
-func (c *Cycler) shiftByKeep(keep int) (first *Package) {
-	total := len(c.packages)
-	if total <= keep {
-		return nil
-	}
+func (c *Cycler) push(pkg Package) {
+	pkg.CreatedAt = time.Now()
+	c.packages = append(c.packages, pkg)
+}
 
-	first, c.packages = &c.packages[0], c.packages[1:]
-	return
+// Retention policy of the packages, a package is kept when any of the rules keeps it.
+//
+// - keep: the newest N packages
+// - keep_daily, keep_weekly, keep_monthly, keep_yearly: the newest package of each of the last N days, weeks, months, years
+// - max_age: remove the packages older than it, even they are kept by the rules above
+//
+// The packages are the ones recorded in the cycler, or listed from the storage with `retention_source: remote`.
+// With `retention_dry_run: true`, the expired packages are only logged.
+type Retention struct {
+	Keep        int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+	MaxAge      time.Duration
+	Remote      bool
+	DryRun      bool
+	// FilenameFormat to parse the created time of the remote packages
+	FilenameFormat string
+	// Ext of the packages of the model, to ignore the other files in the remote
+	Ext string
 }
 
-func (c *Cycler) run(fileKey string, fileKeys []string, keep int, deletePackage func(fileKey string) error) {
+func newRetention(model config.ModelConfig, v *viper.Viper) Retention {
 	logger := logger.Tag("Cycler")
 
+	r := Retention{
+		Keep:        v.GetInt("keep"),
+		KeepDaily:   v.GetInt("keep_daily"),
+		KeepWeekly:  v.GetInt("keep_weekly"),
+		KeepMonthly: v.GetInt("keep_monthly"),
+		KeepYearly:  v.GetInt("keep_yearly"),
+		DryRun:      v.GetBool("retention_dry_run"),
+	}
+
+	switch source := v.GetString("retention_source"); source {
+	case "", "local":
+	case "remote":
+		r.Remote = true
+		r.FilenameFormat = "2006.01.02.15.04.05"
+		if model.CompressWith.Viper != nil && len(model.CompressWith.Viper.GetString("filename_format")) > 0 {
+			r.FilenameFormat = model.CompressWith.Viper.GetString("filename_format")
+		}
+		// the storages are opened after the package is archived, so the extension is known
+		if model.Viper != nil {
+			r.Ext = model.Viper.GetString("Ext")
+		}
+	default:
+		logger.Warnf("Ignore invalid retention_source: %s", source)
+	}
+
+	if maxAge := v.GetString("max_age"); len(maxAge) > 0 {
+		d, err := helper.ParseDuration(maxAge)
+		if err != nil || d <= 0 {
+			logger.Warnf("Ignore invalid max_age: %s", maxAge)
+		} else {
+			r.MaxAge = d
+		}
+	}
+
+	return r
+}
+
+// hasRules return false when no retention is configured, all the packages are kept
+func (r Retention) hasRules() bool {
+	return r.Keep > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 || r.KeepMonthly > 0 || r.KeepYearly > 0 || r.MaxAge > 0
+}
+
+// hasCountRules return true when any of the keep rules is configured
+func (r Retention) hasCountRules() bool {
+	return r.Keep > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 || r.KeepMonthly > 0 || r.KeepYearly > 0
+}
+
+// expired return the packages to remove at now, the order of packages is kept
+func (r Retention) expired(packages PackageList, now time.Time) (kept PackageList, expired PackageList) {
+	if !r.hasRules() {
+		return packages, nil
+	}
+
+	// newest first
+	order := make([]int, len(packages))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return packages[order[a]].CreatedAt.After(packages[order[b]].CreatedAt)
+	})
+
+	keep := make([]bool, len(packages))
+	if r.hasCountRules() {
+		// The newest package in each bucket is kept, `keep` has no bucket and counts every package
+		rules := []struct {
+			count  int
+			bucket func(t time.Time) string
+		}{
+			{r.Keep, nil},
+			{r.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+			{r.KeepWeekly, func(t time.Time) string {
+				year, week := t.ISOWeek()
+				return fmt.Sprintf("%d-W%02d", year, week)
+			}},
+			{r.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+			{r.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+		}
+
+		for _, rule := range rules {
+			count, last := rule.count, ""
+			for _, i := range order {
+				if count <= 0 {
+					break
+				}
+				if rule.bucket == nil {
+					keep[i] = true
+					count--
+					continue
+				}
+				if bucket := rule.bucket(packages[i].CreatedAt.Local()); bucket != last {
+					keep[i] = true
+					count--
+					last = bucket
+				}
+			}
+		}
+	} else {
+		for i := range keep {
+			keep[i] = true
+		}
+	}
+
+	for i, pkg := range packages {
+		if r.MaxAge > 0 && now.Sub(pkg.CreatedAt) > r.MaxAge {
+			keep[i] = false
+		}
+	}
+	// never remove the newest package
+	if len(order) > 0 {
+		keep[order[0]] = true
+	}
+
+	for i, pkg := range packages {
+		if keep[i] {
+			kept = append(kept, pkg)
+		} else {
+			expired = append(expired, pkg)
+		}
+	}
+	return kept, expired
+}
+
+// run the retention after pkg is stored, return the number of the removed packages
+func (c *Cycler) run(ctx context.Context, pkg Package, retention Retention, s Storage) (removed int) {
+	logger := logger.TagContext(ctx, "Cycler")
+
 	cyclerFileName := filepath.Join(cyclerPath, c.name+".json")
 
 	c.load(cyclerFileName)
-	c.add(fileKey, fileKeys)
+	c.push(pkg)
 	defer c.save(cyclerFileName)
 
-	if keep == 0 {
+	if !retention.hasRules() {
 		return
 	}
 
-	for {
-		pkg := c.shiftByKeep(keep)
-		if pkg == nil {
-			break
+	var kept, expired PackageList
+	if retention.Remote {
+		packages, err := remotePackages(s, retention.FilenameFormat, retention.Ext)
+		if err != nil {
+			logger.Warnf("List remote packages failed, skip retention: %v", err)
+			return
 		}
+		_, expired = retention.expired(packages, time.Now())
+	} else {
+		kept, expired = retention.expired(c.packages, time.Now())
+	}
 
-		fk := pkg.FileKey
-		if len(pkg.FileKeys) != 0 && !strings.HasSuffix(fk, "/") {
-			fk += "/"
-		}
-		for _, k := range append(pkg.FileKeys, fk) {
-			// deletePackage() should handle directory case which has `/` suffix
-			err := deletePackage(k)
+	for _, pkg := range expired {
+		failed := false
+		for _, k := range pkg.keys() {
+			if retention.DryRun {
+				logger.Infof("Would remove %s (dry run)", k)
+				continue
+			}
+
+			// delete() should handle directory case which has `/` suffix
+			err := s.delete(k)
 			if err != nil {
 				logger.Warnf("Remove %s failed: %v", k, err)
+				failed = true
 			} else {
 				logger.Info("Removed", k)
 			}
 		}
+		if !retention.DryRun && !failed {
+			removed++
+		}
+	}
+
+	if retention.DryRun {
+		return
+	}
+	if retention.Remote {
+		// forget the removed packages
+		removedKeys := map[string]bool{}
+		for _, pkg := range expired {
+			removedKeys[path.Base(pkg.FileKey)] = true
+		}
+		for _, pkg := range c.packages {
+			if !removedKeys[path.Base(pkg.FileKey)] {
+				kept = append(kept, pkg)
+			}
+		}
+	}
+	c.packages = kept
+	return
+}
+
+// keys return the keys to delete the package, the chunks first, then the directory and the manifest
+func (pkg Package) keys() []string {
+	var keys []string
+
+	if len(pkg.FileKey) > 0 {
+		fk := pkg.FileKey
+		if len(pkg.FileKeys) != 0 && !strings.HasSuffix(fk, "/") {
+			fk += "/"
+		}
+		keys = append(append(keys, pkg.FileKeys...), fk)
+	}
+	if len(pkg.ManifestKey) > 0 {
+		keys = append(keys, pkg.ManifestKey)
 	}
+
+	return keys
+}
+
+// list the packages from the saved cycler state
+func (c *Cycler) list() PackageList {
+	if !c.isLoaded {
+		c.load(filepath.Join(cyclerPath, c.name+".json"))
+	}
+
+	return c.packages
+}
+
+// find the package of fileKey from the saved cycler state
+func (c *Cycler) find(fileKey string) *Package {
+	c.list()
+
+	fileKey = strings.TrimSuffix(fileKey, "/")
+	for i := range c.packages {
+		if c.packages[i].FileKey == fileKey || path.Base(c.packages[i].FileKey) == path.Base(fileKey) {
+			return &c.packages[i]
+		}
+	}
+
+	return nil
 }
 
 func (c *Cycler) load(cyclerFileName string) {
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -15,16 +18,17 @@ func TestCycler_add(t *testing.T) {
 	assert.Equal(t, len(cycler.packages), 2)
 }
 
-func TestCycler_shiftByKeep(t *testing.T) {
+func TestRetention_keep(t *testing.T) {
+	now := time.Now()
 	cycler := Cycler{
 		packages: PackageList{
 			Package{
 				FileKey:   "p1",
-				CreatedAt: time.Now(),
+				CreatedAt: now.Add(-time.Minute),
 			},
 			Package{
 				FileKey:   "p2",
-				CreatedAt: time.Now(),
+				CreatedAt: now.Add(-time.Minute),
 			},
 		},
 	}
@@ -33,13 +37,143 @@ func TestCycler_shiftByKeep(t *testing.T) {
 	cycler.add("p5", []string{})
 	cycler.add("p6", []string{})
 
-	pkg := cycler.shiftByKeep(2)
-	assert.Equal(t, len(cycler.packages), 5)
-	assert.Equal(t, pkg.FileKey, "p1")
-	pkg = cycler.shiftByKeep(2)
-	assert.Equal(t, len(cycler.packages), 4)
-	assert.Equal(t, pkg.FileKey, "p2")
-	pkg = cycler.shiftByKeep(4)
-	assert.Equal(t, len(cycler.packages), 4)
-	assert.Nil(t, pkg)
+	kept, expired := Retention{Keep: 2}.expired(cycler.packages, now)
+	assert.Equal(t, []string{"p5", "p6"}, fileKeys(kept))
+	assert.Equal(t, []string{"p1", "p2", "p3", "p4"}, fileKeys(expired))
+
+	kept, expired = Retention{Keep: 6}.expired(cycler.packages, now)
+	assert.Equal(t, 6, len(kept))
+	assert.Equal(t, 0, len(expired))
+
+	kept, expired = Retention{}.expired(cycler.packages, now)
+	assert.Equal(t, 6, len(kept))
+	assert.Equal(t, 0, len(expired))
+}
+
+func TestRetention_gfs(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+
+	// two packages every day in the last 400 days
+	var packages PackageList
+	for i := 400; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i)
+		packages = append(packages,
+			Package{FileKey: day.Format("2006.01.02") + ".am", CreatedAt: day.Add(-6 * time.Hour)},
+			Package{FileKey: day.Format("2006.01.02") + ".pm", CreatedAt: day},
+		)
+	}
+
+	kept, expired := Retention{KeepDaily: 3}.expired(packages, now)
+	assert.Equal(t, []string{"2024.03.13.pm", "2024.03.14.pm", "2024.03.15.pm"}, fileKeys(kept))
+	assert.Equal(t, len(packages)-3, len(expired))
+
+	kept, _ = Retention{KeepWeekly: 3}.expired(packages, now)
+	// 2024-03-15 is Friday, the newest of the previous weeks is Sunday
+	assert.Equal(t, []string{"2024.03.03.pm", "2024.03.10.pm", "2024.03.15.pm"}, fileKeys(kept))
+
+	kept, _ = Retention{KeepMonthly: 3}.expired(packages, now)
+	assert.Equal(t, []string{"2024.01.31.pm", "2024.02.29.pm", "2024.03.15.pm"}, fileKeys(kept))
+
+	kept, _ = Retention{KeepYearly: 5}.expired(packages, now)
+	assert.Equal(t, []string{"2023.12.31.pm", "2024.03.15.pm"}, fileKeys(kept))
+
+	// the rules are combined, a package kept by any of them is kept
+	kept, _ = Retention{Keep: 1, KeepDaily: 2, KeepMonthly: 2}.expired(packages, now)
+	assert.Equal(t, []string{"2024.02.29.pm", "2024.03.14.pm", "2024.03.15.pm"}, fileKeys(kept))
+
+	// max_age removes the older packages even they are kept by the rules
+	kept, _ = Retention{KeepMonthly: 12, MaxAge: 30 * 24 * time.Hour}.expired(packages, now)
+	assert.Equal(t, []string{"2024.02.29.pm", "2024.03.15.pm"}, fileKeys(kept))
+
+	kept, expired = Retention{MaxAge: 36 * time.Hour}.expired(packages, now)
+	assert.Equal(t, []string{"2024.03.14.am", "2024.03.14.pm", "2024.03.15.am", "2024.03.15.pm"}, fileKeys(kept))
+	assert.Equal(t, len(packages)-4, len(expired))
+
+	// the newest package is never removed
+	kept, _ = Retention{MaxAge: time.Hour}.expired(packages, now.AddDate(1, 0, 0))
+	assert.Equal(t, []string{"2024.03.15.pm"}, fileKeys(kept))
+}
+
+func TestCycler_run(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	cycler := Cycler{name: "test"}
+	assert.NoError(t, os.WriteFile(filepath.Join(cyclerPath, "test.json"), []byte(`[
+		{"file_key": "2022.12.04.07.09.25.tar.xz", "manifest_key": "2022.12.04.07.09.25.manifest.json", "created_at": "2022-12-04T07:09:25Z"},
+		{"file_key": "2022.12.05.07.09.47", "file_keys": ["2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-000"], "created_at": "2022-12-05T07:09:47Z"}
+	]`), 0660))
+
+	s := &fakeStorage{}
+	removed := cycler.run(context.Background(), Package{FileKey: "2022.12.06.07.09.25.tar.xz"}, Retention{KeepDaily: 1, MaxAge: 24 * time.Hour, DryRun: true}, s)
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, 0, len(s.deleted))
+	assert.Equal(t, 3, len(cycler.packages))
+
+	cycler = Cycler{name: "test"}
+	removed = cycler.run(context.Background(), Package{FileKey: "2022.12.07.07.09.25.tar.xz"}, Retention{KeepDaily: 1, MaxAge: 24 * time.Hour}, s)
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, []string{
+		"2022.12.04.07.09.25.tar.xz",
+		"2022.12.04.07.09.25.manifest.json",
+		"2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-000",
+		"2022.12.05.07.09.47/",
+		"2022.12.06.07.09.25.tar.xz",
+	}, s.deleted)
+	assert.Equal(t, []string{"2022.12.07.07.09.25.tar.xz"}, fileKeys(cycler.packages))
+
+	// saved
+	cycler = Cycler{name: "test"}
+	assert.Equal(t, []string{"2022.12.07.07.09.25.tar.xz"}, fileKeys(cycler.list()))
+}
+
+type fakeStorage struct {
+	Storage
+	items   []FileItem
+	deleted []string
+}
+
+func (s *fakeStorage) list(parent string) ([]FileItem, error) {
+	return s.items, nil
+}
+
+func (s *fakeStorage) delete(fileKey string) error {
+	s.deleted = append(s.deleted, fileKey)
+	return nil
+}
+
+func fileKeys(packages PackageList) []string {
+	keys := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		keys = append(keys, pkg.FileKey)
+	}
+	return keys
+}
+
+func TestCycler_find(t *testing.T) {
+	cycler := Cycler{
+		isLoaded: true,
+		packages: PackageList{
+			Package{
+				FileKey:   "2022.12.04.07.09.25.tar.xz",
+				CreatedAt: time.Now(),
+			},
+			Package{
+				FileKey:   "2022.12.04.07.09.47",
+				FileKeys:  []string{"2022.12.04.07.09.47/2022.12.04.07.09.47.tar.xz-000", "2022.12.04.07.09.47/2022.12.04.07.09.47.tar.xz-001"},
+				CreatedAt: time.Now(),
+			},
+		},
+	}
+
+	pkg := cycler.find("2022.12.04.07.09.25.tar.xz")
+	assert.Equal(t, "2022.12.04.07.09.25.tar.xz", pkg.FileKey)
+
+	pkg = cycler.find("backups/2022.12.04.07.09.47/")
+	assert.Equal(t, 2, len(pkg.FileKeys))
+
+	assert.Nil(t, cycler.find("foo.tar.gz"))
 }
@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net/textproto"
 	"os"
 	"path"
@@ -116,8 +118,8 @@ func (s *FTP) mkdir(rpath string) error {
 	return nil
 }
 
-func (s *FTP) upload(fileKey string) error {
-	logger := logger.Tag("FTP")
+func (s *FTP) upload(ctx context.Context, fileKey string) error {
+	logger := logger.TagContext(ctx, "FTP")
 	logger.Info("-> Uploading...")
 
 	var fileKeys []string
@@ -150,7 +152,7 @@ func (s *FTP) upload(fileKey string) error {
 		defer f.Close()
 
 		progress := helper.NewProgressBar(logger, f)
-		if err := s.client.Stor(remotePath, progress.Reader); err != nil {
+		if err := s.client.Stor(remotePath, helper.ContextReader(ctx, progress.Reader)); err != nil {
 			return progress.Errorf("upload failed %v", err)
 		}
 		progress.Done(remotePath)
@@ -160,6 +162,25 @@ func (s *FTP) upload(fileKey string) error {
 	return nil
 }
 
+// uploadStream write the reader into fileKey
+func (s *FTP) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, "FTP")
+
+	remotePath := filepath.Join(s.path, fileKey)
+	if dir := filepath.Dir(fileKey); dir != "." {
+		if err := s.mkdir(filepath.Dir(remotePath)); err != nil {
+			return err
+		}
+	}
+
+	if err := s.client.Stor(remotePath, helper.ContextReader(ctx, r)); err != nil {
+		return fmt.Errorf("upload failed %v", err)
+	}
+	logger.Info("->", remotePath)
+
+	return nil
+}
+
 func (s *FTP) delete(fileKey string) error {
 	logger := logger.Tag("FTP")
 	remotePath := path.Join(s.path, fileKey)
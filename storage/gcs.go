@@ -5,14 +5,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
-	"golang.org/x/oauth2/google"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -77,10 +78,9 @@ func (s *GCS) close() {
 	s.client.Close()
 }
 
-func (s *GCS) upload(fileKey string) (err error) {
-	logger := logger.Tag("GCS")
+func (s *GCS) upload(ctx context.Context, fileKey string) (err error) {
+	logger := logger.TagContext(ctx, "GCS")
 
-	var ctx = context.Background()
 	var cancel context.CancelFunc
 
 	if s.timeout.Seconds() > 0 {
@@ -102,7 +102,7 @@ func (s *GCS) upload(fileKey string) (err error) {
 
 	for _, key := range fileKeys {
 		sourcePath := filepath.Join(filepath.Dir(s.archivePath), key)
-		remotePath := filepath.Join(s.path, key)
+		remotePath := s.objectKey(key)
 
 		// Open file
 		f, err := os.Open(sourcePath)
@@ -127,10 +127,35 @@ func (s *GCS) upload(fileKey string) (err error) {
 	return nil
 }
 
+// uploadStream write the reader into fileKey
+func (s *GCS) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, "GCS")
+
+	var cancel context.CancelFunc
+	if s.timeout.Seconds() > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	remotePath := s.objectKey(fileKey)
+	object := s.client.Bucket(s.bucket).Object(remotePath).If(storage.Conditions{DoesNotExist: true})
+	writer := object.NewWriter(ctx)
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return fmt.Errorf("GCS upload error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("GCS upload Writer.Close: %v", err)
+	}
+	logger.Info("->", remotePath)
+
+	return nil
+}
+
 func (s *GCS) delete(fileKey string) (err error) {
 	// No need to remove empty directory
 	if !strings.HasSuffix(fileKey, "/") {
-		remotePath := filepath.Join(s.path, fileKey)
+		remotePath := s.objectKey(fileKey)
 		object := s.client.Bucket(s.bucket).Object(remotePath)
 		if err = object.Delete(context.Background()); err != nil {
 			return fmt.Errorf("GCS failed to delete file %q, %v", remotePath, err)
@@ -143,7 +168,7 @@ func (s *GCS) delete(fileKey string) (err error) {
 // List all files in the bucket
 func (s *GCS) list(parent string) ([]FileItem, error) {
 	var files []FileItem
-	remotePath := filepath.Join(s.path, parent)
+	remotePath := listPrefix(s.objectKey(parent))
 
 	it := s.client.Bucket(s.bucket).Objects(context.Background(), &storage.Query{Prefix: remotePath})
 	for {
@@ -156,7 +181,7 @@ func (s *GCS) list(parent string) ([]FileItem, error) {
 		}
 
 		file := FileItem{
-			Filename:     attrs.Name,
+			Filename:     relativeKey(s.path, attrs.Name),
 			Size:         attrs.Size,
 			LastModified: attrs.Created,
 		}
@@ -169,7 +194,7 @@ func (s *GCS) list(parent string) ([]FileItem, error) {
 
 // Generate a sign URL for download
 func (s *GCS) download(fileKey string) (*DownloadResult, error) {
-	url, err := s.client.Bucket(s.bucket).SignedURL(fileKey, &storage.SignedURLOptions{
+	url, err := s.client.Bucket(s.bucket).SignedURL(s.objectKey(fileKey), &storage.SignedURLOptions{
 		Expires: time.Now().Add(time.Hour * 1),
 	})
 	if err != nil {
@@ -178,3 +203,8 @@ func (s *GCS) download(fileKey string) (*DownloadResult, error) {
 
 	return &DownloadResult{RedirectURL: url}, nil
 }
+
+// objectKey return the name of the object in the bucket, fileKey is relative to path
+func (s *GCS) objectKey(fileKey string) string {
+	return path.Join(s.path, fileKey)
+}
@@ -1,11 +1,15 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/gobackup/gobackup/helper"
 	"github.com/gobackup/gobackup/logger"
@@ -27,8 +31,8 @@ func (s *Local) open() error {
 
 func (s *Local) close() {}
 
-func (s *Local) upload(fileKey string) (err error) {
-	logger := logger.Tag("Local")
+func (s *Local) upload(ctx context.Context, fileKey string) (err error) {
+	logger := logger.TagContext(ctx, "Local")
 
 	// Related path
 	if !path.IsAbs(s.path) {
@@ -41,7 +45,7 @@ func (s *Local) upload(fileKey string) (err error) {
 		logger.Errorf("failed to mkdir %q, %v", targetDir, err)
 	}
 
-	_, err = helper.Exec("cp", "-a", s.archivePath, targetPath)
+	_, err = helper.ExecContext(ctx, "cp", "-a", s.archivePath, targetPath)
 	if err != nil {
 		return err
 	}
@@ -49,6 +53,38 @@ func (s *Local) upload(fileKey string) (err error) {
 	return nil
 }
 
+// uploadStream write the reader into fileKey
+func (s *Local) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, "Local")
+
+	// Related path
+	if !path.IsAbs(s.path) {
+		s.path = path.Join(s.model.WorkDir, s.path)
+	}
+
+	targetPath := path.Join(s.path, fileKey)
+	if err := helper.MkdirP(path.Dir(targetPath)); err != nil {
+		return err
+	}
+
+	f, err := os.Create(targetPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, helper.ContextReader(ctx, r)); err != nil {
+		f.Close()
+		os.Remove(targetPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	logger.Info("Store succeeded", targetPath)
+	return nil
+}
+
 func (s *Local) delete(fileKey string) (err error) {
 	targetPath := filepath.Join(s.path, fileKey)
 	logger.Info("Deleting", targetPath)
@@ -80,5 +116,48 @@ func (s *Local) list(parent string) ([]FileItem, error) {
 }
 
 func (s *Local) download(fileKey string) (*DownloadResult, error) {
-	return nil, fmt.Errorf("Local is not support download")
+	// Related path
+	if !path.IsAbs(s.path) {
+		s.path = path.Join(s.model.WorkDir, s.path)
+	}
+
+	targetPath, err := s.filePath(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	filename := filepath.Base(fileKey)
+	return &DownloadResult{
+		Reader:      f,
+		Filename:    filename,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(filename)),
+		cleanup:     f.Close,
+	}, nil
+}
+
+// filePath return the path of fileKey in the storage path, the keys out of it are rejected
+func (s *Local) filePath(fileKey string) (string, error) {
+	if filepath.IsAbs(fileKey) {
+		return "", fmt.Errorf("%w: %s is absolute", ErrInvalidFileKey, fileKey)
+	}
+
+	targetPath := filepath.Join(s.path, filepath.Clean(fileKey))
+	rel, err := filepath.Rel(s.path, targetPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is out of the storage path", ErrInvalidFileKey, fileKey)
+	}
+
+	return targetPath, nil
 }
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/longbridgeapp/assert"
+)
+
+func Test_Local_download(t *testing.T) {
+	dir := t.TempDir()
+	storagePath := filepath.Join(dir, "backups")
+	assert.NoError(t, os.MkdirAll(filepath.Join(storagePath, "2024"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(storagePath, "2024", "backup.tar"), []byte("backup"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0600))
+
+	s := &Local{path: storagePath}
+
+	result, err := s.download("2024/../2024/backup.tar")
+	assert.NoError(t, err)
+	assert.Equal(t, "backup.tar", result.Filename)
+	assert.NoError(t, result.Close())
+
+	for _, fileKey := range []string{"../secret", "2024/../../secret", filepath.Join(dir, "secret"), ".", ""} {
+		_, err := s.download(fileKey)
+		assert.True(t, errors.Is(err, ErrInvalidFileKey), fileKey)
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const manifestSuffix = ".manifest.json"
+
+// remotePackages build the packages from the listing of the storage, the created time is parsed
+// from the filename with format (the `filename_format` of `compress_with`), the other files are ignored.
+// Only the files directly in the storage path or in the directory of a split package are used,
+// and when ext is not empty, they must be named with the time and ext, as the packages of the model.
+//
+//	2022.12.04.07.09.25.tar.gz
+//	2022.12.04.07.09.25.tar.gz.manifest.json
+//	2022.12.04.07.09.47/2022.12.04.07.09.47.tar.gz-000
+//	2022.12.04.07.09.47.manifest.json
+func remotePackages(s Storage, format string, ext string) (PackageList, error) {
+	items, err := s.list("/")
+	if err != nil {
+		return nil, err
+	}
+
+	packages := map[string]*Package{}
+	hasData := map[string]bool{}
+	get := func(fileKey string, createdAt time.Time) *Package {
+		if _, ok := packages[fileKey]; !ok {
+			packages[fileKey] = &Package{FileKey: fileKey, CreatedAt: createdAt}
+		}
+		return packages[fileKey]
+	}
+	// isPackageFile return true if the name is the time and ext, with any suffix for the chunks
+	isPackageFile := func(name string, n int, chunk bool) bool {
+		if len(ext) == 0 {
+			return true
+		}
+		if chunk {
+			return strings.HasPrefix(name[n:], ext)
+		}
+		return name[n:] == ext
+	}
+
+	for _, item := range items {
+		// The object storages list the keys recursively
+		name := strings.Trim(filepath.ToSlash(item.Filename), "/")
+		parts := strings.Split(name, "/")
+		if len(parts) > 2 {
+			continue
+		}
+		base := parts[len(parts)-1]
+
+		// chunks of the split package in the directory
+		if len(parts) == 2 {
+			dir := parts[0]
+			if createdAt, n := parseFilenameTime(dir, format); n > 0 && n == len(dir) && strings.HasPrefix(base, dir) && isPackageFile(base, n, true) {
+				pkg := get(dir, createdAt)
+				pkg.FileKeys = append(pkg.FileKeys, dir+"/"+base)
+				hasData[dir] = true
+			}
+			continue
+		}
+
+		createdAt, n := parseFilenameTime(base, format)
+		if n == 0 {
+			continue
+		}
+		if strings.HasSuffix(base, manifestSuffix) {
+			fileKey := strings.TrimSuffix(base, manifestSuffix)
+			// the manifest of a split package is named by the directory
+			if len(fileKey) == n || isPackageFile(fileKey, n, false) {
+				get(fileKey, createdAt).ManifestKey = base
+			}
+			continue
+		}
+		if !isPackageFile(base, n, false) {
+			continue
+		}
+		get(base, createdAt)
+		hasData[base] = true
+	}
+
+	var result PackageList
+	for fileKey, pkg := range packages {
+		if !hasData[fileKey] {
+			// The storages which only list files, find the chunks of the split package by its manifest
+			items, err := s.list(fileKey)
+			if err != nil {
+				continue
+			}
+			for _, item := range items {
+				name := filepath.ToSlash(item.Filename)
+				if dir := path.Dir(name); dir != "." && path.Base(dir) != fileKey {
+					continue
+				}
+				if base := path.Base(name); strings.HasPrefix(base, fileKey) && isPackageFile(base, len(fileKey), true) {
+					pkg.FileKeys = append(pkg.FileKeys, fileKey+"/"+base)
+				}
+			}
+			if len(pkg.FileKeys) == 0 {
+				continue
+			}
+		}
+		sort.Strings(pkg.FileKeys)
+		result = append(result, *pkg)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].FileKey < result[j].FileKey
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// parseFilenameTime parse the time at the beginning of the filename with format,
+// and return the length of the time part, 0 if not found
+func parseFilenameTime(filename string, format string) (time.Time, int) {
+	for n := len(filename); n > 0; n-- {
+		if t, err := time.ParseInLocation(format, filename[:n], time.Local); err == nil {
+			return t, n
+		}
+	}
+
+	return time.Time{}, 0
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestRemotePackages(t *testing.T) {
+	s := &fakeStorage{
+		items: []FileItem{
+			{Filename: "2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-001"},
+			{Filename: "2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-000"},
+			{Filename: "2022.12.05.07.09.47.manifest.json"},
+			{Filename: "2022.12.04.07.09.25.tar.xz.manifest.json"},
+			{Filename: "2022.12.04.07.09.25.tar.xz"},
+			{Filename: "2022.12.06.07.09.25.tar.xz"},
+			{Filename: "README.md"},
+			{Filename: "other/foo.tar.xz"},
+			// the packages of the other model in the same path, or in the sub directories
+			{Filename: "2022.12.07.07.09.25.tar.gz.enc"},
+			{Filename: "2022.12.07.07.09.25.tar.gz.enc.manifest.json"},
+			{Filename: "other/2022.12.08.07.09.25/2022.12.08.07.09.25.tar.xz-000"},
+			{Filename: "2022.12.09.07.09.25/notes.txt"},
+		},
+	}
+
+	packages, err := remotePackages(s, "2006.01.02.15.04.05", ".tar.xz")
+	assert.NoError(t, err)
+	assert.Equal(t, PackageList{
+		{
+			FileKey:     "2022.12.04.07.09.25.tar.xz",
+			ManifestKey: "2022.12.04.07.09.25.tar.xz.manifest.json",
+			CreatedAt:   time.Date(2022, 12, 4, 7, 9, 25, 0, time.Local),
+		},
+		{
+			FileKey:     "2022.12.05.07.09.47",
+			FileKeys:    []string{"2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-000", "2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-001"},
+			ManifestKey: "2022.12.05.07.09.47.manifest.json",
+			CreatedAt:   time.Date(2022, 12, 5, 7, 9, 47, 0, time.Local),
+		},
+		{
+			FileKey:   "2022.12.06.07.09.25.tar.xz",
+			CreatedAt: time.Date(2022, 12, 6, 7, 9, 25, 0, time.Local),
+		},
+	}, packages)
+
+	// without the extension, all the packages in the path are used
+	packages, err = remotePackages(s, "2006.01.02.15.04.05", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2022.12.04.07.09.25.tar.xz", "2022.12.05.07.09.47", "2022.12.06.07.09.25.tar.xz", "2022.12.07.07.09.25.tar.gz.enc"}, fileKeys(packages))
+
+	packages, err = remotePackages(s, "20060102", ".tar.xz")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(packages))
+}
+
+func Test_listPrefix(t *testing.T) {
+	assert.Equal(t, "backups/", listPrefix("backups"))
+	assert.Equal(t, "backups/2022.12.05.07.09.47/", listPrefix("backups/2022.12.05.07.09.47/"))
+	assert.Equal(t, "", listPrefix("/"))
+	assert.Equal(t, "", listPrefix(""))
+}
+
+func TestCycler_runRemote(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	storagePath := t.TempDir()
+	for _, name := range []string{
+		"2022.12.04.07.09.25.tar.xz",
+		"2022.12.04.07.09.25.tar.xz.manifest.json",
+		"2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-000",
+		"2022.12.05.07.09.47/2022.12.05.07.09.47.tar.xz-001",
+		"2022.12.05.07.09.47.manifest.json",
+		"2022.12.06.07.09.25.tar.xz",
+		"README.md",
+		// the package of the other model in the same path
+		"2022.12.03.07.09.25.tar.gz",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(storagePath, name)), 0750))
+		assert.NoError(t, os.WriteFile(filepath.Join(storagePath, name), []byte(name), 0640))
+	}
+
+	compressViper := viper.New()
+	compressViper.Set("filename_format", "2006.01.02.15.04.05")
+	storageViper := viper.New()
+	storageViper.Set("path", storagePath)
+	storageViper.Set("keep", 1)
+	storageViper.Set("retention_source", "remote")
+	storageViper.Set("retention_dry_run", true)
+	modelViper := viper.New()
+	modelViper.Set("Ext", ".tar.xz")
+	model := config.ModelConfig{
+		Name:         "remote_test",
+		Viper:        modelViper,
+		CompressWith: config.SubConfig{Type: "tar", Viper: compressViper},
+	}
+
+	base, s := new(model, "", config.SubConfig{Name: "local", Type: "local", Viper: storageViper})
+	assert.Equal(t, true, base.retention.Remote)
+	assert.NoError(t, s.open())
+
+	// the host has no cycler state
+	base.cycler.run(context.Background(), Package{FileKey: "2022.12.06.07.09.25.tar.xz"}, base.retention, s)
+	assert.True(t, fileExists(filepath.Join(storagePath, "2022.12.04.07.09.25.tar.xz")))
+	assert.True(t, fileExists(filepath.Join(storagePath, "2022.12.05.07.09.47.manifest.json")))
+
+	base.retention.DryRun = false
+	base.cycler.run(context.Background(), Package{FileKey: "2022.12.06.07.09.25.tar.xz"}, base.retention, s)
+
+	entries, err := os.ReadDir(storagePath)
+	assert.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"2022.12.03.07.09.25.tar.gz", "2022.12.06.07.09.25.tar.xz", "README.md"}, names)
+	assert.Equal(t, []string{"2022.12.06.07.09.25.tar.xz", "2022.12.06.07.09.25.tar.xz"}, fileKeys(base.cycler.list()))
+}
@@ -1,10 +1,13 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -38,6 +41,15 @@ type S3 struct {
 	client       *s3manager.Uploader
 	storageClass string
 	awsCfg       *aws.Config
+	// multipartUploads left by the failed attempts, keyed by remote path
+	multipartUploads map[string]*s3MultipartUpload
+}
+
+// s3MultipartUpload is a multipart upload in progress, the uploaded parts are kept,
+// so that the next attempt resumes from them after a failure.
+type s3MultipartUpload struct {
+	id    string
+	parts []*s3.CompletedPart
 }
 
 func (s S3) providerName() string {
@@ -260,8 +272,8 @@ func (s *S3) open() (err error) {
 func (s *S3) close() {
 }
 
-func (s *S3) upload(fileKey string) (err error) {
-	logger := logger.Tag(s.providerName())
+func (s *S3) upload(ctx context.Context, fileKey string) (err error) {
+	logger := logger.TagContext(ctx, s.providerName())
 
 	var fileKeys []string
 	if len(s.fileKeys) != 0 {
@@ -276,7 +288,7 @@ func (s *S3) upload(fileKey string) (err error) {
 
 	for _, key := range fileKeys {
 		sourcePath := filepath.Join(filepath.Dir(s.archivePath), key)
-		remotePath := filepath.Join(s.path, key)
+		remotePath := s.objectKey(key)
 
 		f, err := os.Open(sourcePath)
 		if err != nil {
@@ -284,6 +296,22 @@ func (s *S3) upload(fileKey string) (err error) {
 		}
 		defer f.Close()
 
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		// the large file is uploaded in parts, which is resumed by the next attempt after a failure
+		if partSize := s3PartSize(info.Size()); info.Size() > partSize {
+			if err := s.uploadParts(ctx, f, info.Size(), partSize, remotePath); err != nil {
+				return err
+			}
+			if s.Service == "s3" {
+				logger.Info("=>", fmt.Sprintf("s3://%s/%s", s.bucket, remotePath))
+			}
+			continue
+		}
+
 		progress := helper.NewProgressBar(logger, f)
 
 		input := &s3manager.UploadInput{
@@ -299,21 +327,11 @@ func (s *S3) upload(fileKey string) (err error) {
 			input.StorageClass = aws.String(s.storageClass)
 		}
 
-		result, err := s.client.Upload(input, func(uploader *s3manager.Uploader) {
-			// set the part size as low as possible to avoid timeouts and aborts
-			// also set concurrency to 1 for the same reason
-			var partSize int64 = 64 * 1024 * 1024 // 64MiB
-			maxParts := progress.FileLength / partSize
-
-			// 10000 parts is the limit for AWS S3. If the resulting number of parts would exceed that limit, increase the
-			// part size as much as needed but as little possible
-			if maxParts > 10000 {
-				partSize = int64(math.Ceil(float64(progress.FileLength) / 10000))
-			}
-
+		result, err := s.client.UploadWithContext(ctx, input, func(uploader *s3manager.Uploader) {
+			// set concurrency to 1 to avoid timeouts and aborts
 			uploader.Concurrency = 1
 			uploader.LeavePartsOnError = false
-			uploader.PartSize = partSize
+			uploader.PartSize = s3PartSize(progress.FileLength)
 		})
 
 		if err != nil {
@@ -330,8 +348,152 @@ func (s *S3) upload(fileKey string) (err error) {
 	return nil
 }
 
+// s3PartSize return the part size of the file, it is as low as possible to avoid timeouts and aborts
+func s3PartSize(fileLength int64) int64 {
+	var partSize int64 = 64 * 1024 * 1024 // 64MiB
+	maxParts := fileLength / partSize
+
+	// 10000 parts is the limit for AWS S3. If the resulting number of parts would exceed that limit, increase the
+	// part size as much as needed but as little possible
+	if maxParts > 10000 {
+		partSize = int64(math.Ceil(float64(fileLength) / 10000))
+	}
+
+	return partSize
+}
+
+// uploadParts upload the file in parts, the multipart upload of remotePath left by the last attempt is resumed
+func (s *S3) uploadParts(ctx context.Context, f *os.File, size int64, partSize int64, remotePath string) error {
+	logger := logger.TagContext(ctx, s.providerName())
+
+	if s.multipartUploads == nil {
+		s.multipartUploads = map[string]*s3MultipartUpload{}
+	}
+
+	upload := s.multipartUploads[remotePath]
+	if upload == nil {
+		input := &s3.CreateMultipartUploadInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(remotePath),
+		}
+		if len(s.storageClass) > 0 {
+			input.StorageClass = aws.String(s.storageClass)
+		}
+
+		out, err := s.client.S3.CreateMultipartUploadWithContext(ctx, input)
+		if err != nil {
+			return err
+		}
+		upload = &s3MultipartUpload{id: *out.UploadId}
+		s.multipartUploads[remotePath] = upload
+	} else {
+		logger.Infof("-> Resume uploading %s from part %d", remotePath, len(upload.parts)+1)
+	}
+
+	total := (size + partSize - 1) / partSize
+	for n := int64(len(upload.parts)) + 1; n <= total; n++ {
+		offset := (n - 1) * partSize
+		out, err := s.client.S3.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(s.bucket),
+			Key:        aws.String(remotePath),
+			UploadId:   aws.String(upload.id),
+			PartNumber: aws.Int64(n),
+			Body:       io.NewSectionReader(f, offset, min(partSize, size-offset)),
+		})
+		if err != nil {
+			return fmt.Errorf("upload part %d/%d of %s failed: %v", n, total, remotePath, err)
+		}
+		upload.parts = append(upload.parts, &s3.CompletedPart{ETag: out.ETag, PartNumber: aws.Int64(n)})
+		logger.Infof("-> Uploaded part %d/%d of %s", n, total, remotePath)
+	}
+
+	_, err := s.client.S3.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(remotePath),
+		UploadId:        aws.String(upload.id),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: upload.parts},
+	})
+	if err != nil {
+		return err
+	}
+	delete(s.multipartUploads, remotePath)
+
+	return nil
+}
+
+// discardResume abort the multipart uploads left by the failed attempts, so the parts are not charged
+func (s *S3) discardResume() {
+	logger := logger.Tag(s.providerName())
+
+	for remotePath, upload := range s.multipartUploads {
+		_, err := s.client.S3.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(remotePath),
+			UploadId: aws.String(upload.id),
+		})
+		if err != nil {
+			logger.Warnf("Abort multipart upload of %s failed: %v", remotePath, err)
+		}
+	}
+	s.multipartUploads = nil
+}
+
+// s3StreamPartSize is the part size of uploadStream
+const s3StreamPartSize = 64 * 1024 * 1024 // 64MiB
+
+// s3MaxStreamSize is the largest stream of uploadStream, 64MiB * 10000 parts = 625GiB
+var s3MaxStreamSize int64 = s3StreamPartSize * s3manager.MaxUploadParts
+
+// uploadStream upload the reader into fileKey with multipart upload, the size is unknown
+// so the part size is fixed to 64MiB, which allows packages up to 625GiB.
+func (s *S3) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, s.providerName())
+
+	remotePath := s.objectKey(fileKey)
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(remotePath),
+		Body:   &s3StreamReader{Reader: r, logger: logger, remotePath: remotePath},
+	}
+	if len(s.storageClass) > 0 {
+		input.StorageClass = aws.String(s.storageClass)
+	}
+
+	result, err := s.client.UploadWithContext(ctx, input, func(uploader *s3manager.Uploader) {
+		uploader.Concurrency = 1
+		uploader.LeavePartsOnError = false
+		uploader.PartSize = s3StreamPartSize
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("->", result.Location)
+	return nil
+}
+
+// s3StreamReader fails the stream larger than s3MaxStreamSize with the error logged,
+// instead of the error of the part 10001 from the uploader
+type s3StreamReader struct {
+	io.Reader
+	logger     logger.Logger
+	remotePath string
+	size       int64
+}
+
+func (r *s3StreamReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.size += int64(n)
+	if r.size > s3MaxStreamSize {
+		err := fmt.Errorf("stream of %s is larger than %d bytes (64MiB * %d parts), which is the limit of the streaming upload, disable `streaming` for the larger packages", r.remotePath, s3MaxStreamSize, s3manager.MaxUploadParts)
+		r.logger.Error(err)
+		return n, err
+	}
+	return n, err
+}
+
 func (s *S3) delete(fileKey string) (err error) {
-	remotePath := filepath.Join(s.path, fileKey)
+	remotePath := s.objectKey(fileKey)
 	input := &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(remotePath),
@@ -342,7 +504,7 @@ func (s *S3) delete(fileKey string) (err error) {
 
 // List the objects in the bucket with the prefix = parent
 func (s *S3) list(parent string) ([]FileItem, error) {
-	remotePath := filepath.Join(s.path, parent)
+	remotePath := listPrefix(s.objectKey(parent))
 	continueToken := ""
 	var items []FileItem
 
@@ -366,7 +528,7 @@ func (s *S3) list(parent string) ([]FileItem, error) {
 
 		for _, object := range result.Contents {
 			items = append(items, FileItem{
-				Filename:     *object.Key,
+				Filename:     relativeKey(s.path, *object.Key),
 				Size:         *object.Size,
 				LastModified: *object.LastModified,
 			})
@@ -382,11 +544,11 @@ func (s *S3) list(parent string) ([]FileItem, error) {
 	return items, nil
 }
 
-// Get the object download URL by fileKey (include remote_path)
+// Get the object download URL by fileKey (relative to path)
 func (s *S3) download(fileKey string) (*DownloadResult, error) {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(fileKey),
+		Key:    aws.String(s.objectKey(fileKey)),
 	}
 
 	req, _ := s.client.S3.GetObjectRequest(input)
@@ -397,3 +559,8 @@ func (s *S3) download(fileKey string) (*DownloadResult, error) {
 
 	return &DownloadResult{RedirectURL: url}, nil
 }
+
+// objectKey return the key of the object in the bucket, fileKey is relative to path
+func (s *S3) objectKey(fileKey string) string {
+	return path.Join(s.path, fileKey)
+}
@@ -1,9 +1,21 @@
 package storage
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
 )
@@ -86,3 +98,90 @@ func Test_providerName(t *testing.T) {
 	}
 
 }
+
+// fakeS3 record the multipart uploads, the part of failPart fails once
+type fakeS3 struct {
+	s3iface.S3API
+	failPart  int64
+	created   int
+	aborted   int
+	parts     []string
+	completed []*s3.CompletedPart
+}
+
+func (f *fakeS3) CreateMultipartUploadWithContext(ctx aws.Context, input *s3.CreateMultipartUploadInput, opts ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+	f.created++
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (f *fakeS3) UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error) {
+	if *input.PartNumber == f.failPart {
+		f.failPart = 0
+		return nil, fmt.Errorf("connection reset")
+	}
+
+	data, _ := io.ReadAll(input.Body)
+	f.parts = append(f.parts, string(data))
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", *input.PartNumber))}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	f.completed = input.MultipartUpload.Parts
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	f.aborted++
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func Test_S3_uploadParts(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "foo.tar.gz")
+	assert.NoError(t, os.WriteFile(filePath, []byte("hello world"), 0640))
+	f, err := os.Open(filePath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	api := &fakeS3{failPart: 2}
+	s := &S3{bucket: "test-bucket", client: &s3manager.Uploader{S3: api}}
+
+	err = s.uploadParts(context.Background(), f, 11, 4, "backups/foo.tar.gz")
+	assert.EqualError(t, err, "upload part 2/3 of backups/foo.tar.gz failed: connection reset")
+	assert.Equal(t, []string{"hell"}, api.parts)
+
+	// resume from the failed part
+	err = s.uploadParts(context.Background(), f, 11, 4, "backups/foo.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, api.created)
+	assert.Equal(t, []string{"hell", "o wo", "rld"}, api.parts)
+	assert.Equal(t, 3, len(api.completed))
+	assert.Equal(t, "etag-3", *api.completed[2].ETag)
+	assert.Equal(t, 0, len(s.multipartUploads))
+
+	// the failed upload is aborted when there is no more attempt
+	api.failPart = 1
+	err = s.uploadParts(context.Background(), f, 11, 4, "backups/bar.tar.gz")
+	assert.Error(t, err)
+	s.discardResume()
+	assert.Equal(t, 1, api.aborted)
+	assert.Equal(t, 0, len(s.multipartUploads))
+}
+
+func Test_s3StreamReader(t *testing.T) {
+	assert.Equal(t, int64(625*1024*1024*1024), s3MaxStreamSize)
+
+	originalMaxStreamSize := s3MaxStreamSize
+	s3MaxStreamSize = 10
+	defer func() {
+		s3MaxStreamSize = originalMaxStreamSize
+	}()
+
+	r := &s3StreamReader{Reader: strings.NewReader("0123456789"), logger: logger.Tag("S3"), remotePath: "backups/foo.tar.gz"}
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	r = &s3StreamReader{Reader: strings.NewReader("0123456789a"), logger: logger.Tag("S3"), remotePath: "backups/foo.tar.gz"}
+	_, err = io.ReadAll(r)
+	assert.EqualError(t, err, "stream of backups/foo.tar.gz is larger than 10 bytes (64MiB * 10000 parts), which is the limit of the streaming upload, disable `streaming` for the larger packages")
+}
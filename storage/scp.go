@@ -115,8 +115,8 @@ func (s *SCP) close() {
 	s.client.Close()
 }
 
-func (s *SCP) upload(fileKey string) error {
-	logger := logger.Tag("SCP")
+func (s *SCP) upload(ctx context.Context, fileKey string) error {
+	logger := logger.TagContext(ctx, "SCP")
 
 	var fileKeys []string
 	if len(s.fileKeys) != 0 {
@@ -139,7 +139,7 @@ func (s *SCP) upload(fileKey string) error {
 		}
 
 		// upload file
-		if err := s.up(sourcePath, remotePath); err != nil {
+		if err := s.up(ctx, sourcePath, remotePath); err != nil {
 			return err
 		}
 	}
@@ -148,8 +148,8 @@ func (s *SCP) upload(fileKey string) error {
 	return nil
 }
 
-func (s *SCP) up(localPath, remotePath string) error {
-	logger := logger.Tag("SCP")
+func (s *SCP) up(ctx context.Context, localPath, remotePath string) error {
+	logger := logger.TagContext(ctx, "SCP")
 
 	client, err := scp.NewClientBySSH(s.client)
 	if err != nil {
@@ -167,7 +167,7 @@ func (s *SCP) up(localPath, remotePath string) error {
 	defer file.Close()
 
 	progress := helper.NewProgressBar(logger, file)
-	if err := client.CopyFile(context.Background(), progress.Reader, remotePath, "0644"); err != nil {
+	if err := client.CopyFile(ctx, progress.Reader, remotePath, "0644"); err != nil {
 		return progress.Errorf("store %s failed: %v", remotePath, err)
 	}
 	progress.Done(remotePath)
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"mime"
@@ -25,6 +26,8 @@ type SFTP struct {
 	SSH
 	path   string
 	client *sftp.Client
+	// partials are the remote files written by the failed attempts, they are resumed from the offset
+	partials map[string]bool
 }
 
 func (s *SFTP) open() error {
@@ -86,8 +89,8 @@ func (s *SFTP) close() {
 	s.client.Close()
 }
 
-func (s *SFTP) upload(fileKey string) error {
-	logger := logger.Tag("SFTP")
+func (s *SFTP) upload(ctx context.Context, fileKey string) error {
+	logger := logger.TagContext(ctx, "SFTP")
 
 	var fileKeys []string
 	if len(s.fileKeys) != 0 {
@@ -112,7 +115,7 @@ func (s *SFTP) upload(fileKey string) error {
 	for _, key := range fileKeys {
 		sourcePath := filepath.Join(filepath.Dir(s.archivePath), key)
 		remotePath := filepath.Join(s.path, key)
-		if err := s.up(sourcePath, remotePath); err != nil {
+		if err := s.up(ctx, sourcePath, remotePath); err != nil {
 			return err
 		}
 	}
@@ -121,8 +124,8 @@ func (s *SFTP) upload(fileKey string) error {
 	return nil
 }
 
-func (s *SFTP) up(localPath, remotePath string) error {
-	logger := logger.Tag("SFTP")
+func (s *SFTP) up(ctx context.Context, localPath, remotePath string) error {
+	logger := logger.TagContext(ctx, "SFTP")
 
 	file, err := os.Open(localPath)
 	if err != nil {
@@ -131,23 +134,112 @@ func (s *SFTP) up(localPath, remotePath string) error {
 	}
 	defer file.Close()
 
-	logger.Info("-> upload to", remotePath)
-	remoteFile, err := s.client.OpenFile(remotePath, (os.O_WRONLY | os.O_CREATE | os.O_TRUNC))
+	offset := s.resumeOffset(remotePath, file)
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+		logger.Info("-> upload to", remotePath)
+	} else {
+		logger.Infof("-> resume uploading to %s from %d bytes", remotePath, offset)
+	}
+
+	remoteFile, err := s.client.OpenFile(remotePath, flags)
 	if err != nil {
 		logger.Errorf("Unable to open remote file %s: %v", remotePath, err)
 		return err
 	}
 	defer remoteFile.Close()
 
-	if _, err := io.Copy(remoteFile, file); err != nil {
+	if s.partials == nil {
+		s.partials = map[string]bool{}
+	}
+	s.partials[remotePath] = true
+
+	if _, err := remoteFile.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := s.copy(ctx, remoteFile, file); err != nil {
 		logger.Errorf("Unable to upload local file %s: %v", localPath, err)
 		return err
 	}
+	delete(s.partials, remotePath)
+	logger.Infof("Store %s succeeded", remotePath)
+
+	return nil
+}
+
+// resumeOffset return the size of the remote file written by the failed attempt, 0 to upload from the beginning
+func (s *SFTP) resumeOffset(remotePath string, file *os.File) int64 {
+	if !s.partials[remotePath] {
+		return 0
+	}
+
+	remoteInfo, err := s.client.Stat(remotePath)
+	if err != nil {
+		return 0
+	}
+	info, err := file.Stat()
+	if err != nil || remoteInfo.Size() > info.Size() {
+		return 0
+	}
+
+	return remoteInfo.Size()
+}
+
+// discardResume remove the remote files written by the failed attempts
+func (s *SFTP) discardResume() {
+	for remotePath := range s.partials {
+		s.client.Remove(remotePath)
+	}
+	s.partials = nil
+}
+
+// uploadStream write the reader into fileKey
+func (s *SFTP) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, "SFTP")
+
+	remotePath := filepath.Join(s.path, fileKey)
+	if err := s.client.MkdirAll(filepath.Dir(remotePath)); err != nil {
+		return err
+	}
+
+	logger.Info("-> upload to", remotePath)
+	remoteFile, err := s.client.OpenFile(remotePath, (os.O_WRONLY | os.O_CREATE | os.O_TRUNC))
+	if err != nil {
+		return err
+	}
+	defer remoteFile.Close()
+
+	if err := s.copy(ctx, remoteFile, r); err != nil {
+		return err
+	}
 	logger.Infof("Store %s succeeded", remotePath)
 
 	return nil
 }
 
+// copy r into the remote file, the connection is closed when ctx is done,
+// so that a stalled transfer is interrupted.
+func (s *SFTP) copy(ctx context.Context, remoteFile io.Writer, r io.Reader) error {
+	stop := context.AfterFunc(ctx, func() {
+		s.client.Close()
+	})
+	defer stop()
+
+	if _, err := io.Copy(remoteFile, helper.ContextReader(ctx, r)); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (s *SFTP) delete(fileKey string) error {
 	logger := logger.Tag("SFTP")
 
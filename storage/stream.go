@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/manifest"
+)
+
+// streamUploader is implemented by the storages which can upload from a reader
+// without knowing the size, it is required for the streaming mode.
+type streamUploader interface {
+	uploadStream(ctx context.Context, fileKey string, r io.Reader) error
+}
+
+// Stream upload the package to all the storages while it is being written,
+// so that no temp file is required.
+//
+// A storage that fails is dropped, the others keep going.
+type Stream struct {
+	ctx       context.Context
+	targets   []*streamTarget
+	startedAt time.Time
+	// Files are the uploaded files with checksum in created order
+	Files []manifest.File
+	// fileKey of the package committed
+	fileKey string
+}
+
+type streamTarget struct {
+	name     string
+	base     Base
+	config   config.SubConfig
+	storage  Storage
+	uploader streamUploader
+	err      error
+	// duration from the stream opened to the storage finished or failed
+	duration time.Duration
+	removed  int
+}
+
+// OpenStream open all the storages of the model for streaming upload, the uploads are stopped when ctx is done
+func OpenStream(ctx context.Context, model config.ModelConfig) (*Stream, error) {
+	logger := logger.TagContext(ctx, "Storage")
+
+	st := &Stream{ctx: ctx, startedAt: time.Now()}
+
+	names := make([]string, 0, len(model.Storages))
+	for name := range model.Storages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		storageConfig := model.Storages[name]
+
+		base, s := new(model, "", storageConfig)
+		uploader, ok := s.(streamUploader)
+		if !ok {
+			st.Close()
+			return nil, fmt.Errorf("storage %s (%s) does not support streaming", name, storageConfig.Type)
+		}
+
+		logger.Info("=> Storage | " + storageConfig.Type)
+		if err := s.open(); err != nil {
+			st.Close()
+			return nil, err
+		}
+
+		st.targets = append(st.targets, &streamTarget{name: name, base: base, config: storageConfig, storage: s, uploader: uploader})
+	}
+
+	if len(st.targets) == 0 {
+		return nil, fmt.Errorf("no storage configured")
+	}
+
+	return st, nil
+}
+
+// Create a file of fileKey in all the storages, the data written is uploaded on the fly.
+func (st *Stream) Create(fileKey string) (io.WriteCloser, error) {
+	w := &streamWriter{stream: st, key: fileKey, hash: sha256.New()}
+
+	for _, t := range st.targets {
+		if t.err != nil {
+			continue
+		}
+
+		pr, pw := io.Pipe()
+		upload := &streamUpload{target: t, writer: pw, done: make(chan error, 1)}
+		go func(t *streamTarget) {
+			err := t.uploader.uploadStream(st.ctx, fileKey, pr)
+			pr.CloseWithError(err)
+			upload.done <- err
+		}(t)
+
+		w.uploads = append(w.uploads, upload)
+	}
+
+	if len(w.uploads) == 0 {
+		return nil, st.Err()
+	}
+
+	return w, nil
+}
+
+// uploadManifest upload the manifest to the storages, a failure is only a warning
+func (st *Stream) uploadManifest(fileKey string, data []byte) map[*streamTarget]bool {
+	logger := logger.TagContext(st.ctx, "Storage")
+
+	uploaded := map[*streamTarget]bool{}
+	for _, t := range st.targets {
+		if t.err != nil {
+			continue
+		}
+
+		if err := t.uploader.uploadStream(st.ctx, fileKey, bytes.NewReader(data)); err != nil {
+			logger.Warnf("Upload manifest %s to %s failed: %v", fileKey, t.name, err)
+			continue
+		}
+		uploaded[t] = true
+	}
+
+	return uploaded
+}
+
+// Commit the package into the cycler of the succeeded storages,
+// the manifest will be uploaded when it is not nil.
+func (st *Stream) Commit(pkg Package, m *manifest.Manifest) error {
+	logger := logger.TagContext(st.ctx, "Storage")
+	st.fileKey = pkg.FileKey
+
+	var uploaded map[*streamTarget]bool
+	if m != nil {
+		data, err := m.JSON()
+		if err != nil {
+			return err
+		}
+		uploaded = st.uploadManifest(manifest.Path(pkg.FileKey), data)
+	}
+
+	for _, t := range st.targets {
+		if t.err != nil {
+			continue
+		}
+
+		tpkg := pkg
+		if uploaded[t] {
+			tpkg.ManifestKey = manifest.Path(pkg.FileKey)
+		}
+
+		logger.Infof("Stored %s to %s", pkg.FileKey, t.name)
+		t.removed = t.base.cycler.run(st.ctx, tpkg, t.base.retention, t.storage)
+		t.duration = time.Since(st.startedAt)
+	}
+
+	return st.Err()
+}
+
+// Abort the uploads with err, it must be called before closing the writers,
+// so that the uploads in progress fail instead of storing a truncated file.
+func (st *Stream) Abort(err error) {
+	for _, t := range st.targets {
+		st.fail(t, err)
+	}
+}
+
+// Err return the errors of the failed storages, when there is only one storage, its error is returned directly.
+func (st *Stream) Err() error {
+	return st.Results().Err()
+}
+
+// Results of the storages, the storages are uploaded at the same time,
+// so the duration is counted from the stream opened.
+func (st *Stream) Results() Results {
+	results := make(Results, 0, len(st.targets))
+	for _, t := range st.targets {
+		duration := t.duration
+		if duration == 0 {
+			duration = time.Since(st.startedAt)
+		}
+		result := Result{Name: t.name, Type: t.config.Type, Duration: duration, Removed: t.removed, Err: t.err}
+		if t.err == nil {
+			result.FileKey = st.fileKey
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// fail mark the storage failed with err
+func (st *Stream) fail(t *streamTarget, err error) {
+	if t.err == nil {
+		t.err = err
+		t.duration = time.Since(st.startedAt)
+	}
+}
+
+// Close all the storages
+func (st *Stream) Close() {
+	for _, t := range st.targets {
+		t.storage.close()
+	}
+}
+
+type streamUpload struct {
+	target *streamTarget
+	writer *io.PipeWriter
+	done   chan error
+}
+
+// streamWriter write the data into the uploads of all the storages
+type streamWriter struct {
+	stream  *Stream
+	key     string
+	hash    hash.Hash
+	size    int64
+	uploads []*streamUpload
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	logger := logger.TagContext(w.stream.ctx, "Storage")
+
+	alive := 0
+	for _, u := range w.uploads {
+		if u.target.err != nil {
+			continue
+		}
+
+		if _, err := u.writer.Write(p); err != nil {
+			logger.Errorf("Upload %s to %s failed: %v", w.key, u.target.name, err)
+			w.stream.fail(u.target, err)
+			continue
+		}
+		alive++
+	}
+
+	if alive == 0 {
+		return 0, fmt.Errorf("upload %s failed: %v", w.key, w.stream.Err())
+	}
+
+	w.hash.Write(p)
+	w.size += int64(len(p))
+	return len(p), nil
+}
+
+// Close finish the uploads and wait for them to complete
+func (w *streamWriter) Close() error {
+	logger := logger.TagContext(w.stream.ctx, "Storage")
+
+	alive := 0
+	for _, u := range w.uploads {
+		if u.target.err != nil {
+			u.writer.CloseWithError(u.target.err)
+		} else {
+			u.writer.Close()
+		}
+
+		if err := <-u.done; err != nil && u.target.err == nil {
+			logger.Errorf("Upload %s to %s failed: %v", w.key, u.target.name, err)
+			w.stream.fail(u.target, err)
+		}
+		if u.target.err == nil {
+			alive++
+		}
+	}
+
+	if alive == 0 {
+		return fmt.Errorf("upload %s failed: %v", w.key, w.stream.Err())
+	}
+
+	w.stream.Files = append(w.stream.Files, manifest.File{
+		Key:    w.key,
+		Size:   w.size,
+		SHA256: hex.EncodeToString(w.hash.Sum(nil)),
+	})
+
+	return nil
+}
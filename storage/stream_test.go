@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/manifest"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func newStreamTestModel(t *testing.T, storages ...string) config.ModelConfig {
+	model := config.ModelConfig{
+		Name:     "stream_test",
+		TempPath: t.TempDir(),
+		Viper:    viper.New(),
+		Storages: map[string]config.SubConfig{},
+	}
+	model.Viper.Set("Ext", ".tar.gz")
+
+	for _, name := range storages {
+		storageViper := viper.New()
+		storageViper.Set("path", filepath.Join(t.TempDir(), name))
+		model.Storages[name] = config.SubConfig{Name: name, Type: "local", Viper: storageViper}
+	}
+
+	return model
+}
+
+func TestStream(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	model := newStreamTestModel(t, "local1", "local2")
+
+	st, err := OpenStream(context.Background(), model)
+	assert.NoError(t, err)
+	defer st.Close()
+
+	w, err := st.Create("2022.12.04.07.09.25/2022.12.04.07.09.25.tar.gz-000")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("hello "))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("world"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, 1, len(st.Files))
+	assert.Equal(t, int64(11), st.Files[0].Size)
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", st.Files[0].SHA256)
+
+	m, err := manifest.New(model, "2022.12.04.07.09.25", time.Now())
+	assert.NoError(t, err)
+	m.Add(st.Files[0])
+
+	pkg := Package{FileKey: "2022.12.04.07.09.25", FileKeys: []string{st.Files[0].Key}}
+	assert.NoError(t, st.Commit(pkg, m))
+
+	for _, name := range []string{"local1", "local2"} {
+		storagePath := model.Storages[name].Viper.GetString("path")
+		data, err := os.ReadFile(filepath.Join(storagePath, "2022.12.04.07.09.25", "2022.12.04.07.09.25.tar.gz-000"))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+		assert.True(t, fileExists(filepath.Join(storagePath, "2022.12.04.07.09.25.manifest.json")))
+
+		cycler := newCycler(model, model.Storages[name])
+		found := cycler.find("2022.12.04.07.09.25")
+		assert.NotNil(t, found)
+		assert.Equal(t, "2022.12.04.07.09.25.manifest.json", found.ManifestKey)
+	}
+}
+
+func TestStream_Abort(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	model := newStreamTestModel(t, "local")
+	storagePath := model.Storages["local"].Viper.GetString("path")
+
+	st, err := OpenStream(context.Background(), model)
+	assert.NoError(t, err)
+	defer st.Close()
+
+	w, err := st.Create("2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	assert.NoError(t, err)
+
+	st.Abort(errors.New("archive failed"))
+	assert.Error(t, w.Close())
+	assert.EqualError(t, st.Err(), "archive failed")
+	assert.False(t, fileExists(filepath.Join(storagePath, "2022.12.04.07.09.25.tar.gz")))
+
+	_, err = st.Create("2022.12.04.07.09.25.tar.gz")
+	assert.EqualError(t, err, "archive failed")
+}
+
+func TestOpenStream_Unsupported(t *testing.T) {
+	model := newStreamTestModel(t)
+	model.Storages["scp"] = config.SubConfig{Name: "scp", Type: "scp", Viper: viper.New()}
+
+	_, err := OpenStream(context.Background(), model)
+	assert.EqualError(t, err, "storage scp (scp) does not support streaming")
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/manifest"
+)
+
+// VerifyResult of a package in storage
+type VerifyResult struct {
+	FileKey string       `json:"file_key"`
+	Files   []VerifyFile `json:"files"`
+	// Manifest is false when the manifest of the package is missing, so checksums are not compared
+	Manifest bool   `json:"manifest"`
+	Error    string `json:"error,omitempty"`
+}
+
+// VerifyFile result of a file in the package
+type VerifyFile struct {
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OK return true if the package and all files are verified
+func (r VerifyResult) OK() bool {
+	if len(r.Error) > 0 {
+		return false
+	}
+
+	for _, file := range r.Files {
+		if len(file.Error) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Err return the first error of the package
+func (r VerifyResult) Err() error {
+	if len(r.Error) > 0 {
+		return fmt.Errorf("%s: %s", r.FileKey, r.Error)
+	}
+
+	for _, file := range r.Files {
+		if len(file.Error) > 0 {
+			return fmt.Errorf("%s: %s", file.Key, file.Error)
+		}
+	}
+
+	return nil
+}
+
+// Verify stream the packages back from storage and check them against the manifest checksums.
+//
+// When fileKey is empty, all the packages recorded in the cycler will be verified.
+func Verify(model config.ModelConfig, storageName string, fileKey string) ([]VerifyResult, error) {
+	logger := logger.Tag("Verify")
+
+	if len(storageName) == 0 {
+		storageName = model.DefaultStorage
+	}
+
+	storageConfig, ok := model.Storages[storageName]
+	if !ok {
+		return nil, fmt.Errorf("Storage %s not found", storageName)
+	}
+
+	base, s := new(model, "", storageConfig)
+	if s == nil {
+		return nil, fmt.Errorf("Storage %s is not supported", storageConfig.Type)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	var packages []Package
+	if len(fileKey) > 0 {
+		fileKey = strings.TrimSuffix(fileKey, "/")
+		pkg := Package{FileKey: fileKey}
+		if found := base.cycler.find(fileKey); found != nil {
+			pkg.FileKeys = found.FileKeys
+		}
+		packages = append(packages, pkg)
+	} else {
+		packages = base.cycler.list()
+	}
+
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no package found in storage %s", storageName)
+	}
+
+	logger.Infof("=> Verify %d packages in %s", len(packages), storageName)
+
+	results := make([]VerifyResult, 0, len(packages))
+	for _, pkg := range packages {
+		result := verifyPackage(s, pkg)
+		if result.OK() {
+			logger.Info("OK", result.FileKey)
+		} else {
+			logger.Error("Failed", result.Err())
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func verifyPackage(s Storage, pkg Package) VerifyResult {
+	result := VerifyResult{FileKey: pkg.FileKey}
+
+	m, err := fetchManifest(s, pkg.FileKey)
+	if err == nil {
+		result.Manifest = true
+	}
+
+	// Chunks in the cycler first, then the manifest
+	keys := pkg.FileKeys
+	if len(keys) == 0 && m != nil {
+		keys = m.Chunks
+	}
+	if len(keys) == 0 {
+		keys = []string{path.Base(pkg.FileKey)}
+	}
+
+	if m != nil && len(m.Chunks) > 0 && len(pkg.FileKeys) > 0 && len(m.Chunks) != len(pkg.FileKeys) {
+		result.Error = fmt.Sprintf("chunks count mismatch, manifest: %d, cycler: %d", len(m.Chunks), len(pkg.FileKeys))
+	}
+
+	parent := path.Dir(pkg.FileKey)
+	for _, key := range keys {
+		result.Files = append(result.Files, verifyFile(s, m, path.Join(parent, key), key))
+	}
+
+	return result
+}
+
+func verifyFile(s Storage, m *manifest.Manifest, remoteKey string, key string) VerifyFile {
+	file := VerifyFile{Key: remoteKey}
+
+	reader, err := openFile(s, remoteKey)
+	if err != nil {
+		file.Error = fmt.Sprintf("missing or unreadable: %v", err)
+		return file
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	file.Size, err = io.Copy(hash, reader)
+	if err != nil {
+		file.Error = fmt.Sprintf("read failed: %v", err)
+		return file
+	}
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if m == nil {
+		return file
+	}
+
+	expected, ok := m.Checksum(key)
+	if !ok {
+		file.Error = "not found in manifest"
+	} else if expected != file.SHA256 {
+		file.Error = fmt.Sprintf("checksum mismatch, expected: %s, got: %s", expected, file.SHA256)
+	}
+
+	return file
+}
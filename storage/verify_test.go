@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/manifest"
+	"github.com/longbridgeapp/assert"
+	"github.com/spf13/viper"
+)
+
+func TestVerify(t *testing.T) {
+	originalCyclerPath := cyclerPath
+	cyclerPath = t.TempDir()
+	defer func() {
+		cyclerPath = originalCyclerPath
+	}()
+
+	tempPath := t.TempDir()
+	storagePath := t.TempDir()
+
+	modelViper := viper.New()
+	modelViper.Set("Ext", ".tar.gz")
+	storageViper := viper.New()
+	storageViper.Set("path", storagePath)
+
+	model := config.ModelConfig{
+		Name:           "verify_test",
+		TempPath:       tempPath,
+		DumpPath:       filepath.Join(tempPath, "verify_test"),
+		Viper:          modelViper,
+		DefaultStorage: "local",
+		Storages: map[string]config.SubConfig{
+			"local": {Name: "local", Type: "local", Viper: storageViper},
+		},
+	}
+
+	archivePath := filepath.Join(tempPath, "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, os.WriteFile(archivePath, []byte("hello"), 0640))
+	manifestPath, err := manifest.Write(model, archivePath, time.Now())
+	assert.NoError(t, err)
+
+	for _, p := range []string{archivePath, manifestPath} {
+		data, err := os.ReadFile(p)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(storagePath, filepath.Base(p)), data, 0640))
+	}
+
+	results, err := Verify(model, "", "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.True(t, results[0].OK())
+	assert.True(t, results[0].Manifest)
+	assert.Equal(t, int64(5), results[0].Files[0].Size)
+
+	// tampered
+	assert.NoError(t, os.WriteFile(filepath.Join(storagePath, "2022.12.04.07.09.25.tar.gz"), []byte("hellO"), 0640))
+	results, err = Verify(model, "", "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, err)
+	assert.False(t, results[0].OK())
+	assert.Contains(t, results[0].Err().Error(), "checksum mismatch")
+
+	// missing
+	results, err = Verify(model, "", "2022.12.04.07.09.47.tar.gz")
+	assert.NoError(t, err)
+	assert.False(t, results[0].OK())
+	assert.False(t, results[0].Manifest)
+	assert.Contains(t, results[0].Err().Error(), "missing or unreadable")
+
+	_, err = Verify(model, "s3", "")
+	assert.EqualError(t, err, "Storage s3 not found")
+}
+
+func TestVerify_S3WithPath(t *testing.T) {
+	model := newS3TestModel(t, "2022.12.04.07.09.25.tar.gz", "hello")
+
+	results, err := Verify(model, "", "2022.12.04.07.09.25.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.True(t, results[0].OK())
+	assert.True(t, results[0].Manifest)
+	assert.Equal(t, "2022.12.04.07.09.25.tar.gz", results[0].Files[0].Key)
+	assert.Equal(t, int64(5), results[0].Files[0].Size)
+}
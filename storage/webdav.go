@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -56,8 +58,8 @@ func (s *WebDAV) open() error {
 
 func (s *WebDAV) close() {}
 
-func (s *WebDAV) upload(fileKey string) error {
-	logger := logger.Tag("WebDAV")
+func (s *WebDAV) upload(ctx context.Context, fileKey string) error {
+	logger := logger.TagContext(ctx, "WebDAV")
 	logger.Info("-> Uploading...")
 
 	var fileKeys []string
@@ -90,7 +92,7 @@ func (s *WebDAV) upload(fileKey string) error {
 		defer f.Close()
 
 		progress := helper.NewProgressBar(logger, f)
-		if err := s.client.WriteStream(remotePath, progress.Reader, 0644); err != nil {
+		if err := s.client.WriteStream(remotePath, helper.ContextReader(ctx, progress.Reader), 0644); err != nil {
 			return progress.Errorf("upload failed %v", err)
 		}
 		progress.Done(remotePath)
@@ -100,6 +102,23 @@ func (s *WebDAV) upload(fileKey string) error {
 	return nil
 }
 
+// uploadStream write the reader into fileKey
+func (s *WebDAV) uploadStream(ctx context.Context, fileKey string, r io.Reader) error {
+	logger := logger.TagContext(ctx, "WebDAV")
+
+	remotePath := filepath.Join(s.path, fileKey)
+	if err := s.client.MkdirAll(filepath.Dir(remotePath), 0644); err != nil {
+		return err
+	}
+
+	if err := s.client.WriteStream(remotePath, helper.ContextReader(ctx, r), 0644); err != nil {
+		return fmt.Errorf("upload failed %v", err)
+	}
+	logger.Info("->", remotePath)
+
+	return nil
+}
+
 func (s *WebDAV) delete(fileKey string) error {
 	logger := logger.Tag("WebDAV")
 	remotePath := path.Join(s.path, fileKey)
@@ -2,6 +2,7 @@ package web
 
 import (
 	"bufio"
+	"context"
 	"embed"
 	"errors"
 	"fmt"
@@ -12,12 +13,17 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/job"
 	"github.com/gobackup/gobackup/logger"
+	"github.com/gobackup/gobackup/metrics"
 	"github.com/gobackup/gobackup/model"
 	"github.com/gobackup/gobackup/storage"
 	"github.com/stoicperlman/fls"
@@ -27,9 +33,15 @@ import (
 var staticFS embed.FS
 var logFile *os.File
 var storageDownload = storage.Download
+var modelVerify = func(ctx context.Context, m *model.Model, opts model.VerifyOptions) ([]storage.VerifyResult, error) {
+	return m.Verify(ctx, opts)
+}
 
 var errConfigPathNotFound = errors.New("config file not found")
 
+// runLogInterval to check the new lines of a running log
+var runLogInterval = 200 * time.Millisecond
+
 type embedFileSystem struct {
 	http.FileSystem
 	indexes bool
@@ -54,7 +66,11 @@ func (e embedFileSystem) Exists(prefix string, path string) bool {
 func StartHTTP(version string) (err error) {
 	logger := logger.Tag("API")
 
-	if len(config.Web.Password) == 0 {
+	password, err := config.Web.ResolvePassword()
+	if err != nil {
+		return err
+	}
+	if len(password) == 0 {
 		logger.Warn("You are running with insecure API server. Please don't forget setup `web.password` in config file for more safety.")
 	}
 
@@ -80,9 +96,9 @@ func StartHTTP(version string) (err error) {
 	r := setupRouter(version)
 
 	// Enable baseAuth
-	if len(config.Web.Username) > 0 && len(config.Web.Password) > 0 {
+	if len(config.Web.Username) > 0 && len(password) > 0 {
 		r.Use(gin.BasicAuth(gin.Accounts{
-			config.Web.Username: config.Web.Password,
+			config.Web.Username: password,
 		}))
 	}
 
@@ -105,6 +121,7 @@ func setupRouter(version string) *gin.Engine {
 			"version": version,
 		})
 	})
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	r.Use(func(c *gin.Context) {
 		c.Next()
@@ -131,20 +148,34 @@ func setupRouter(version string) *gin.Engine {
 	group.GET("/list", list)
 	group.GET("/download", download)
 	group.POST("/perform", perform)
+	group.POST("/verify", verify)
+	group.GET("/runs", listRuns)
+	group.GET("/runs/:id", getRun)
+	group.GET("/runs/:id/log", getRunLog)
+	group.GET("/jobs", listJobs)
+	group.POST("/jobs/:id/cancel", cancelJob)
 	group.GET("/log", log)
 	return r
 }
 
 func requireConfigEditorAuth(c *gin.Context) {
-	if len(config.Web.Username) == 0 || len(config.Web.Password) == 0 {
+	password, err := config.Web.ResolvePassword()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if len(config.Web.Username) == 0 || len(password) == 0 {
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 			"message": "Config editor requires API authentication.",
 		})
 		return
 	}
 
-	username, password, ok := c.Request.BasicAuth()
-	if !ok || username != config.Web.Username || password != config.Web.Password {
+	username, reqPassword, ok := c.Request.BasicAuth()
+	if !ok || username != config.Web.Username || reqPassword != password {
 		c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"message": "Authentication required.",
@@ -159,10 +190,17 @@ func requireConfigEditorAuth(c *gin.Context) {
 func getConfig(c *gin.Context) {
 	models := map[string]any{}
 	for _, m := range model.GetModels() {
+		lastRun, err := history.Last(m.Config.Name)
+		if err != nil {
+			logger.Errorf("Load last run of %s failed: %v", m.Config.Name, err)
+		}
+
 		models[m.Config.Name] = gin.H{
 			"description":   m.Config.Description,
 			"schedule":      m.Config.Schedule,
 			"schedule_info": m.Config.Schedule.String(),
+			"last_run":      lastRun,
+			"config_file":   m.Config.ConfigFile,
 		}
 	}
 
@@ -186,6 +224,8 @@ type configPathStatus struct {
 type configPathStatusEntry struct {
 	Path   string `json:"path"`
 	Exists bool   `json:"exists"`
+	// Models defined in the file, the main config file or an included one
+	Models []string `json:"models,omitempty"`
 }
 
 
@@ -209,12 +249,21 @@ func configPathStatuses() []configPathStatus {
 }
 
 func allowedConfigPaths() []configPathStatusEntry {
+	modelsOfFile := map[string][]string{}
+	for _, m := range config.Models {
+		modelsOfFile[m.ConfigFile] = append(modelsOfFile[m.ConfigFile], m.Name)
+	}
+
 	statuses := configPathStatuses()
 	allowedPaths := make([]configPathStatusEntry, 0, len(statuses))
 	for _, status := range statuses {
+		models := modelsOfFile[status.Path]
+		sort.Strings(models)
+
 		allowedPaths = append(allowedPaths, configPathStatusEntry{
 			Path:   status.Path,
 			Exists: status.Exists,
+			Models: models,
 		})
 	}
 
@@ -517,12 +566,177 @@ func perform(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		if err := m.Perform(); err != nil {
-			logger.Errorf("Perform error: %v", err)
+	m.Trigger = history.TriggerWeb
+	j, queued := job.Enqueue(*m)
+	if !queued {
+		c.JSON(200, gin.H{"message": fmt.Sprintf("Backup: %s is already %s.", param.Model, j.Status), "job": j})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("Backup: %s performed in background.", param.Model), "job": j})
+}
+
+// GET /api/jobs
+func listJobs(c *gin.Context) {
+	c.JSON(200, gin.H{"jobs": job.List()})
+}
+
+// POST /api/jobs/:id/cancel
+func cancelJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithError(400, fmt.Errorf("invalid job id: %s", c.Param("id")))
+		return
+	}
+
+	if err := job.Cancel(id); err != nil {
+		switch err {
+		case job.ErrNotFound:
+			c.AbortWithError(404, err)
+		default:
+			c.AbortWithError(409, err)
 		}
-	}()
-	c.JSON(200, gin.H{"message": fmt.Sprintf("Backup: %s performed in background.", param.Model)})
+		return
+	}
+
+	j, _ := job.Get(id)
+	c.JSON(200, gin.H{"message": fmt.Sprintf("Job %d canceled.", id), "job": j})
+}
+
+// POST /api/verify
+func verify(c *gin.Context) {
+	type verifyParam struct {
+		Model   string `form:"model" json:"model" binding:"required"`
+		Storage string `form:"storage" json:"storage"`
+		File    string `form:"file" json:"file"`
+		Deep    bool   `form:"deep" json:"deep"`
+	}
+
+	var param verifyParam
+	if err := c.ShouldBind(&param); err != nil {
+		c.AbortWithError(400, err)
+		return
+	}
+
+	m := model.GetModelByName(param.Model)
+	if m == nil {
+		c.AbortWithError(404, fmt.Errorf("Model: \"%s\" not found", param.Model))
+		return
+	}
+
+	// it is stopped when the request is canceled
+	results, err := modelVerify(c.Request.Context(), m, model.VerifyOptions{
+		Storage: param.Storage,
+		FileKey: param.File,
+		Deep:    param.Deep,
+	})
+	if results == nil && err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	response := gin.H{"ok": err == nil, "results": results}
+	if err != nil {
+		response["message"] = err.Error()
+	}
+	c.JSON(200, response)
+}
+
+// GET /api/runs?model=xxx&limit=50
+func listRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.AbortWithError(400, fmt.Errorf("invalid limit: %s", c.Query("limit")))
+		return
+	}
+
+	runs, err := history.List(c.Query("model"), limit)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"runs": runs})
+}
+
+// GET /api/runs/:id
+func getRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithError(400, fmt.Errorf("invalid run id: %s", c.Param("id")))
+		return
+	}
+
+	run, err := history.Get(id)
+	if err == history.ErrNotFound {
+		c.AbortWithError(404, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	c.JSON(200, run)
+}
+
+// GET /api/runs/:id/log
+//
+// Stream the log of the run, it keeps following the log until the run is finished.
+func getRunLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithError(400, fmt.Errorf("invalid run id: %s", c.Param("id")))
+		return
+	}
+
+	run, err := history.Get(id)
+	if err == history.ErrNotFound {
+		c.AbortWithError(404, err)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	file, err := os.Open(history.LogPath(id))
+	if os.IsNotExist(err) {
+		c.AbortWithError(404, fmt.Errorf("log of run %d not found", id))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	bf := bufio.NewReader(file)
+	clientGone := c.Request.Context().Done()
+
+	for {
+		line, err := bf.ReadString('\n')
+		if len(line) > 0 {
+			if _, err := c.Writer.WriteString(line); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			continue
+		}
+		if err != io.EOF || run.Status != history.StatusRunning {
+			return
+		}
+
+		select {
+		case <-clientGone:
+			return
+		case <-time.After(runLogInterval):
+		}
+
+		if run, err = history.Get(id); err != nil {
+			return
+		}
+	}
 }
 
 // GET /api/list?model=xxx&parent=
@@ -539,7 +753,13 @@ func list(c *gin.Context) {
 		parent = "/"
 	}
 
-	files, err := storage.List(m.Config, parent)
+	modelConfig, err := config.ResolveSecrets(c.Request.Context(), m.Config)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	files, err := storage.List(modelConfig, parent)
 	if err != nil {
 		c.AbortWithError(500, err)
 		return
@@ -563,7 +783,17 @@ func download(c *gin.Context) {
 		return
 	}
 
-	downloadResult, err := storageDownload(m.Config, file)
+	modelConfig, err := config.ResolveSecrets(c.Request.Context(), m.Config)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	downloadResult, err := storageDownload(modelConfig, file)
+	if errors.Is(err, storage.ErrInvalidFileKey) {
+		c.AbortWithError(400, err)
+		return
+	}
 	if err != nil || downloadResult == nil {
 		c.AbortWithError(500, err)
 		return
@@ -646,10 +876,20 @@ func tailFile() chan string {
 	bf := bufio.NewReader(file)
 
 	go func() {
+		current := logFile
 		for {
 			line, _, _ := bf.ReadLine()
 
 			if len(line) == 0 {
+				// follow the new log file when it is rotated
+				if rotated, ok := reopenRotated(current); ok {
+					if current != logFile {
+						current.Close()
+					}
+					current = rotated
+					bf.Reset(current)
+					continue
+				}
 				time.Sleep(50 * time.Millisecond)
 			} else {
 				out_chan <- string(line)
@@ -659,3 +899,22 @@ func tailFile() chan string {
 
 	return out_chan
 }
+
+// reopenRotated open the log file again when file has been rotated
+func reopenRotated(file *os.File) (*os.File, bool) {
+	info, err := os.Stat(config.LogFilePath)
+	if err != nil {
+		return nil, false
+	}
+
+	current, err := file.Stat()
+	if err != nil || os.SameFile(info, current) {
+		return nil, false
+	}
+
+	rotated, err := os.Open(config.LogFilePath)
+	if err != nil {
+		return nil, false
+	}
+	return rotated, true
+}
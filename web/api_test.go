@@ -2,16 +2,23 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gobackup/gobackup/config"
+	"github.com/gobackup/gobackup/history"
+	"github.com/gobackup/gobackup/job"
+	"github.com/gobackup/gobackup/model"
 	"github.com/gobackup/gobackup/storage"
 	"github.com/longbridgeapp/assert"
 	"github.com/spf13/viper"
@@ -61,6 +68,12 @@ func TestAPIStatus(t *testing.T) {
 	assertMatchJSON(t, gin.H{"message": "GoBackup is running.", "version": "master"}, body)
 }
 
+func TestAPIMetrics(t *testing.T) {
+	code, body := invokeHttp("GET", "/metrics", nil, nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, body, `gobackup_runs_total{model="test_model",result="success"}`)
+}
+
 func TestAPIGetModels(t *testing.T) {
 	code, _ := invokeHttp("GET", "/api/config", nil, nil)
 
@@ -71,7 +84,27 @@ func TestAPIPostPeform(t *testing.T) {
 	code, body := invokeHttp("POST", "/api/perform", nil, gin.H{"model": "test_model"})
 
 	assert.Equal(t, 200, code)
-	assertMatchJSON(t, gin.H{"message": "Backup: test_model performed in background."}, body)
+	var res struct {
+		Message string  `json:"message"`
+		Job     job.Job `json:"job"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &res))
+	assert.Equal(t, "Backup: test_model performed in background.", res.Message)
+	assert.Equal(t, "test_model", res.Job.Model)
+	assert.Equal(t, history.TriggerWeb, res.Job.Trigger)
+
+	code, body = invokeHttp("GET", "/api/jobs", nil, nil)
+	assert.Equal(t, 200, code)
+	var jobs struct {
+		Jobs []job.Job `json:"jobs"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &jobs))
+	assert.Equal(t, res.Job.ID, jobs.Jobs[0].ID)
+
+	code, _ = invokeHttp("POST", "/api/jobs/abc/cancel", nil, nil)
+	assert.Equal(t, 400, code)
+	code, _ = invokeHttp("POST", fmt.Sprintf("/api/jobs/%d/cancel", res.Job.ID+1000), nil, nil)
+	assert.Equal(t, 404, code)
 }
 
 func TestAPIDownloadStreamsLocalFile(t *testing.T) {
@@ -107,8 +140,47 @@ func TestAPIDownloadStreamsLocalFile(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/api/download?model=download_test&path="+fileName, nil)
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, 500, w.Code)
-	assert.Contains(t, w.Body.String(), "Local is not support download")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, fileContent, w.Body.String())
+	assert.Equal(t, "attachment; filename=\"backup.tar.gz\"", w.Header().Get("Content-Disposition"))
+}
+
+func TestAPIDownloadRejectsPathTraversal(t *testing.T) {
+	tempDir := t.TempDir()
+	storagePath := filepath.Join(tempDir, "backups")
+	assert.NoError(t, os.MkdirAll(storagePath, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "secret"), []byte("secret payload"), 0644))
+
+	originalModels := config.Models
+	defer func() {
+		config.Models = originalModels
+	}()
+
+	config.Models = []config.ModelConfig{{
+		Name:           "download_test",
+		DefaultStorage: "local",
+		Storages: map[string]config.SubConfig{
+			"local": {
+				Name: "local",
+				Type: "local",
+				Viper: func() *viper.Viper {
+					vp := viper.New()
+					vp.Set("path", storagePath)
+					return vp
+				}(),
+			},
+		},
+	}}
+
+	r := setupRouter("master")
+	for _, file := range []string{"../secret", "../../../../etc/passwd", url.QueryEscape(filepath.Join(tempDir, "secret"))} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/download?model=download_test&path="+file, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 400, w.Code)
+		assert.NotContains(t, w.Body.String(), "secret payload")
+	}
 }
 
 func TestAPIDownloadStreamsReaderResult(t *testing.T) {
@@ -152,3 +224,108 @@ func TestAPIDownloadStreamsReaderResult(t *testing.T) {
 	assert.Equal(t, "attachment; filename=\"backup.tar.gz\"", w.Header().Get("Content-Disposition"))
 	assert.Equal(t, "14", w.Header().Get("Content-Length"))
 }
+
+func TestAPIVerify(t *testing.T) {
+	originalVerify := modelVerify
+	defer func() {
+		modelVerify = originalVerify
+	}()
+
+	var verifyOpts model.VerifyOptions
+	modelVerify = func(ctx context.Context, m *model.Model, opts model.VerifyOptions) ([]storage.VerifyResult, error) {
+		verifyOpts = opts
+		return []storage.VerifyResult{{
+			FileKey:  "backup.tar.gz",
+			Manifest: true,
+			Files:    []storage.VerifyFile{{Key: "backup.tar.gz", Size: 14, SHA256: "abc"}},
+		}}, nil
+	}
+
+	code, body := invokeHttp("POST", "/api/verify", nil, gin.H{"model": "test_model", "file": "backup.tar.gz", "deep": true})
+	assert.Equal(t, 200, code)
+	assert.Equal(t, model.VerifyOptions{FileKey: "backup.tar.gz", Deep: true}, verifyOpts)
+	assert.Equal(t, `{"ok":true,"results":[{"file_key":"backup.tar.gz","files":[{"key":"backup.tar.gz","size":14,"sha256":"abc"}],"manifest":true}]}`, body)
+
+	code, _ = invokeHttp("POST", "/api/verify", nil, gin.H{"model": "not_exist"})
+	assert.Equal(t, 404, code)
+}
+
+func TestAPIRuns(t *testing.T) {
+	run := history.Start("test_model", history.TriggerWeb)
+	run.Finish(nil)
+
+	code, body := invokeHttp("GET", "/api/runs?model=test_model&limit=1", nil, nil)
+	assert.Equal(t, 200, code)
+	var res struct {
+		Runs []history.Run `json:"runs"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &res))
+	assert.Equal(t, 1, len(res.Runs))
+	assert.Equal(t, run.ID, res.Runs[0].ID)
+	assert.Equal(t, history.StatusSuccess, res.Runs[0].Status)
+
+	code, _ = invokeHttp("GET", "/api/runs?limit=abc", nil, nil)
+	assert.Equal(t, 400, code)
+
+	code, body = invokeHttp("GET", fmt.Sprintf("/api/runs/%d", run.ID), nil, nil)
+	assert.Equal(t, 200, code)
+	var got history.Run
+	assert.NoError(t, json.Unmarshal([]byte(body), &got))
+	assert.Equal(t, "test_model", got.Model)
+	assert.Equal(t, history.TriggerWeb, got.Trigger)
+
+	code, _ = invokeHttp("GET", "/api/runs/abc", nil, nil)
+	assert.Equal(t, 400, code)
+	code, _ = invokeHttp("GET", fmt.Sprintf("/api/runs/%d", run.ID+1000000), nil, nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestAPIRunLog(t *testing.T) {
+	run := history.Start("test_model", history.TriggerWeb)
+
+	code, _ := invokeHttp("GET", fmt.Sprintf("/api/runs/%d/log", run.ID), nil, nil)
+	assert.Equal(t, 404, code)
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(history.LogPath(run.ID)), 0750))
+	assert.NoError(t, os.WriteFile(history.LogPath(run.ID), []byte("line 1\nline 2\n"), 0640))
+	defer os.Remove(history.LogPath(run.ID))
+
+	// the stream is ended after the run is finished
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		f, _ := os.OpenFile(history.LogPath(run.ID), os.O_WRONLY|os.O_APPEND, 0640)
+		f.WriteString("line 3\n")
+		f.Close()
+		run.Finish(nil)
+	}()
+
+	code, body := invokeHttp("GET", fmt.Sprintf("/api/runs/%d/log", run.ID), nil, nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "line 1\nline 2\nline 3\n", body)
+
+	code, _ = invokeHttp("GET", "/api/runs/abc/log", nil, nil)
+	assert.Equal(t, 400, code)
+	code, _ = invokeHttp("GET", fmt.Sprintf("/api/runs/%d/log", run.ID+1000000), nil, nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestAllowedConfigPathsWithModels(t *testing.T) {
+	originalModels := config.Models
+	t.Cleanup(func() {
+		config.Models = originalModels
+	})
+
+	currentPath := config.CurrentConfigFilePath()
+	config.Models = []config.ModelConfig{
+		{Name: "b", ConfigFile: currentPath},
+		{Name: "a", ConfigFile: currentPath},
+		{Name: "c", ConfigFile: "/etc/gobackup/models.d/c.yml"},
+	}
+
+	paths := allowedConfigPaths()
+	assert.Equal(t, currentPath, paths[0].Path)
+	assert.Equal(t, []string{"a", "b"}, paths[0].Models)
+	for _, path := range paths[1:] {
+		assert.Nil(t, path.Models)
+	}
+}
@@ -19,6 +19,7 @@ import { api } from '@/lib/api';
 interface ConfigPathOption {
   path: string;
   exists: boolean;
+  models?: string[];
 }
 
 type MonacoEnvironment = {
@@ -435,6 +436,7 @@ ${CREATE_CONFIRMATION_MESSAGE}`);
                       {existingPaths.length > 0 && existingPaths.map((pathOption) => (
                         <option key={pathOption.path} value={pathOption.path}>
                           {pathOption.path}
+                          {pathOption.models && pathOption.models.length > 0 ? ` (${pathOption.models.join(', ')})` : ''}
                         </option>
                       ))}
                       {missingPaths.length > 0 && missingPaths.map((pathOption) => (
@@ -24,6 +24,13 @@ export interface ModelCardProps {
   onBrowse: (key: string) => void;
 }
 
+const formatDuration = (ms: number): string => {
+  if (ms < 1000) {
+    return `${ms}ms`;
+  }
+  return `${(ms / 1000).toFixed(1)}s`;
+};
+
 export default function ModelCard({
   modelKey,
   model,
@@ -31,6 +38,7 @@ export default function ModelCard({
   onBrowse,
 }: ModelCardProps) {
   const scheduleEnable = model.schedule?.enabled;
+  const lastRun = model.last_run;
 
   return (
     <Card appearance="filled-alternative" className="hover:shadow-md transition-shadow">
@@ -44,6 +52,13 @@ export default function ModelCard({
                 {model.schedule_info}
               </div>
             )}
+            {lastRun && (
+              <Tooltip content={lastRun.error || `Finished in ${formatDuration(lastRun.duration_ms)}`} relationship="description">
+                <div className={`text-xs font-medium mt-1 ${lastRun.status === 'success' ? 'text-green-600' : 'text-red-600'}`}>
+                  Last {lastRun.status === 'success' ? 'success' : 'failure'}: {new Date(lastRun.finished_at).toLocaleString()}
+                </div>
+              </Tooltip>
+            )}
           </div>
         }
       />
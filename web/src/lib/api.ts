@@ -90,6 +90,8 @@ export interface ModelConfig {
     enabled: boolean;
   };
   schedule_info?: string;
+  last_run?: Run;
+  config_file?: string;
 }
 
 export interface ConfigResponse {
@@ -101,6 +103,7 @@ export interface ConfigPathsResponse {
   allowed_paths?: Array<{
     path: string;
     exists: boolean;
+    models?: string[];
   }>;
   current_path?: string;
 }
@@ -124,8 +127,63 @@ export interface ListResponse {
   files: FileItem[];
 }
 
+export interface JobStep {
+  model: string;
+  status: 'queued' | 'running' | 'success' | 'failure' | 'canceled' | 'skipped';
+  error?: string;
+}
+
+export interface Job {
+  id: number;
+  model: string;
+  trigger: 'cli' | 'scheduler' | 'web';
+  status: 'queued' | 'running' | 'success' | 'failure' | 'canceled';
+  queued_at: string;
+  started_at?: string;
+  finished_at?: string;
+  error?: string;
+  steps?: JobStep[];
+}
+
+export interface JobsResponse {
+  jobs: Job[];
+}
+
 export interface PerformResponse {
   message: string;
+  job?: Job;
+}
+
+export interface RunStage {
+  name: string;
+  duration_ms: number;
+}
+
+export interface RunStorage {
+  name: string;
+  type: string;
+  duration_ms: number;
+  removed: number;
+  attempts: number;
+  error?: string;
+}
+
+export interface Run {
+  id: number;
+  model: string;
+  trigger: 'cli' | 'scheduler' | 'web';
+  status: 'running' | 'success' | 'failure';
+  started_at: string;
+  finished_at: string;
+  duration_ms: number;
+  stages: RunStage[];
+  archive_size: number;
+  storages: RunStorage[];
+  error?: string;
+}
+
+export interface RunsResponse {
+  runs: Run[];
 }
 
 export const api = {
@@ -184,6 +242,30 @@ export const api = {
     return `${API_URL}/download?${new URLSearchParams({ model, path }).toString()}`;
   },
 
+  listRuns: async (model?: string, limit: number = 50): Promise<RunsResponse> => {
+    const query = new URLSearchParams({ limit: String(limit) });
+    if (model) {
+      query.set('model', model);
+    }
+    return requestJSON<RunsResponse>(`${API_URL}/runs?${query.toString()}`);
+  },
+
+  getRun: async (id: number): Promise<Run> => {
+    return requestJSON<Run>(`${API_URL}/runs/${id}`);
+  },
+
+  getRunLogUrl: (id: number): string => {
+    return `${API_URL}/runs/${id}/log`;
+  },
+
+  listJobs: async (): Promise<JobsResponse> => {
+    return requestJSON<JobsResponse>(`${API_URL}/jobs`);
+  },
+
+  cancelJob: async (id: number): Promise<PerformResponse> => {
+    return requestJSON<PerformResponse>(`${API_URL}/jobs/${id}/cancel`, { method: 'POST' });
+  },
+
   getLogStreamUrl: (): string => {
     return `${API_URL}/log`;
   },